@@ -11,11 +11,13 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // часовые пояса пользователей нужны и в образе без системной tzdata
 )
 
 func Run() error {
@@ -40,30 +42,105 @@ func Run() error {
 	}
 
 	repo := postgres.New(dbPool)
-	assignmentSvc := service.NewReviewerAssignmentService(repo, repo, repo)
-	teamSvc := service.NewTeamService(repo, repo)
-	prSvc := service.NewPRService(repo, repo, assignmentSvc)
-	userSvc := service.NewUserService(repo, repo)
-
-	teamHandler := handler.NewTeamHandler(teamSvc)
+	assignmentSvc := service.NewReviewerAssignmentService(repo, repo, repo, repo, repo, repo, repo, repo, rand.NewSource(time.Now().UnixNano()))
+	staffingWorker := service.NewStaffingWorker(repo, repo, repo, repo, assignmentSvc, time.Minute)
+	teamSvc := service.NewTeamService(repo, repo, repo, repo, repo, repo, assignmentSvc, staffingWorker)
+	prSvc := service.NewPRService(repo, repo, repo, repo, assignmentSvc, staffingWorker)
+	userSvc := service.NewUserService(repo, repo, repo, repo, repo, assignmentSvc, staffingWorker)
+	syncSvc := service.NewTeamSyncService(repo, repo, repo, repo, staffingWorker)
+	scimSvc := service.NewScimService(repo, repo, repo, repo, teamSvc, staffingWorker)
+	rosterSvc := service.NewRosterService(repo, repo, repo, assignmentSvc, teamSvc, staffingWorker)
+	identitySvc := service.NewIdentityService(repo, repo)
+	repositorySvc := service.NewRepositoryService(repo, repo)
+	orgSvc := service.NewOrganizationService(repo, repo)
+
+	// Фоновый добор ревьюверов на недоукомплектованные PR
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	go staffingWorker.Run(workerCtx)
+
+	teamHandler := handler.NewTeamHandler(teamSvc, syncSvc)
 	prHandler := handler.NewPRHandler(prSvc, userSvc)
 	userHandler := handler.NewUserHandler(userSvc, prSvc)
 	statsHandler := handler.NewStatsHandler(userSvc)
+	scimHandler := handler.NewScimHandler(scimSvc, userSvc)
+	rosterHandler := handler.NewRosterHandler(rosterSvc)
+	identityHandler := handler.NewIdentityHandler(identitySvc)
+	repositoryHandler := handler.NewRepositoryHandler(repositorySvc)
 	healthHandler := handler.NewHealthHandler()
+	// Без ключа запросы отклоняются. TRUST_ORG_HEADER=true — только за доверенным
+	// шлюзом: запрос без ключа относится к организации из X-Organization, а его
+	// автора называет X-Actor-ID
+	tenantMiddleware := handler.NewTenantMiddleware(orgSvc, os.Getenv("TRUST_ORG_HEADER") == "true")
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /team/add", teamHandler.AddTeam)
 	mux.HandleFunc("GET /team/get", teamHandler.GetTeam)
+	mux.HandleFunc("POST /team/update", teamHandler.UpdateTeam)
+	mux.HandleFunc("POST /team/delete", teamHandler.DeleteTeam)
+	mux.HandleFunc("POST /team/setPolicy", teamHandler.SetPolicy)
+	mux.HandleFunc("POST /team/setParent", teamHandler.SetParent)
+	mux.HandleFunc("POST /team/setRole", teamHandler.SetRole)
+	mux.HandleFunc("POST /team/sync", teamHandler.Sync)
+	mux.HandleFunc("POST /team/rules/add", teamHandler.AddRule)
+	mux.HandleFunc("GET /team/rules", teamHandler.GetRules)
+	mux.HandleFunc("POST /team/rules/delete", teamHandler.DeleteRule)
+	mux.HandleFunc("POST /repository/add", repositoryHandler.AddRepository)
+	mux.HandleFunc("GET /repository/get", repositoryHandler.GetRepository)
+	mux.HandleFunc("GET /repository/list", repositoryHandler.ListRepositories)
+	mux.HandleFunc("POST /repository/update", repositoryHandler.UpdateRepository)
 	mux.HandleFunc("POST /users/setIsActive", userHandler.SetActive)
+	mux.HandleFunc("POST /users/setWorkingHours", userHandler.SetWorkingHours)
+	mux.HandleFunc("POST /users/setCapacity", userHandler.SetCapacity)
+	mux.HandleFunc("POST /users/setSkills", userHandler.SetSkills)
+	mux.HandleFunc("POST /users/setSeniority", userHandler.SetSeniority)
 	mux.HandleFunc("GET /users/getReview", userHandler.GetReview)
+	mux.HandleFunc("POST /users/offboard", userHandler.Offboard)
+	mux.HandleFunc("POST /users/import", rosterHandler.Import)
+	mux.HandleFunc("GET /users/export", rosterHandler.Export)
+	mux.HandleFunc("GET /users/identities", identityHandler.GetIdentities)
+	mux.HandleFunc("POST /users/identities/link", identityHandler.LinkIdentity)
+	mux.HandleFunc("POST /users/identities/unlink", identityHandler.UnlinkIdentity)
+	mux.HandleFunc("GET /users/resolve", identityHandler.Resolve)
+	mux.HandleFunc("POST /users/move", teamHandler.MoveUser)
+	mux.HandleFunc("GET /users/moves", teamHandler.GetMoves)
+	mux.HandleFunc("GET /users/teams", teamHandler.GetMemberships)
+	mux.HandleFunc("POST /users/addTeam", teamHandler.AddMembership)
+	mux.HandleFunc("POST /users/removeTeam", teamHandler.RemoveMembership)
+	mux.HandleFunc("POST /users/setPrimaryTeam", teamHandler.SetPrimaryTeam)
 	mux.HandleFunc("POST /pullRequest/create", prHandler.CreatePR)
+	mux.HandleFunc("POST /pullRequest/previewAssignment", prHandler.PreviewAssignment)
 	mux.HandleFunc("POST /pullRequest/merge", prHandler.MergePR)
 	mux.HandleFunc("POST /pullRequest/reassign", prHandler.ReassignReviewer)
+	mux.HandleFunc("POST /pullRequest/addReviewer", prHandler.AddReviewer)
+	mux.HandleFunc("POST /pullRequest/removeReviewer", prHandler.RemoveReviewer)
+	mux.HandleFunc("GET /pullRequest/understaffed", prHandler.GetUnderstaffed)
+	mux.HandleFunc("GET /pullRequest/assignmentHistory", prHandler.GetAssignmentHistory)
+	mux.HandleFunc("POST /pullRequest/replayAssignment", prHandler.ReplayAssignment)
 	mux.HandleFunc("GET /stats", statsHandler.GetStats)
 	mux.HandleFunc("GET /stats/reviewers", statsHandler.GetReviewerStats)
 	mux.HandleFunc("GET /stats/prs", statsHandler.GetPRStats)
-	mux.HandleFunc("GET /health", healthHandler.Health)
-	mux.HandleFunc("GET /ready", healthHandler.Ready)
+	mux.HandleFunc("GET /stats/teams", statsHandler.GetTeamStats)
+
+	// SCIM принимает только SCIM-токен организации
+	scim := http.NewServeMux()
+	scim.HandleFunc("POST /scim/v2/Users", scimHandler.CreateUser)
+	scim.HandleFunc("GET /scim/v2/Users", scimHandler.ListUsers)
+	scim.HandleFunc("GET /scim/v2/Users/{id}", scimHandler.GetUser)
+	scim.HandleFunc("PATCH /scim/v2/Users/{id}", scimHandler.PatchUser)
+	scim.HandleFunc("DELETE /scim/v2/Users/{id}", scimHandler.DeleteUser)
+	scim.HandleFunc("POST /scim/v2/Groups", scimHandler.CreateGroup)
+	scim.HandleFunc("GET /scim/v2/Groups", scimHandler.ListGroups)
+	scim.HandleFunc("GET /scim/v2/Groups/{id}", scimHandler.GetGroup)
+	scim.HandleFunc("PATCH /scim/v2/Groups/{id}", scimHandler.PatchGroup)
+	scim.HandleFunc("DELETE /scim/v2/Groups/{id}", scimHandler.DeleteGroup)
+
+	// Проверки живости не привязаны к организации
+	root := http.NewServeMux()
+	root.Handle("/", tenantMiddleware.Wrap(mux))
+	root.Handle("/scim/v2/", tenantMiddleware.WrapScim(scim))
+	root.HandleFunc("GET /health", healthHandler.Health)
+	root.HandleFunc("GET /ready", healthHandler.Ready)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -72,7 +149,7 @@ func Run() error {
 
 	server := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: root,
 	}
 
 	log.Printf("Server starting on :%s", port)
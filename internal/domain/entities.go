@@ -2,40 +2,509 @@
 package domain
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
 // User — пользователь системы
 type User struct {
-	UserID    string    `json:"user_id"`
-	Username  string    `json:"username"`
-	TeamName  string    `json:"team_name"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	UserID         string     `json:"user_id"`
+	Username       string     `json:"username"`
+	TeamName       string     `json:"team_name"`
+	IsActive       bool       `json:"is_active"`
+	Timezone       string     `json:"timezone"`                   // IANA-имя, например Europe/Moscow
+	WorkStartHour  int        `json:"work_start_hour"`            // начало рабочего дня (локальное время, включительно)
+	WorkEndHour    int        `json:"work_end_hour"`              // конец рабочего дня (локальное время, не включительно)
+	MaxOpenReviews *int       `json:"max_open_reviews,omitempty"` // nil — используется значение команды
+	Skills         []string   `json:"skills"`                     // навыки для подбора по меткам PR: go, postgres, frontend
+	Seniority      string     `json:"seniority"`                  // intern, junior, middle или senior
+	Email          string     `json:"email,omitempty"`
+	Role           string     `json:"role,omitempty"`        // роль в команде, в составе которой загружен пользователь
+	DepartedAt     *time.Time `json:"departed_at,omitempty"` // пользователь ушел; история сохраняется
+	CreatedAt      time.Time  `json:"created_at,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
+}
+
+// AnonymizedUsername — имя, которое получает пользователь после анонимизации
+const AnonymizedUsername = "anonymized user"
+
+// Рабочие часы по умолчанию для новых пользователей
+const (
+	DefaultTimezone      = "UTC"
+	DefaultWorkStartHour = 9
+	DefaultWorkEndHour   = 18
+)
+
+// UnsetWorkHour — граница рабочего окна не указана в запросе
+const UnsetWorkHour = -1
+
+// IsWorkingAt сообщает, попадает ли момент t в рабочие часы пользователя;
+// неизвестный часовой пояс — ошибка time.LoadLocation
+func (u User) IsWorkingAt(t time.Time) (bool, error) {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return false, err
+	}
+	return u.IsWorkingHour(t.In(loc).Hour()), nil
+}
+
+// IsWorkingHour сообщает, попадает ли местный час hour в рабочие часы пользователя.
+// Окно может переходить через полночь (например, 22–6); при равных границах
+// пользователь считается доступным круглосуточно.
+func (u User) IsWorkingHour(hour int) bool {
+	switch {
+	case u.WorkStartHour == u.WorkEndHour:
+		return true
+	case u.WorkStartHour < u.WorkEndHour:
+		return hour >= u.WorkStartHour && hour < u.WorkEndHour
+	default:
+		return hour >= u.WorkStartHour || hour < u.WorkEndHour
+	}
+}
+
+// ValidateWorkingHours проверяет часовой пояс и границы рабочего окна
+func ValidateWorkingHours(timezone string, startHour, endHour int) error {
+	if _, err := time.LoadLocation(timezone); timezone == "" || err != nil {
+		return NewError(ErrorCodeInvalidInput, "unknown timezone: "+timezone)
+	}
+	if startHour < 0 || startHour > 23 || endHour < 0 || endHour > 24 {
+		return NewError(ErrorCodeInvalidInput, "working hours must be within 0..24")
+	}
+	return nil
+}
+
+// Уровни пользователей
+const (
+	SeniorityIntern = "intern"
+	SeniorityJunior = "junior"
+	SeniorityMiddle = "middle" // по умолчанию
+	SenioritySenior = "senior"
+)
+
+// ValidateSeniority проверяет уровень пользователя
+func ValidateSeniority(seniority string) error {
+	switch seniority {
+	case SeniorityIntern, SeniorityJunior, SeniorityMiddle, SenioritySenior:
+		return nil
+	default:
+		return NewError(ErrorCodeInvalidInput, "unknown seniority: "+seniority)
+	}
+}
+
+// Роли участников команды
+const (
+	TeamRoleLead       = "lead"       // управляет составом, может быть обязательным ревьювером
+	TeamRoleMaintainer = "maintainer" // управляет составом
+	TeamRoleMember     = "member"     // по умолчанию
+)
+
+// ValidateTeamRole проверяет роль участника команды
+func ValidateTeamRole(role string) error {
+	switch role {
+	case TeamRoleLead, TeamRoleMaintainer, TeamRoleMember:
+		return nil
+	default:
+		return NewError(ErrorCodeInvalidInput, "unknown team role: "+role)
+	}
+}
+
+// CanManageTeam сообщает, может ли участник с ролью role менять состав команды
+func CanManageTeam(role string) bool {
+	return role == TeamRoleLead || role == TeamRoleMaintainer
+}
+
+// IsLead сообщает, является ли пользователь лидом команды
+func (u User) IsLead() bool {
+	return u.Role == TeamRoleLead
+}
+
+// IsSenior сообщает, может ли пользователь быть старшим ревьювером
+func (u User) IsSenior() bool {
+	return u.Seniority == SenioritySenior
+}
+
+// IsJunior сообщает, подходит ли пользователь в «теневые» ревьюверы
+func (u User) IsJunior() bool {
+	return u.Seniority == SeniorityIntern || u.Seniority == SeniorityJunior
+}
+
+// OpenReviewsLimit возвращает лимит одновременно открытых ревью пользователя
+// с учетом значения команды по умолчанию; ok=false — лимита нет
+func (u User) OpenReviewsLimit(policy TeamPolicy) (limit int, ok bool) {
+	switch {
+	case u.MaxOpenReviews != nil:
+		return *u.MaxOpenReviews, true
+	case policy.DefaultMaxOpenReviews != nil:
+		return *policy.DefaultMaxOpenReviews, true
+	default:
+		return 0, false
+	}
+}
+
+// NormalizeTags приводит навыки/метки к нижнему регистру, убирает пустые и дубликаты
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
 }
 
 // Team — команда (с загруженными участниками, если нужно)
 type Team struct {
+	TeamName   string     `json:"team_name"`
+	ParentTeam string     `json:"parent_team,omitempty"` // пусто — команда верхнего уровня
+	Members    []User     `json:"members,omitempty"`     // опционально, если запрашиваем с участниками
+	Subteams   []Team     `json:"subteams,omitempty"`    // опционально, если запрашиваем поддерево
+	Policy     TeamPolicy `json:"policy"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+// TeamMembership — членство пользователя в команде. Основная команда совпадает
+// с User.TeamName: по ней определяются политика PR пользователя и статистика.
+type TeamMembership struct {
+	UserID    string    `json:"user_id"`
 	TeamName  string    `json:"team_name"`
-	Members   []User    `json:"members,omitempty"` // опционально, если запрашиваем с участниками
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	IsPrimary bool      `json:"is_primary"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewHandover — судьба открытого ревью при переходе ревьювера в другую команду
+type ReviewHandover struct {
+	Repository    string `json:"repository"`
+	PullRequestID string `json:"pull_request_id"`
+	ReplacedBy    string `json:"replaced_by,omitempty"` // пусто — замены не нашлось, ревью осталось за пользователем
+}
+
+// Offboarding — результат ухода пользователя
+type Offboarding struct {
+	User       *User            `json:"user"`
+	Handovers  []ReviewHandover `json:"handovers"` // без ReplacedBy — ревьювер снят, PR поставлен в очередь добора
+	Anonymized bool             `json:"anonymized"`
+}
+
+// TeamMove — переход пользователя между командами
+type TeamMove struct {
+	MoveID    int64            `json:"move_id"`
+	UserID    string           `json:"user_id"`
+	FromTeam  string           `json:"from_team"` // пусто — пользователь был без команды
+	ToTeam    string           `json:"to_team"`   // пусто — пользователь остался без команды
+	Handovers []ReviewHandover `json:"handovers"`
+	MovedAt   time.Time        `json:"moved_at"`
+}
+
+// Действия синхронизации команд с декларативной конфигурацией, в порядке применения
+const (
+	SyncCreateTeam      = "create_team"
+	SyncUpdatePolicy    = "update_policy"
+	SyncSetParent       = "set_parent"
+	SyncAddMember       = "add_member"    // новый пользователь или пользователь без команды
+	SyncMoveMember      = "move_member"   // смена основной команды
+	SyncUpdateMember    = "update_member" // изменение атрибутов пользователя
+	SyncRemoveMember    = "remove_member" // исключение из основной команды
+	SyncAddSecondary    = "add_secondary"
+	SyncRemoveSecondary = "remove_secondary"
+	SyncSetRole         = "set_role"
+)
+
+// TeamSyncChange — одно изменение плана синхронизации
+type TeamSyncChange struct {
+	Action   string `json:"action"`
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// TeamSyncPlan — изменения, приводящие БД к конфигурации; Applied — выполнены ли они
+type TeamSyncPlan struct {
+	Changes []TeamSyncChange `json:"changes"`
+	Applied bool             `json:"applied"`
+}
+
+// Действия импорта строки CSV-реестра
+const (
+	RosterCreate    = "create"    // новый пользователь
+	RosterUpdate    = "update"    // изменились имя или активность
+	RosterMove      = "move"      // сменилась основная команда
+	RosterUnchanged = "unchanged" // строка совпадает с БД
+)
+
+// RosterImportRow — результат импорта одной строки CSV; Row — номер строки в файле
+type RosterImportRow struct {
+	Row    int    `json:"row"`
+	UserID string `json:"user_id"`
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RosterImport — отчет об импорте реестра. Строки с ошибками не применяются,
+// и тогда не применяется весь файл.
+type RosterImport struct {
+	Rows         []RosterImportRow `json:"rows"`
+	CreatedTeams []string          `json:"created_teams"` // команды, которых не было в БД
+	Errors       int               `json:"errors"`
+	Applied      bool              `json:"applied"`
+}
+
+// Провайдеры внешних учетных записей пользователя
+const (
+	IdentityGitHub = "github" // логин GitHub
+	IdentityGitLab = "gitlab" // имя пользователя GitLab
+	IdentitySlack  = "slack"  // ID пользователя Slack
+	IdentityEmail  = "email"  // адрес почты
+)
+
+// UserIdentity — учетная запись пользователя во внешней системе
+type UserIdentity struct {
+	UserID     string    `json:"user_id"`
+	Provider   string    `json:"provider"`
+	ExternalID string    `json:"external_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NormalizeIdentity проверяет провайдера и приводит внешний ID к виду, в котором
+// он хранится: логины GitHub, GitLab и email не зависят от регистра, ID Slack — зависят
+func NormalizeIdentity(provider, externalID string) (string, error) {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		return "", NewError(ErrorCodeInvalidInput, "external_id is required")
+	}
+	switch provider {
+	case IdentityGitHub, IdentityGitLab, IdentityEmail:
+		return strings.ToLower(externalID), nil
+	case IdentitySlack:
+		return externalID, nil
+	default:
+		return "", NewError(ErrorCodeInvalidInput, "unknown identity provider: "+provider)
+	}
 }
 
-// PullRequest — полный объект PR для внешнего API
+// Что делать с открытыми PR участников при удалении команды
+const (
+	TeamDeleteReject = "reject" // отказать, пока у участников есть открытые PR
+	TeamDeleteDetach = "detach" // удалить: участники и их открытые PR остаются, но без команды и добора
+)
+
+// TeamPolicy — настройки назначения ревьюверов на уровне команды
+type TeamPolicy struct {
+	DefaultMaxOpenReviews *int   `json:"default_max_open_reviews"` // nil — без ограничения
+	Strategy              string `json:"strategy"`                 // стратегия ранжирования кандидатов
+	RequireSenior         bool   `json:"require_senior"`           // среди ревьюверов должен быть senior
+	RequireLead           bool   `json:"require_lead"`             // среди ревьюверов должен быть lead команды
+	ShadowJunior          bool   `json:"shadow_junior"`            // добавлять junior'а «в тень», без обязательного апрува
+
+	// Стратегия affinity: штраф за повторные пары автор–ревьювер за последние AffinityWindowDays дней
+	AffinityWindowDays int     `json:"affinity_window_days"`
+	AffinityWeight     float64 `json:"affinity_weight"`
+
+	// Ручное снятие ревьювера без замены не может оставить на PR меньше MinReviewers
+	MinReviewers int `json:"min_reviewers"`
+}
+
+// DefaultTeamPolicy возвращает настройки новой команды
+func DefaultTeamPolicy() TeamPolicy {
+	return TeamPolicy{
+		Strategy:           StrategyRandom,
+		AffinityWindowDays: 30,
+		AffinityWeight:     1.0,
+		MinReviewers:       1,
+	}
+}
+
+// Стратегии назначения ревьюверов
+const (
+	StrategyRandom   = "random"   // случайный выбор (по умолчанию)
+	StrategySkills   = "skills"   // совпадение навыков с метками PR с учетом загрузки
+	StrategyAffinity = "affinity" // разнообразие пар автор–ревьювер
+)
+
+// KnownStrategies — все поддерживаемые стратегии
+var KnownStrategies = []string{StrategyRandom, StrategySkills, StrategyAffinity}
+
+// Validate проверяет корректность настроек команды
+func (p TeamPolicy) Validate() error {
+	return p.validate(DefaultReviewersCount)
+}
+
+// validate проверяет настройки для PR, которому нужно reviewersCount ревьюверов
+func (p TeamPolicy) validate(reviewersCount int) error {
+	if p.DefaultMaxOpenReviews != nil && *p.DefaultMaxOpenReviews < 0 {
+		return NewError(ErrorCodeInvalidInput, "default_max_open_reviews must not be negative")
+	}
+	if !isKnownStrategy(p.Strategy) {
+		return NewError(ErrorCodeInvalidInput, "unknown strategy: "+p.Strategy)
+	}
+	if p.AffinityWindowDays < 1 {
+		return NewError(ErrorCodeInvalidInput, "affinity_window_days must be positive")
+	}
+	if p.AffinityWeight < 0 {
+		return NewError(ErrorCodeInvalidInput, "affinity_weight must not be negative")
+	}
+	if p.MinReviewers < 0 || p.MinReviewers > reviewersCount {
+		return NewError(ErrorCodeInvalidInput, "min_reviewers must be between 0 and the reviewers count")
+	}
+	return nil
+}
+
+func isKnownStrategy(name string) bool {
+	for _, known := range KnownStrategies {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Repository — репозиторий, в котором живут PR. Политика назначения
+// PR репозитория — политика команды автора с переопределениями Policy.
+type Repository struct {
+	Name      string           `json:"repository"`
+	OwnerTeam string           `json:"owner_team"` // команда-владелец; пусто — не задана
+	Policy    RepositoryPolicy `json:"policy"`
+	CreatedAt time.Time        `json:"created_at,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at,omitempty"`
+}
+
+// RepositoryPolicy — переопределения политики команды для PR репозитория;
+// nil — берется значение команды автора
+type RepositoryPolicy struct {
+	Strategy       *string  `json:"strategy,omitempty"`
+	RequireSenior  *bool    `json:"require_senior,omitempty"`
+	RequireLead    *bool    `json:"require_lead,omitempty"`
+	ShadowJunior   *bool    `json:"shadow_junior,omitempty"`
+	MinReviewers   *int     `json:"min_reviewers,omitempty"`
+	ReviewersCount *int     `json:"reviewers_count,omitempty"` // nil — DefaultReviewersCount
+	RequiredTeams  []string `json:"required_teams,omitempty"`  // от каждой команды нужен хотя бы один ревьювер
+	ExcludedPaths  []string `json:"excluded_paths,omitempty"`  // пути, которые не учитываются при подборе
+}
+
+// Apply возвращает политику PR: политику команды с переопределениями репозитория.
+// Если ревьюверов нужно меньше, чем MinReviewers команды, минимум снижается до их числа.
+func (p RepositoryPolicy) Apply(policy TeamPolicy) PRPolicy {
+	if p.Strategy != nil {
+		policy.Strategy = *p.Strategy
+	}
+	if p.RequireSenior != nil {
+		policy.RequireSenior = *p.RequireSenior
+	}
+	if p.RequireLead != nil {
+		policy.RequireLead = *p.RequireLead
+	}
+	if p.ShadowJunior != nil {
+		policy.ShadowJunior = *p.ShadowJunior
+	}
+	if p.MinReviewers != nil {
+		policy.MinReviewers = *p.MinReviewers
+	}
+	applied := PRPolicy{
+		TeamPolicy:     policy,
+		ReviewersCount: DefaultReviewersCount,
+		RequiredTeams:  p.RequiredTeams,
+		ExcludedPaths:  p.ExcludedPaths,
+	}
+	if p.ReviewersCount != nil {
+		applied.ReviewersCount = *p.ReviewersCount
+		if p.MinReviewers == nil && applied.MinReviewers > applied.ReviewersCount {
+			applied.MinReviewers = applied.ReviewersCount
+		}
+	}
+	return applied
+}
+
+// Validate проверяет переопределения по тем же правилам, что и настройки команды,
+// и нормализует исключенные пути
+func (p *RepositoryPolicy) Validate() error {
+	p.ExcludedPaths = NormalizePaths(p.ExcludedPaths)
+	seen := make(map[string]bool, len(p.RequiredTeams))
+	for _, team := range p.RequiredTeams {
+		if team == "" {
+			return NewError(ErrorCodeInvalidInput, "required team name must not be empty")
+		}
+		if seen[team] {
+			return NewError(ErrorCodeInvalidInput, "required team is listed twice: "+team)
+		}
+		seen[team] = true
+	}
+	return p.Apply(DefaultTeamPolicy()).Validate()
+}
+
+// MaxReviewersCount — сколько ревьюверов на PR может потребовать репозиторий
+const MaxReviewersCount = 10
+
+// PRPolicy — политика назначения ревьюверов на конкретный PR: политика команды
+// автора с переопределениями репозитория
+type PRPolicy struct {
+	TeamPolicy
+	ReviewersCount int      // сколько ревьюверов нужно PR
+	RequiredTeams  []string // команды, от каждой из которых нужен ревьювер
+	ExcludedPaths  []string // пути, не влияющие на подбор
+}
+
+// Validate проверяет политику PR
+func (p PRPolicy) Validate() error {
+	if p.ReviewersCount < 1 || p.ReviewersCount > MaxReviewersCount {
+		return NewError(ErrorCodeInvalidInput, fmt.Sprintf("reviewers_count must be between 1 and %d", MaxReviewersCount))
+	}
+	if len(p.RequiredTeams) > p.ReviewersCount {
+		return NewError(ErrorCodeInvalidInput, "required_teams must not outnumber reviewers_count")
+	}
+	return p.TeamPolicy.validate(p.ReviewersCount)
+}
+
+// Scope возвращает PR в том виде, в каком его видит подбор: без исключенных путей.
+// Исходный PR не меняется.
+func (p PRPolicy) Scope(pr *PullRequest) *PullRequest {
+	if len(p.ExcludedPaths) == 0 || len(pr.Paths) == 0 {
+		return pr
+	}
+	scoped := *pr
+	scoped.Paths = make([]string, 0, len(pr.Paths))
+	for _, path := range pr.Paths {
+		excluded := false
+		for _, prefix := range p.ExcludedPaths {
+			if touchesPath([]string{path}, prefix) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			scoped.Paths = append(scoped.Paths, path)
+		}
+	}
+	return &scoped
+}
+
+// PullRequest — полный объект PR для внешнего API. ID уникален в пределах
+// репозитория; PR без репозитория (Repository пуст) образуют отдельное пространство ID.
 type PullRequest struct {
+	Repository        string     `json:"repository"`
 	PullRequestID     string     `json:"pull_request_id"`
 	PullRequestName   string     `json:"pull_request_name"`
 	AuthorID          string     `json:"author_id"`
 	Status            string     `json:"status"`
 	AssignedReviewers []string   `json:"assigned_reviewers"`   // только ID ревьюверов
+	ShadowReviewers   []string   `json:"shadow_reviewers"`     // наблюдающие junior'ы, их апрув не требуется
+	Labels            []string   `json:"labels"`               // метки для подбора ревьюверов по навыкам
+	Paths             []string   `json:"paths"`                // измененные файлы для правил исключения
+	Understaffed      bool       `json:"understaffed"`         // ждет добора ревьюверов в очереди
 	CreatedAt         time.Time  `json:"created_at,omitempty"` // теперь единообразно: snake_case + omitempty
 	MergedAt          *time.Time `json:"merged_at,omitempty"`
 }
 
 // PullRequestShort — укороченная версия (например, для списка у ревьювера)
 type PullRequestShort struct {
+	Repository      string    `json:"repository"`
 	PullRequestID   string    `json:"pull_request_id"`
 	PullRequestName string    `json:"pull_request_name"`
 	AuthorID        string    `json:"author_id"`
@@ -49,17 +518,177 @@ const (
 	PRStatusMerged = "MERGED"
 )
 
+// DefaultReviewersCount — сколько ревьюверов требуется на PR
+const DefaultReviewersCount = 2
+
+// CandidateScore — оценка кандидата в ревьюверы с разбивкой по слагаемым
+type CandidateScore struct {
+	UserID    string             `json:"user_id"`
+	Score     float64            `json:"score"`
+	OnHours   bool               `json:"on_hours"` // сейчас рабочее время кандидата; такие идут первыми
+	Breakdown map[string]float64 `json:"breakdown,omitempty"`
+}
+
+// Причины исключения кандидата из пула
+const (
+	ExcludedAuthor     = "author"
+	ExcludedInactive   = "inactive"
+	ExcludedAssigned   = "already_assigned"
+	ExcludedReplaced   = "replaced"
+	ExcludedRule       = "rule"
+	ExcludedAtCapacity = "at_capacity"
+	ExcludedNotSenior  = "not_senior"
+	ExcludedNotLead    = "not_lead"
+)
+
+// Exclusion — исключенный из пула кандидат и причина
+type Exclusion struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// StrategyManual — ревьювер выбран человеком, а не стратегией
+const StrategyManual = "manual"
+
+// Assignment — результат подбора ревьюверов и его объяснение
+type Assignment struct {
+	Reviewers       []string         `json:"reviewers"`
+	ShadowReviewers []string         `json:"shadow_reviewers,omitempty"`
+	Requested       []string         `json:"requested,omitempty"` // запрошенные вручную, назначены без подбора
+	Strategy        string           `json:"strategy"`
+	Pool            []string         `json:"candidate_pool"`
+	Excluded        []Exclusion      `json:"excluded"`
+	Scores          []CandidateScore `json:"scores"`                 // все рассмотренные кандидаты в порядке предпочтения
+	EscalatedTo     []string         `json:"escalated_to,omitempty"` // родительские команды, добавленные в пул
+
+	// Входные данные подбора; для ручных назначений — nil
+	Snapshot *AssignmentSnapshot `json:"snapshot,omitempty"`
+}
+
+// CandidateSnapshot — признаки кандидата на момент подбора
+type CandidateSnapshot struct {
+	UserID      string   `json:"user_id"`
+	Seniority   string   `json:"seniority"`
+	Role        string   `json:"role,omitempty"`
+	Skills      []string `json:"skills,omitempty"`
+	OpenReviews int      `json:"open_reviews"`
+	RecentPairs int      `json:"recent_pairs"`
+	OnHours     bool     `json:"on_hours"`
+}
+
+// TeamCandidates — обязательная команда репозитория и ее кандидаты из снимка
+type TeamCandidates struct {
+	TeamName   string   `json:"team_name"`
+	Candidates []string `json:"candidates"`
+}
+
+// AssignmentSnapshot — все входные данные подбора, по которым выбор
+// воспроизводится детерминированно
+type AssignmentSnapshot struct {
+	Seed       int64               `json:"seed"`
+	Policy     TeamPolicy          `json:"policy"`
+	Labels     []string            `json:"labels,omitempty"`
+	Count      int                 `json:"count"`
+	NeedSenior bool                `json:"need_senior"`
+	NeedLead   bool                `json:"need_lead"`
+	NeedTeams  []TeamCandidates    `json:"need_teams,omitempty"` // обязательные команды, еще не представленные на PR
+	WithShadow bool                `json:"with_shadow"`
+	Candidates []CandidateSnapshot `json:"candidates"` // прошедшие все фильтры, в исходном порядке
+}
+
+// Exclude записывает исключение кандидата из пула
+func (a *Assignment) Exclude(userID, reason, detail string) {
+	a.Excluded = append(a.Excluded, Exclusion{UserID: userID, Reason: reason, Detail: detail})
+}
+
+// ReviewerScores возвращает оценки только выбранных ревьюверов
+func (a *Assignment) ReviewerScores() []CandidateScore {
+	scores := make([]CandidateScore, 0, len(a.Reviewers))
+	for _, score := range a.Scores {
+		for _, reviewer := range a.Reviewers {
+			if score.UserID == reviewer {
+				scores = append(scores, score)
+			}
+		}
+	}
+	return scores
+}
+
+// Alternates возвращает оценки кандидатов, не попавших ни в ревьюверы, ни в «тень»,
+// в порядке предпочтения
+func (a *Assignment) Alternates() []CandidateScore {
+	taken := make(map[string]bool, len(a.Reviewers)+len(a.ShadowReviewers))
+	for _, r := range a.Reviewers {
+		taken[r] = true
+	}
+	for _, r := range a.ShadowReviewers {
+		taken[r] = true
+	}
+	alternates := make([]CandidateScore, 0, len(a.Scores))
+	for _, score := range a.Scores {
+		if !taken[score.UserID] {
+			alternates = append(alternates, score)
+		}
+	}
+	return alternates
+}
+
+// Виды событий назначения
+const (
+	AssignmentEventCreate   = "create"
+	AssignmentEventFill     = "fill"
+	AssignmentEventReassign = "reassign"
+	AssignmentEventAdd      = "add"
+	AssignmentEventRemove   = "remove"
+)
+
+// AssignmentEvent — сохраненное решение о назначении ревьюверов
+type AssignmentEvent struct {
+	EventID       int64       `json:"event_id"`
+	Repository    string      `json:"repository"`
+	PullRequestID string      `json:"pull_request_id"`
+	Kind          string      `json:"kind"`
+	Reviewers     []string    `json:"reviewers"` // назначенные событием (для remove — снятые)
+	Explanation   *Assignment `json:"explanation,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// StaffingRequest — запись очереди PR, которым не хватило ревьюверов
+type StaffingRequest struct {
+	Repository       string     `json:"repository"`
+	PullRequestID    string     `json:"pull_request_id"`
+	MissingReviewers int        `json:"missing_reviewers"`
+	Reason           ErrorCode  `json:"reason"` // NO_CANDIDATE или NO_CAPACITY
+	Attempts         int        `json:"attempts"`
+	EnqueuedAt       time.Time  `json:"enqueued_at"`
+	LastAttemptAt    *time.Time `json:"last_attempt_at,omitempty"`
+}
+
 // === Доменные ошибки ===
 type ErrorCode string
 
 const (
-	ErrorCodeTeamExists   ErrorCode = "TEAM_EXISTS"
-	ErrorCodePRExists     ErrorCode = "PR_EXISTS"
-	ErrorCodePRMerged     ErrorCode = "PR_MERGED"
-	ErrorCodeNotAssigned  ErrorCode = "NOT_ASSIGNED"
-	ErrorCodeNoCandidate  ErrorCode = "NO_CANDIDATE"
-	ErrorCodeNotFound     ErrorCode = "NOT_FOUND"
-	ErrorCodeInvalidInput ErrorCode = "INVALID_INPUT"
+	ErrorCodeTeamExists    ErrorCode = "TEAM_EXISTS"
+	ErrorCodeUserExists    ErrorCode = "USER_EXISTS"
+	ErrorCodeIdentityTaken ErrorCode = "IDENTITY_TAKEN"
+	ErrorCodeRepoExists    ErrorCode = "REPOSITORY_EXISTS"
+	ErrorCodeOrgExists     ErrorCode = "ORGANIZATION_EXISTS"
+	ErrorCodeTeamHasOpenPR ErrorCode = "TEAM_HAS_OPEN_PRS"
+	ErrorCodeUserInTeam    ErrorCode = "USER_IN_OTHER_TEAM"
+	ErrorCodeUserDeparted  ErrorCode = "USER_DEPARTED"
+	ErrorCodePRExists      ErrorCode = "PR_EXISTS"
+	ErrorCodePRMerged      ErrorCode = "PR_MERGED"
+	ErrorCodeNotAssigned   ErrorCode = "NOT_ASSIGNED"
+	ErrorCodeAssigned      ErrorCode = "ALREADY_ASSIGNED"
+	ErrorCodeMinReviewers  ErrorCode = "MIN_REVIEWERS"
+	ErrorCodeNoCandidate   ErrorCode = "NO_CANDIDATE"
+	ErrorCodeNoCapacity    ErrorCode = "NO_CAPACITY"
+	ErrorCodeRuleViolation ErrorCode = "RULE_VIOLATION"
+	ErrorCodeNotFound      ErrorCode = "NOT_FOUND"
+	ErrorCodeInvalidInput  ErrorCode = "INVALID_INPUT"
+	ErrorCodeForbidden     ErrorCode = "FORBIDDEN"
+	ErrorCodeUnauthorized  ErrorCode = "UNAUTHORIZED"
 )
 
 type DomainError struct {
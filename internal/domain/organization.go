@@ -0,0 +1,84 @@
+// internal/domain/organization.go
+package domain
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// DefaultOrganization — организация, в которой живут данные, созданные до появления
+// организаций, и запросы без API-ключа и заголовка организации
+const DefaultOrganization = "default"
+
+// Organization — организация (бизнес-юнит). Команды, пользователи и PR разных
+// организаций изолированы друг от друга.
+type Organization struct {
+	OrgID     string    `json:"org_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var organizationIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateOrganizationID проверяет ID организации: строчные латинские буквы, цифры, - и _
+func ValidateOrganizationID(orgID string) error {
+	if !organizationIDPattern.MatchString(orgID) {
+		return NewError(ErrorCodeInvalidInput, "invalid organization id: "+orgID)
+	}
+	return nil
+}
+
+type organizationKey struct{}
+
+// WithOrganization возвращает контекст запроса к данным организации orgID
+func WithOrganization(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgID)
+}
+
+// OrganizationFrom получает организацию из контекста; без нее — DefaultOrganization
+func OrganizationFrom(ctx context.Context) string {
+	if orgID, ok := ctx.Value(organizationKey{}).(string); ok && orgID != "" {
+		return orgID
+	}
+	return DefaultOrganization
+}
+
+// Области действия API-ключа
+const (
+	APIKeyScopeAPI  = "api"  // обычный API
+	APIKeyScopeSCIM = "scim" // только провижининг через /scim/v2
+)
+
+// APIKey — владелец API-ключа: организация и, для ключа пользователя, сам
+// пользователь. Ключ без пользователя — ключ администратора организации.
+type APIKey struct {
+	OrgID  string
+	UserID string
+	Scope  string
+}
+
+// Actor — аутентифицированный автор запроса, от имени которого проверяются
+// права на изменение команд. Пустой UserID — администратор организации.
+type Actor struct {
+	UserID string
+}
+
+// IsAdmin сообщает, действует ли запрос от имени администратора организации
+func (a Actor) IsAdmin() bool {
+	return a.UserID == ""
+}
+
+type actorKey struct{}
+
+// WithActor возвращает контекст запроса от имени actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom получает автора запроса из контекста; ok == false, если личность
+// автора не установлена
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,108 @@
+// internal/domain/rules.go
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Виды правил исключения
+const (
+	RuleReviewerAuthor = "reviewer_author" // subject не ревьюит PR автора target
+	RuleUserPath       = "user_path"       // subject не ревьюит PR, затрагивающие путь target
+	RuleSeniorityPath  = "seniority_path"  // уровень subject не ревьюит PR, затрагивающие путь target
+)
+
+// ExclusionRule — правило команды, запрещающее назначение ревьювера
+type ExclusionRule struct {
+	RuleID    int64     `json:"rule_id"`
+	TeamName  string    `json:"team_name"`
+	Kind      string    `json:"kind"`
+	Subject   string    `json:"subject"`
+	Target    string    `json:"target"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Validate проверяет правило и нормализует путь в target
+func (r *ExclusionRule) Validate() error {
+	if r.Subject == "" || r.Target == "" {
+		return NewError(ErrorCodeInvalidInput, "rule subject and target are required")
+	}
+	switch r.Kind {
+	case RuleReviewerAuthor:
+		if r.Subject == r.Target {
+			return NewError(ErrorCodeInvalidInput, "reviewer and author must differ")
+		}
+	case RuleUserPath:
+		r.Target = normalizePath(r.Target)
+	case RuleSeniorityPath:
+		if err := ValidateSeniority(r.Subject); err != nil {
+			return err
+		}
+		r.Target = normalizePath(r.Target)
+	default:
+		return NewError(ErrorCodeInvalidInput, "unknown rule kind: "+r.Kind)
+	}
+	return nil
+}
+
+// Forbids сообщает, запрещает ли правило назначить reviewer на pr
+func (r ExclusionRule) Forbids(reviewer User, pr *PullRequest) bool {
+	switch r.Kind {
+	case RuleReviewerAuthor:
+		return reviewer.UserID == r.Subject && pr.AuthorID == r.Target
+	case RuleUserPath:
+		return reviewer.UserID == r.Subject && touchesPath(pr.Paths, r.Target)
+	case RuleSeniorityPath:
+		return reviewer.Seniority == r.Subject && touchesPath(pr.Paths, r.Target)
+	default:
+		return false
+	}
+}
+
+// Describe возвращает правило в читаемом виде для сообщений об ошибках
+func (r ExclusionRule) Describe() string {
+	if r.Kind == RuleSeniorityPath {
+		return fmt.Sprintf("rule #%d: %s reviewers must not review %s", r.RuleID, r.Subject, r.Target)
+	}
+	return fmt.Sprintf("rule #%d: %s must not review %s", r.RuleID, r.Subject, r.Target)
+}
+
+// FirstViolation возвращает первое правило, запрещающее назначение, или nil
+func FirstViolation(rules []ExclusionRule, reviewer User, pr *PullRequest) *ExclusionRule {
+	for i := range rules {
+		if rules[i].Forbids(reviewer, pr) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// touchesPath сообщает, затрагивает ли хотя бы один из путей каталог или файл prefix.
+// Префикс «migrations/» и «migrations» одинаково совпадают с «migrations/001.sql».
+func touchesPath(paths []string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	for _, path := range paths {
+		path = normalizePath(path)
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePath(path string) string {
+	return strings.TrimPrefix(strings.TrimSpace(path), "/")
+}
+
+// NormalizePaths приводит пути PR к виду без ведущего «/» и убирает пустые
+func NormalizePaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path = normalizePath(path); path != "" {
+			result = append(result, path)
+		}
+	}
+	return result
+}
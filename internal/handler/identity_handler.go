@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
+)
+
+// IdentityHandler обработчик внешних учетных записей пользователей
+type IdentityHandler struct {
+	identityService *service.IdentityService
+}
+
+// NewIdentityHandler создает новый handler
+func NewIdentityHandler(identityService *service.IdentityService) *IdentityHandler {
+	return &IdentityHandler{identityService: identityService}
+}
+
+// LinkIdentity обработчик POST /users/identities/link
+func (h *IdentityHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID     string `json:"user_id"`
+		Provider   string `json:"provider"`
+		ExternalID string `json:"external_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := h.identityService.LinkIdentity(r.Context(), req.UserID, req.Provider, req.ExternalID)
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"identity": identity})
+}
+
+// UnlinkIdentity обработчик POST /users/identities/unlink
+func (h *IdentityHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID   string `json:"user_id"`
+		Provider string `json:"provider"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.identityService.UnlinkIdentity(r.Context(), req.UserID, req.Provider); err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	identities, err := h.identityService.GetIdentities(r.Context(), req.UserID)
+	h.writeIdentities(w, req.UserID, identities, err)
+}
+
+// GetIdentities обработчик GET /users/identities
+func (h *IdentityHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	identities, err := h.identityService.GetIdentities(r.Context(), userID)
+	h.writeIdentities(w, userID, identities, err)
+}
+
+// Resolve обработчик GET /users/resolve?provider=github&external_id=alice
+// Параметр external_id можно повторять, чтобы разрешить несколько ID за запрос.
+func (h *IdentityHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	identities, unresolved, err := h.identityService.Resolve(r.Context(), query.Get("provider"), query["external_id"])
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"identities": identities,
+		"unresolved": unresolved,
+	})
+}
+
+func (h *IdentityHandler) writeIdentities(w http.ResponseWriter, userID string, identities []domain.UserIdentity, err error) {
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":    userID,
+		"identities": identities,
+	})
+}
+
+// writeIdentityError пишет ошибку операций с учетными записями
+func writeIdentityError(w http.ResponseWriter, err error) {
+	domErr, ok := err.(domain.DomainError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	statusCode := http.StatusBadRequest
+	switch domErr.Code {
+	case domain.ErrorCodeNotFound:
+		statusCode = http.StatusNotFound
+	case domain.ErrorCodeIdentityTaken, domain.ErrorCodeUserDeparted:
+		statusCode = http.StatusConflict
+	}
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+	})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
@@ -25,9 +26,13 @@ func NewPRHandler(prService *service.PRService, userService *service.UserService
 // CreatePR обработчик POST /pullRequest/create
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
+		Repository      string   `json:"repository"`
+		PullRequestID   string   `json:"pull_request_id"`
+		PullRequestName string   `json:"pull_request_name"`
+		AuthorID        string   `json:"author_id"`
+		Labels          []string `json:"labels"`
+		Paths           []string `json:"paths"`
+		Reviewers       []string `json:"reviewers"` // запрошенные вручную ревьюверы
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -35,14 +40,24 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.prService.CreatePR(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
+	pr, assignment, err := h.prService.CreatePR(r.Context(), service.CreatePRRequest{
+		Repository:         req.Repository,
+		PullRequestID:      req.PullRequestID,
+		Name:               req.PullRequestName,
+		AuthorID:           req.AuthorID,
+		Labels:             req.Labels,
+		Paths:              req.Paths,
+		RequestedReviewers: req.Reviewers,
+	})
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
 			statusCode := http.StatusBadRequest
 			if domErr.Code == domain.ErrorCodeNotFound {
 				statusCode = http.StatusNotFound
-			} else if domErr.Code == domain.ErrorCodePRExists {
+			} else if domErr.Code == domain.ErrorCodePRExists ||
+				domErr.Code == domain.ErrorCodeNoCapacity ||
+				domErr.Code == domain.ErrorCodeRuleViolation {
 				statusCode = http.StatusConflict
 			}
 			w.WriteHeader(statusCode)
@@ -55,24 +70,97 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"pr": map[string]interface{}{
+			"repository":         pr.Repository,
 			"pull_request_id":    pr.PullRequestID,
 			"pull_request_name":  pr.PullRequestName,
 			"author_id":          pr.AuthorID,
 			"status":             pr.Status,
 			"assigned_reviewers": pr.AssignedReviewers,
+			"shadow_reviewers":   pr.ShadowReviewers,
+			"understaffed":       pr.Understaffed,
+			"labels":             pr.Labels,
+			"paths":              pr.Paths,
 			"createdAt":          pr.CreatedAt,
 			"mergedAt":           pr.MergedAt,
 		},
+		"strategy":        assignment.Strategy,
+		"reviewer_scores": assignment.ReviewerScores(),
+	}
+	if explain(r) {
+		response["explanation"] = assignment
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// PreviewAssignment обработчик для POST /pullRequest/previewAssignment
+// Показывает, кого назначит стратегия команды на гипотетический PR, ничего не сохраняя
+func (h *PRHandler) PreviewAssignment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Repository string   `json:"repository"`
+		AuthorID   string   `json:"author_id"`
+		Labels     []string `json:"labels"`
+		Paths      []string `json:"paths"`
+		Reviewers  []string `json:"reviewers"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pr, assignment, err := h.prService.PreviewAssignment(r.Context(), service.CreatePRRequest{
+		Repository:         req.Repository,
+		AuthorID:           req.AuthorID,
+		Labels:             req.Labels,
+		Paths:              req.Paths,
+		RequestedReviewers: req.Reviewers,
 	})
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeRuleViolation {
+				statusCode = http.StatusConflict
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"author_id":          pr.AuthorID,
+		"assigned_reviewers": pr.AssignedReviewers,
+		"shadow_reviewers":   pr.ShadowReviewers,
+		"understaffed":       pr.Understaffed,
+		"alternates":         assignment.Alternates(),
+		"strategy":           assignment.Strategy,
+		"reviewer_scores":    assignment.ReviewerScores(),
+	}
+	if explain(r) {
+		response["explanation"] = assignment
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
 }
 
 // MergePR обработчик POST /pullRequest/merge
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
+		Repository    string `json:"repository"`
 		PullRequestID string `json:"pull_request_id"`
 	}
 
@@ -81,7 +169,7 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.prService.MergePR(r.Context(), req.PullRequestID)
+	pr, err := h.prService.MergePR(r.Context(), req.Repository, req.PullRequestID)
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
@@ -99,11 +187,13 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"pr": map[string]interface{}{
+			"repository":         pr.Repository,
 			"pull_request_id":    pr.PullRequestID,
 			"pull_request_name":  pr.PullRequestName,
 			"author_id":          pr.AuthorID,
 			"status":             pr.Status,
 			"assigned_reviewers": pr.AssignedReviewers,
+			"shadow_reviewers":   pr.ShadowReviewers,
 			"createdAt":          pr.CreatedAt,
 			"mergedAt":           pr.MergedAt,
 		},
@@ -113,8 +203,10 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 // ReassignReviewer обработчик POST /pullRequest/reassign
 func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req struct {
+		Repository    string `json:"repository"`
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
+		NewUserID     string `json:"new_user_id"` // необязательно; пусто — замена подбирается автоматически
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -122,7 +214,7 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, newReviewerID, err := h.prService.ReassignReviewer(r.Context(), req.PullRequestID, req.OldUserID)
+	pr, assignment, err := h.prService.ReassignReviewer(r.Context(), req.Repository, req.PullRequestID, req.OldUserID, req.NewUserID)
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
@@ -131,7 +223,68 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 				statusCode = http.StatusNotFound
 			} else if domErr.Code == domain.ErrorCodePRMerged ||
 				domErr.Code == domain.ErrorCodeNotAssigned ||
-				domErr.Code == domain.ErrorCodeNoCandidate {
+				domErr.Code == domain.ErrorCodeNoCandidate ||
+				domErr.Code == domain.ErrorCodeNoCapacity ||
+				domErr.Code == domain.ErrorCodeAssigned ||
+				domErr.Code == domain.ErrorCodeRuleViolation {
+				statusCode = http.StatusConflict
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"pr": map[string]interface{}{
+			"repository":         pr.Repository,
+			"pull_request_id":    pr.PullRequestID,
+			"pull_request_name":  pr.PullRequestName,
+			"author_id":          pr.AuthorID,
+			"status":             pr.Status,
+			"assigned_reviewers": pr.AssignedReviewers,
+			"shadow_reviewers":   pr.ShadowReviewers,
+			"createdAt":          pr.CreatedAt,
+			"mergedAt":           pr.MergedAt,
+		},
+		"replaced_by": assignment.Reviewers[0],
+	}
+	if explain(r) {
+		response["explanation"] = assignment
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// AddReviewer обработчик для POST /pullRequest/addReviewer
+func (h *PRHandler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Repository    string `json:"repository"`
+		PullRequestID string `json:"pull_request_id"`
+		UserID        string `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := h.prService.AddReviewer(r.Context(), req.Repository, req.PullRequestID, req.UserID)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodePRMerged ||
+				domErr.Code == domain.ErrorCodeAssigned ||
+				domErr.Code == domain.ErrorCodeRuleViolation {
 				statusCode = http.StatusConflict
 			}
 			w.WriteHeader(statusCode)
@@ -148,14 +301,174 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"pr": map[string]interface{}{
+			"repository":         pr.Repository,
 			"pull_request_id":    pr.PullRequestID,
 			"pull_request_name":  pr.PullRequestName,
 			"author_id":          pr.AuthorID,
 			"status":             pr.Status,
 			"assigned_reviewers": pr.AssignedReviewers,
+			"shadow_reviewers":   pr.ShadowReviewers,
+			"understaffed":       pr.Understaffed,
 			"createdAt":          pr.CreatedAt,
 			"mergedAt":           pr.MergedAt,
 		},
-		"replaced_by": newReviewerID,
 	})
 }
+
+// RemoveReviewer обработчик для POST /pullRequest/removeReviewer
+func (h *PRHandler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Repository    string `json:"repository"`
+		PullRequestID string `json:"pull_request_id"`
+		UserID        string `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := h.prService.RemoveReviewer(r.Context(), req.Repository, req.PullRequestID, req.UserID)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodePRMerged ||
+				domErr.Code == domain.ErrorCodeNotAssigned ||
+				domErr.Code == domain.ErrorCodeMinReviewers {
+				statusCode = http.StatusConflict
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": map[string]interface{}{
+			"repository":         pr.Repository,
+			"pull_request_id":    pr.PullRequestID,
+			"pull_request_name":  pr.PullRequestName,
+			"author_id":          pr.AuthorID,
+			"status":             pr.Status,
+			"assigned_reviewers": pr.AssignedReviewers,
+			"shadow_reviewers":   pr.ShadowReviewers,
+			"understaffed":       pr.Understaffed,
+			"createdAt":          pr.CreatedAt,
+			"mergedAt":           pr.MergedAt,
+		},
+	})
+}
+
+// GetAssignmentHistory обработчик GET /pullRequest/assignmentHistory
+// Возвращает сохраненные решения о назначении ревьюверов с объяснениями
+func (h *PRHandler) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	repository := r.URL.Query().Get("repository")
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.prService.GetAssignmentHistory(r.Context(), repository, prID)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if events == nil {
+		events = []domain.AssignmentEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"repository":      repository,
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
+
+// ReplayAssignment обработчик для POST /pullRequest/replayAssignment
+// Повторяет подбор по сохраненному снимку и сравнивает с записанным результатом
+func (h *PRHandler) ReplayAssignment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		EventID int64 `json:"event_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, replayed, err := h.prService.ReplayAssignment(r.Context(), req.EventID)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	recorded := event.Explanation
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event_id":        event.EventID,
+		"repository":      event.Repository,
+		"pull_request_id": event.PullRequestID,
+		"seed":            recorded.Snapshot.Seed,
+		"recorded":        recorded,
+		"replayed":        replayed,
+		"matches": slices.Equal(recorded.Reviewers, replayed.Reviewers) &&
+			slices.Equal(recorded.ShadowReviewers, replayed.ShadowReviewers),
+	})
+}
+
+// GetUnderstaffed обработчик GET /pullRequest/understaffed
+// Возвращает PR, ожидающие добора ревьюверов
+func (h *PRHandler) GetUnderstaffed(w http.ResponseWriter, r *http.Request) {
+	queue, err := h.prService.GetStaffingQueue(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if queue == nil {
+		queue = []domain.StaffingRequest{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pull_requests": queue,
+	})
+}
+
+// explain сообщает, запросил ли клиент объяснение подбора (?explain=true)
+func explain(r *http.Request) bool {
+	return r.URL.Query().Get("explain") == "true"
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
+)
+
+// RepositoryHandler обработчик репозиториев кода
+type RepositoryHandler struct {
+	repositoryService *service.RepositoryService
+}
+
+// NewRepositoryHandler создает новый handler
+func NewRepositoryHandler(repositoryService *service.RepositoryService) *RepositoryHandler {
+	return &RepositoryHandler{repositoryService: repositoryService}
+}
+
+// AddRepository обработчик POST /repository/add
+func (h *RepositoryHandler) AddRepository(w http.ResponseWriter, r *http.Request) {
+	var repository domain.Repository
+	if err := json.NewDecoder(r.Body).Decode(&repository); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repositoryService.CreateRepository(r.Context(), &repository); err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	created, err := h.repositoryService.GetRepository(r.Context(), repository.Name)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"repository": created})
+}
+
+// UpdateRepository обработчик POST /repository/update
+// Заменяет команду-владельца и переопределения политики целиком
+func (h *RepositoryHandler) UpdateRepository(w http.ResponseWriter, r *http.Request) {
+	var repository domain.Repository
+	if err := json.NewDecoder(r.Body).Decode(&repository); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.repositoryService.UpdateRepository(r.Context(), &repository)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"repository": updated})
+}
+
+// GetRepository обработчик GET /repository/get
+func (h *RepositoryHandler) GetRepository(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("repository")
+	if name == "" {
+		http.Error(w, "repository is required", http.StatusBadRequest)
+		return
+	}
+
+	repository, err := h.repositoryService.GetRepository(r.Context(), name)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"repository": repository})
+}
+
+// ListRepositories обработчик GET /repository/list
+// team_name оставляет только репозитории команды
+func (h *RepositoryHandler) ListRepositories(w http.ResponseWriter, r *http.Request) {
+	repositories, err := h.repositoryService.ListRepositories(r.Context(), r.URL.Query().Get("team_name"))
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"repositories": repositories})
+}
+
+// writeRepositoryError пишет ошибку операций с репозиториями
+func writeRepositoryError(w http.ResponseWriter, err error) {
+	domErr, ok := err.(domain.DomainError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	statusCode := http.StatusBadRequest
+	switch domErr.Code {
+	case domain.ErrorCodeNotFound:
+		statusCode = http.StatusNotFound
+	case domain.ErrorCodeRepoExists:
+		statusCode = http.StatusConflict
+	}
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
+)
+
+// RosterHandler обработчик импорта и выгрузки CSV-реестра пользователей
+type RosterHandler struct {
+	rosterService *service.RosterService
+}
+
+// NewRosterHandler создает новый handler
+func NewRosterHandler(rosterService *service.RosterService) *RosterHandler {
+	return &RosterHandler{rosterService: rosterService}
+}
+
+// Import обработчик POST /users/import
+// Тело — CSV (text/csv) или multipart-форма с полем file. С ?dry_run=true
+// только проверяет файл, смена основной команды требует ?allow_move=true.
+// Если в строках есть ошибки, отчет возвращается с 400.
+func (h *RosterHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	query := r.URL.Query()
+	report, err := h.rosterService.Import(r.Context(), body, query.Get("dry_run") == "true", query.Get("allow_move") == "true")
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	statusCode := http.StatusOK
+	if report.Errors > 0 {
+		statusCode = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Export обработчик GET /users/export
+func (h *RosterHandler) Export(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="roster.csv"`)
+	if err := h.rosterService.Export(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,544 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
+)
+
+// Схемы SCIM 2.0 (RFC 7643, RFC 7644)
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimContentType = "application/scim+json"
+	scimBasePath    = "/scim/v2"
+)
+
+// scimMeta — метаданные ресурса SCIM
+type scimMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location"`
+}
+
+// scimUser — пользователь SCIM; id и userName совпадают с user_id
+type scimUser struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Name        *struct {
+		Formatted  string `json:"formatted,omitempty"`
+		GivenName  string `json:"givenName,omitempty"`
+		FamilyName string `json:"familyName,omitempty"`
+	} `json:"name,omitempty"`
+	Active *bool       `json:"active,omitempty"`
+	Emails []scimEmail `json:"emails,omitempty"`
+	Meta   *scimMeta   `json:"meta,omitempty"`
+}
+
+// scimEmail — адрес пользователя; хранится только основной
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// scimMember — ссылка на участника группы
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// scimGroup — группа SCIM; id и displayName совпадают с названием команды
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members"`
+	Meta        *scimMeta    `json:"meta,omitempty"`
+}
+
+// scimPatch — запрос PATCH (RFC 7644, 3.5.2)
+type scimPatch struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// ScimHandler обработчик провижининга SCIM 2.0 из identity provider'а
+type ScimHandler struct {
+	scimService *service.ScimService
+	userService *service.UserService
+}
+
+// NewScimHandler создает новый handler
+func NewScimHandler(scimService *service.ScimService, userService *service.UserService) *ScimHandler {
+	return &ScimHandler{scimService: scimService, userService: userService}
+}
+
+// CreateUser обработчик POST /scim/v2/Users
+func (h *ScimHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req scimUser
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "invalid request body"))
+		return
+	}
+
+	user := &domain.User{UserID: req.UserName, Username: req.DisplayName, IsActive: true}
+	if req.Active != nil {
+		user.IsActive = *req.Active
+	}
+	if user.Username == "" && req.Name != nil {
+		user.Username = req.Name.Formatted
+		if user.Username == "" {
+			user.Username = strings.TrimSpace(req.Name.GivenName + " " + req.Name.FamilyName)
+		}
+	}
+	if user.Username == "" {
+		user.Username = req.UserName
+	}
+	for i, email := range req.Emails {
+		if i == 0 || email.Primary {
+			user.Email = email.Value
+		}
+	}
+	user.WorkStartHour, user.WorkEndHour = domain.DefaultWorkStartHour, domain.DefaultWorkEndHour
+
+	created, err := h.scimService.CreateUser(r.Context(), user)
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusCreated, toScimUser(created))
+}
+
+// GetUser обработчик GET /scim/v2/Users/{id}
+func (h *ScimHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.scimService.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusOK, toScimUser(user))
+}
+
+// ListUsers обработчик GET /scim/v2/Users
+// Поддерживается фильтр вида `attr eq "value"` по userName, id, displayName, emails и active.
+func (h *ScimHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	attr, value, err := parseScimFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	users, err := h.scimService.ListUsers(r.Context())
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+
+	var resources []interface{}
+	for i := range users {
+		user := &users[i]
+		var field string
+		switch attr {
+		case "":
+		case "username", "id":
+			field = user.UserID
+		case "displayname":
+			field = user.Username
+		case "emails", "emails.value":
+			field = user.Email
+		case "active":
+			field = strconv.FormatBool(user.IsActive)
+		default:
+			writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "unsupported filter attribute: "+attr))
+			return
+		}
+		if attr == "" || strings.EqualFold(field, value) {
+			resources = append(resources, toScimUser(user))
+		}
+	}
+	writeScimList(w, r, resources)
+}
+
+// PatchUser обработчик PATCH /scim/v2/Users/{id}
+// Поддерживается замена active; деактивация переназначает открытые ревью пользователя.
+func (h *ScimHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	if _, err := h.scimService.GetUser(r.Context(), userID); err != nil {
+		writeScimError(w, err)
+		return
+	}
+
+	var req scimPatch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "invalid request body"))
+		return
+	}
+	for _, op := range req.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "unsupported patch op: "+op.Op))
+			return
+		}
+		// Значение приходит либо по пути active, либо объектом {"active": ...}
+		raw := op.Value
+		if op.Path == "" {
+			var values map[string]json.RawMessage
+			if err := decodeScimValue(op.Value, &values); err != nil {
+				writeScimError(w, err)
+				return
+			}
+			if raw = values["active"]; raw == nil {
+				continue
+			}
+		} else if !strings.EqualFold(op.Path, "active") {
+			writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "unsupported patch path: "+op.Path))
+			return
+		}
+
+		active, err := parseScimBool(raw)
+		if err != nil {
+			writeScimError(w, err)
+			return
+		}
+		if _, _, err := h.userService.SetActive(r.Context(), userID, active); err != nil {
+			writeScimError(w, err)
+			return
+		}
+	}
+
+	user, err := h.scimService.GetUser(r.Context(), userID)
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusOK, toScimUser(user))
+}
+
+// DeleteUser обработчик DELETE /scim/v2/Users/{id}
+// Пользователь не удаляется, а оформляется ушедшим (см. /users/offboard): ревью
+// передаются коллегам, история сохраняется.
+func (h *ScimHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.scimService.GetUser(r.Context(), r.PathValue("id")); err != nil {
+		writeScimError(w, err)
+		return
+	}
+	if _, err := h.userService.Offboard(r.Context(), r.PathValue("id"), false); err != nil {
+		writeScimError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateGroup обработчик POST /scim/v2/Groups
+func (h *ScimHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req scimGroup
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "invalid request body"))
+		return
+	}
+
+	team, err := h.scimService.CreateGroup(r.Context(), req.DisplayName, memberIDs(req.Members))
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusCreated, toScimGroup(team))
+}
+
+// GetGroup обработчик GET /scim/v2/Groups/{id}
+func (h *ScimHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	team, err := h.scimService.GetGroup(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusOK, toScimGroup(team))
+}
+
+// ListGroups обработчик GET /scim/v2/Groups
+// Поддерживается фильтр вида `displayName eq "value"` (или по id).
+func (h *ScimHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	attr, value, err := parseScimFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	if attr != "" && attr != "displayname" && attr != "id" {
+		writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "unsupported filter attribute: "+attr))
+		return
+	}
+	teams, err := h.scimService.ListGroups(r.Context())
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+
+	var resources []interface{}
+	for i := range teams {
+		if attr == "" || teams[i].TeamName == value {
+			resources = append(resources, toScimGroup(&teams[i]))
+		}
+	}
+	writeScimList(w, r, resources)
+}
+
+// PatchGroup обработчик PATCH /scim/v2/Groups/{id}
+// Поддерживаются add/remove/replace участников и замена displayName (переименование).
+func (h *ScimHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	teamName := r.PathValue("id")
+	if _, err := h.scimService.GetGroup(r.Context(), teamName); err != nil {
+		writeScimError(w, err)
+		return
+	}
+
+	var req scimPatch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeScimError(w, domain.NewError(domain.ErrorCodeInvalidInput, "invalid request body"))
+		return
+	}
+	for _, op := range req.Operations {
+		var err error
+		switch path := strings.ToLower(op.Path); {
+		case path == "displayname" || (path == "" && strings.EqualFold(op.Op, "replace")):
+			var name string
+			if path == "" {
+				var values struct {
+					DisplayName string `json:"displayName"`
+				}
+				err = decodeScimValue(op.Value, &values)
+				name = values.DisplayName
+			} else {
+				err = decodeScimValue(op.Value, &name)
+			}
+			if err == nil {
+				if err = h.scimService.RenameGroup(r.Context(), teamName, name); err == nil && name != "" {
+					teamName = name
+				}
+			}
+		case path == "members":
+			var members []scimMember
+			if len(op.Value) > 0 {
+				if err = decodeScimValue(op.Value, &members); err != nil {
+					break
+				}
+			}
+			switch strings.ToLower(op.Op) {
+			case "add":
+				err = h.scimService.AddGroupMembers(r.Context(), teamName, memberIDs(members))
+			case "remove":
+				err = h.scimService.RemoveGroupMembers(r.Context(), teamName, memberIDs(members))
+			case "replace":
+				err = h.scimService.SetGroupMembers(r.Context(), teamName, memberIDs(members))
+			default:
+				err = domain.NewError(domain.ErrorCodeInvalidInput, "unsupported patch op: "+op.Op)
+			}
+		case strings.HasPrefix(path, "members[") && strings.EqualFold(op.Op, "remove"):
+			// members[value eq "u1"]
+			var attr, value string
+			attr, value, err = parseScimFilter(strings.TrimSuffix(op.Path[len("members["):], "]"))
+			if err == nil && attr != "value" {
+				err = domain.NewError(domain.ErrorCodeInvalidInput, "unsupported patch path: "+op.Path)
+			}
+			if err == nil {
+				err = h.scimService.RemoveGroupMembers(r.Context(), teamName, []string{value})
+			}
+		default:
+			err = domain.NewError(domain.ErrorCodeInvalidInput, "unsupported patch path: "+op.Path)
+		}
+		if err != nil {
+			writeScimError(w, err)
+			return
+		}
+	}
+
+	team, err := h.scimService.GetGroup(r.Context(), teamName)
+	if err != nil {
+		writeScimError(w, err)
+		return
+	}
+	writeScim(w, http.StatusOK, toScimGroup(team))
+}
+
+// DeleteGroup обработчик DELETE /scim/v2/Groups/{id}
+func (h *ScimHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.scimService.DeleteGroup(r.Context(), r.PathValue("id")); err != nil {
+		writeScimError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toScimUser конвертирует пользователя в ресурс SCIM
+func toScimUser(user *domain.User) scimUser {
+	active := user.IsActive
+	result := scimUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          user.UserID,
+		UserName:    user.UserID,
+		DisplayName: user.Username,
+		Active:      &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      &user.CreatedAt,
+			LastModified: &user.UpdatedAt,
+			Location:     scimBasePath + "/Users/" + user.UserID,
+		},
+	}
+	if user.Email != "" {
+		result.Emails = []scimEmail{{Value: user.Email, Primary: true}}
+	}
+	return result
+}
+
+// toScimGroup конвертирует команду в ресурс SCIM
+func toScimGroup(team *domain.Team) scimGroup {
+	members := make([]scimMember, len(team.Members))
+	for i, member := range team.Members {
+		members[i] = scimMember{Value: member.UserID, Display: member.Username}
+	}
+	return scimGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          team.TeamName,
+		DisplayName: team.TeamName,
+		Members:     members,
+		Meta: &scimMeta{
+			ResourceType: "Group",
+			Created:      &team.CreatedAt,
+			LastModified: &team.UpdatedAt,
+			Location:     scimBasePath + "/Groups/" + team.TeamName,
+		},
+	}
+}
+
+// memberIDs получает ID пользователей из ссылок на участников
+func memberIDs(members []scimMember) []string {
+	ids := make([]string, len(members))
+	for i, member := range members {
+		ids[i] = member.Value
+	}
+	return ids
+}
+
+// parseScimFilter разбирает фильтр вида `attr eq "value"`; attr возвращается в
+// нижнем регистре, пустой фильтр — пустой attr
+func parseScimFilter(filter string) (string, string, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return "", "", nil
+	}
+	parts := strings.SplitN(filter, " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return "", "", domain.NewError(domain.ErrorCodeInvalidInput, "unsupported filter: "+filter)
+	}
+	value := strings.TrimSpace(parts[2])
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	return strings.ToLower(parts[0]), value, nil
+}
+
+// decodeScimValue разбирает значение операции PATCH
+func decodeScimValue(raw json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return domain.NewError(domain.ErrorCodeInvalidInput, "invalid patch value: "+err.Error())
+	}
+	return nil
+}
+
+// parseScimBool разбирает булево значение; некоторые IdP присылают его строкой "False"
+func parseScimBool(raw json.RawMessage) (bool, error) {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if value, err := strconv.ParseBool(text); err == nil {
+			return value, nil
+		}
+	}
+	return false, domain.NewError(domain.ErrorCodeInvalidInput, "active must be a boolean")
+}
+
+// writeScimList пишет ListResponse со страницей resources (startIndex — с 1)
+func writeScimList(w http.ResponseWriter, r *http.Request, resources []interface{}) {
+	total := len(resources)
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = total
+	}
+
+	page := []interface{}{}
+	if startIndex <= total {
+		end := startIndex - 1 + count
+		if end > total {
+			end = total
+		}
+		page = resources[startIndex-1 : end]
+	}
+	writeScim(w, http.StatusOK, map[string]interface{}{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(page),
+		"Resources":    page,
+	})
+}
+
+func writeScim(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeScimError пишет ошибку в формате SCIM (RFC 7644, 3.12)
+func writeScimError(w http.ResponseWriter, err error) {
+	domErr, ok := err.(domain.DomainError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	statusCode := http.StatusBadRequest
+	scimType := ""
+	switch domErr.Code {
+	case domain.ErrorCodeNotFound:
+		statusCode = http.StatusNotFound
+	case domain.ErrorCodeUserExists, domain.ErrorCodeTeamExists, domain.ErrorCodeUserDeparted:
+		statusCode = http.StatusConflict
+		scimType = "uniqueness"
+	case domain.ErrorCodeTeamHasOpenPR:
+		statusCode = http.StatusConflict
+	case domain.ErrorCodeForbidden:
+		statusCode = http.StatusForbidden
+	case domain.ErrorCodeUnauthorized:
+		statusCode = http.StatusUnauthorized
+	case domain.ErrorCodeInvalidInput:
+		scimType = "invalidValue"
+	}
+	body := map[string]interface{}{
+		"schemas": []string{scimErrorSchema},
+		"status":  fmt.Sprint(statusCode),
+		"detail":  domErr.Message,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	writeScim(w, statusCode, body)
+}
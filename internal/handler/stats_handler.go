@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
 )
 
@@ -20,19 +22,20 @@ func NewStatsHandler(userService *service.UserService) *StatsHandler {
 }
 
 // GetStats обработчик GET /stats
-// Возвращает статистику по ревьюверам и PR
+// Возвращает статистику по ревьюверам и PR; repository ограничивает ее одним репозиторием
 func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	repository := r.URL.Query().Get("repository")
 
 	// Получаем статистику по ревьюверам
-	reviewerStats, err := h.userService.GetReviewerStats(ctx)
+	reviewerStats, err := h.userService.GetReviewerStats(ctx, repository)
 	if err != nil {
 		http.Error(w, "Failed to get reviewer stats", http.StatusInternalServerError)
 		return
 	}
 
 	// Получаем статистику по PR
-	prStats, err := h.userService.GetPRStats(ctx)
+	prStats, err := h.userService.GetPRStats(ctx, repository)
 	if err != nil {
 		http.Error(w, "Failed to get PR stats", http.StatusInternalServerError)
 		return
@@ -63,8 +66,9 @@ func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 // Может быть использован для более детальной информации
 func (h *StatsHandler) GetReviewerStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	repository := r.URL.Query().Get("repository")
 
-	stats, err := h.userService.GetReviewerStats(ctx)
+	stats, err := h.userService.GetReviewerStats(ctx, repository)
 	if err != nil {
 		http.Error(w, "Failed to get reviewer stats", http.StatusInternalServerError)
 		return
@@ -92,8 +96,9 @@ func (h *StatsHandler) GetReviewerStats(w http.ResponseWriter, r *http.Request)
 // GetPRStats вспомогательный метод для получения статистики PR
 func (h *StatsHandler) GetPRStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	repository := r.URL.Query().Get("repository")
 
-	stats, err := h.userService.GetPRStats(ctx)
+	stats, err := h.userService.GetPRStats(ctx, repository)
 	if err != nil {
 		http.Error(w, "Failed to get PR stats", http.StatusInternalServerError)
 		return
@@ -116,3 +121,50 @@ func (h *StatsHandler) GetPRStats(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetTeamStats обработчик GET /stats/teams
+// Для каждой команды возвращает собственную статистику (own) и вместе
+// с подкомандами (total); team_name ограничивает ответ поддеревом команды,
+// repository — PR одного репозитория
+func (h *StatsHandler) GetTeamStats(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	repository := r.URL.Query().Get("repository")
+
+	stats, err := h.userService.GetTeamStats(r.Context(), teamName, repository)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, "Failed to get team stats", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	counters := func(st repo.TeamStats) map[string]interface{} {
+		return map[string]interface{}{
+			"members":          st.Members,
+			"assignment_count": st.AssignmentCount,
+			"authored_prs":     st.AuthoredPRs,
+			"open_prs":         st.OpenPRs,
+		}
+	}
+	statsData := make([]map[string]interface{}, len(stats))
+	for i, stat := range stats {
+		statsData[i] = map[string]interface{}{
+			"team_name":   stat.Own.TeamName,
+			"parent_team": stat.Own.ParentTeam,
+			"own":         counters(stat.Own),
+			"total":       counters(stat.Total),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team_stats": statsData,
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -18,26 +19,69 @@ type ErrorDetail struct {
 	Message string `json:"message"`
 }
 
+// memberRequest — участник команды в теле запроса
+type memberRequest struct {
+	UserID        string   `json:"user_id"`
+	Username      string   `json:"username"`
+	IsActive      bool     `json:"is_active"`
+	Timezone      string   `json:"timezone"`
+	WorkStartHour *int     `json:"work_start_hour"`
+	WorkEndHour   *int     `json:"work_end_hour"`
+	Skills        []string `json:"skills"`
+	Seniority     string   `json:"seniority"`
+	Email         string   `json:"email"`
+	Role          string   `json:"role"` // lead, maintainer или member
+}
+
+// toDomain конвертирует участника в domain model. Не указанные поля остаются
+// пустыми: сервис берет их у существующего пользователя, а нового заполняет
+// значениями по умолчанию.
+func (m memberRequest) toDomain() domain.User {
+	user := domain.User{
+		UserID:        m.UserID,
+		Username:      m.Username,
+		IsActive:      m.IsActive,
+		Timezone:      m.Timezone,
+		Skills:        m.Skills,
+		Seniority:     m.Seniority,
+		Email:         m.Email,
+		Role:          m.Role,
+		WorkStartHour: domain.UnsetWorkHour,
+		WorkEndHour:   domain.UnsetWorkHour,
+	}
+	if m.WorkStartHour != nil {
+		user.WorkStartHour = *m.WorkStartHour
+	}
+	if m.WorkEndHour != nil {
+		user.WorkEndHour = *m.WorkEndHour
+	}
+	return user
+}
+
 // TeamHandler обработчик для операций с командами
 type TeamHandler struct {
 	teamService *service.TeamService
+	syncService *service.TeamSyncService
 }
 
 // NewTeamHandler создает новый handler
-func NewTeamHandler(teamService *service.TeamService) *TeamHandler {
-	return &TeamHandler{teamService: teamService}
+func NewTeamHandler(teamService *service.TeamService, syncService *service.TeamSyncService) *TeamHandler {
+	return &TeamHandler{teamService: teamService, syncService: syncService}
 }
 
 // AddTeam обработчик POST /team/add
 func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		TeamName string `json:"team_name"`
-		Members  []struct {
-			UserID   string `json:"user_id"`
-			Username string `json:"username"`
-			IsActive bool   `json:"is_active"`
-		} `json:"members"`
+		TeamName string            `json:"team_name"`
+		Members  []memberRequest   `json:"members"`
+		Policy   domain.TeamPolicy `json:"policy"`
+		// Родительская команда, к которой эскалирует подбор ревьюверов
+		ParentTeam string `json:"parent_team"`
+		// Разрешить перевод участников, уже состоящих в другой команде
+		AllowMove bool `json:"allow_move"`
 	}
+	// Поля политики, не указанные в запросе, получают значения по умолчанию
+	req.Policy = domain.DefaultTeamPolicy()
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -46,23 +90,31 @@ func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 
 	// Конвертируем в domain model
 	team := &domain.Team{
-		TeamName: req.TeamName,
-		Members:  make([]domain.User, len(req.Members)),
+		TeamName:   req.TeamName,
+		ParentTeam: req.ParentTeam,
+		Members:    make([]domain.User, len(req.Members)),
+		Policy:     req.Policy,
 	}
 
 	for i, member := range req.Members {
-		team.Members[i] = domain.User{
-			UserID:   member.UserID,
-			Username: member.Username,
-			IsActive: member.IsActive,
-		}
+		team.Members[i] = member.toDomain()
 	}
 
-	result, err := h.teamService.CreateTeam(r.Context(), team)
+	result, err := h.teamService.CreateTeam(r.Context(), team, req.AllowMove)
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeUserInTeam || domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
 			json.NewEncoder(w).Encode(ErrorResponse{
 				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
 			})
@@ -76,7 +128,8 @@ func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"team": map[string]interface{}{
-			"team_name": result.TeamName,
+			"team_name":   result.TeamName,
+			"parent_team": result.ParentTeam,
 			"members": func() []interface{} {
 				members := make([]interface{}, len(result.Members))
 				for i, m := range result.Members {
@@ -84,15 +137,18 @@ func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 						"user_id":   m.UserID,
 						"username":  m.Username,
 						"is_active": m.IsActive,
+						"role":      m.Role,
 					}
 				}
 				return members
 			}(),
+			"policy": result.Policy,
 		},
 	})
 }
 
 // GetTeam обработчик GET /team/get
+// При subtree=true в ответ входят все подкоманды
 func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
@@ -100,7 +156,14 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := h.teamService.GetTeam(r.Context(), teamName)
+	var team *domain.Team
+	var err error
+	subtree := r.URL.Query().Get("subtree") == "true"
+	if subtree {
+		team, err = h.teamService.GetTeamTree(r.Context(), teamName)
+	} else {
+		team, err = h.teamService.GetTeam(r.Context(), teamName)
+	}
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
@@ -114,20 +177,589 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(teamView(team, subtree))
+}
+
+// teamView — команда с участниками в ответе API; при subtree — с подкомандами
+func teamView(team *domain.Team, subtree bool) map[string]interface{} {
+	members := make([]interface{}, len(team.Members))
+	for i, m := range team.Members {
+		members[i] = map[string]interface{}{
+			"user_id":         m.UserID,
+			"username":        m.Username,
+			"is_active":       m.IsActive,
+			"timezone":        m.Timezone,
+			"work_start_hour": m.WorkStartHour,
+			"work_end_hour":   m.WorkEndHour,
+			"skills":          m.Skills,
+			"seniority":       m.Seniority,
+			"role":            m.Role,
+			"is_primary":      m.TeamName == team.TeamName, // false — дополнительный участник
+		}
+	}
+	view := map[string]interface{}{
+		"team_name":   team.TeamName,
+		"parent_team": team.ParentTeam,
+		"members":     members,
+		"policy":      team.Policy,
+	}
+	if subtree {
+		subteams := make([]interface{}, len(team.Subteams))
+		for i := range team.Subteams {
+			subteams[i] = teamView(&team.Subteams[i], true)
+		}
+		view["subteams"] = subteams
+	}
+	return view
+}
+
+// SetParent обработчик POST /team/setParent
+// Пустой parent_team делает команду командой верхнего уровня
+func (h *TeamHandler) SetParent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName   string `json:"team_name"`
+		ParentTeam string `json:"parent_team"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teamService.SetParent(r.Context(), req.TeamName, req.ParentTeam)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"team_name": team.TeamName,
-		"members": func() []interface{} {
-			members := make([]interface{}, len(team.Members))
-			for i, m := range team.Members {
-				members[i] = map[string]interface{}{
-					"user_id":   m.UserID,
-					"username":  m.Username,
-					"is_active": m.IsActive,
-				}
+		"team_name":   team.TeamName,
+		"parent_team": team.ParentTeam,
+	})
+}
+
+// Sync обработчик POST /team/sync
+// Тело — конфигурация команд в YAML (или JSON). Без ?apply=true возвращает план, ничего не меняя.
+func (h *TeamHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	var plan *domain.TeamSyncPlan
+	cfg, err := service.ParseTeamConfig(r.Body)
+	if err == nil && r.URL.Query().Get("apply") == "true" {
+		plan, err = h.syncService.Apply(r.Context(), cfg)
+	} else if err == nil {
+		plan, err = h.syncService.Plan(r.Context(), cfg)
+	}
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// SetRole обработчик POST /team/setRole
+func (h *TeamHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
+		Role     string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teamService.SetRole(r.Context(), req.TeamName, req.UserID, req.Role)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team": teamView(team, false),
+	})
+}
+
+// SetPolicy обработчик POST /team/setPolicy
+// Поля, отсутствующие в запросе, сохраняют текущие значения
+func (h *TeamHandler) SetPolicy(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string          `json:"team_name"`
+		Policy   json.RawMessage `json:"policy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teamService.GetTeam(r.Context(), req.TeamName)
+	var policy *domain.TeamPolicy
+	if err == nil {
+		policy = &team.Policy
+		if len(req.Policy) > 0 {
+			if err := json.Unmarshal(req.Policy, policy); err != nil {
+				http.Error(w, "Invalid policy", http.StatusBadRequest)
+				return
+			}
+		}
+		policy, err = h.teamService.SetPolicy(r.Context(), req.TeamName, policy)
+	}
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team_name": req.TeamName,
+		"policy":    policy,
+	})
+}
+
+// AddRule обработчик для POST /team/rules/add
+func (h *TeamHandler) AddRule(w http.ResponseWriter, r *http.Request) {
+	var rule domain.ExclusionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.teamService.AddRule(r.Context(), &rule)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rule": created,
+	})
+}
+
+// GetRules обработчик для GET /team/rules
+func (h *TeamHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.teamService.GetRules(r.Context(), teamName)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team_name": teamName,
+		"rules":     rules,
+	})
+}
+
+// DeleteRule обработчик для POST /team/rules/delete
+func (h *TeamHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RuleID int64 `json:"rule_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.teamService.DeleteRule(r.Context(), req.RuleID); err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusNotFound
+			if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rule_id": req.RuleID,
+	})
+}
+
+// UpdateTeam обработчик для POST /team/update
+func (h *TeamHandler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName      string          `json:"team_name"`
+		NewTeamName   string          `json:"new_team_name"`
+		AddMembers    []memberRequest `json:"add_members"`
+		RemoveMembers []string        `json:"remove_members"`
+		AllowMove     bool            `json:"allow_move"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	update := service.UpdateTeamRequest{
+		TeamName:      req.TeamName,
+		NewTeamName:   req.NewTeamName,
+		AddMembers:    make([]domain.User, len(req.AddMembers)),
+		RemoveMembers: req.RemoveMembers,
+		AllowMove:     req.AllowMove,
+	}
+	for i, member := range req.AddMembers {
+		update.AddMembers[i] = member.toDomain()
+	}
+
+	team, err := h.teamService.UpdateTeam(r.Context(), update)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeTeamExists || domErr.Code == domain.ErrorCodeUserInTeam ||
+				domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	members := make([]interface{}, len(team.Members))
+	for i, m := range team.Members {
+		members[i] = map[string]interface{}{
+			"user_id":   m.UserID,
+			"username":  m.Username,
+			"is_active": m.IsActive,
+			"role":      m.Role,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team": map[string]interface{}{
+			"team_name": team.TeamName,
+			"members":   members,
+			"policy":    team.Policy,
+		},
+	})
+}
+
+// DeleteTeam обработчик для POST /team/delete
+func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName     string `json:"team_name"`
+		OpenPRPolicy string `json:"open_pr_policy"` // reject (по умолчанию) или detach
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	openPRs, err := h.teamService.DeleteTeam(r.Context(), req.TeamName, req.OpenPRPolicy)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeTeamHasOpenPR {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team_name":         req.TeamName,
+		"detached_open_prs": openPRs,
+	})
+}
+
+// MoveUser обработчик для POST /users/move
+func (h *TeamHandler) MoveUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID          string `json:"user_id"`
+		TeamName        string `json:"team_name"`
+		HandoverReviews bool   `json:"handover_reviews"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	move, err := h.teamService.MoveUser(r.Context(), req.UserID, req.TeamName, req.HandoverReviews)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"move": move,
+	})
+}
+
+// GetMoves обработчик для GET /users/moves
+func (h *TeamHandler) GetMoves(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	moves, err := h.teamService.GetMoves(r.Context(), userID)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"moves":   moves,
+	})
+}
+
+// GetMemberships обработчик для GET /users/teams
+func (h *TeamHandler) GetMemberships(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	memberships, err := h.teamService.GetMemberships(r.Context(), userID)
+	h.writeMemberships(w, userID, memberships, err)
+}
+
+// AddMembership обработчик для POST /users/addTeam
+func (h *TeamHandler) AddMembership(w http.ResponseWriter, r *http.Request) {
+	h.changeMembership(w, r, h.teamService.AddMembership)
+}
+
+// RemoveMembership обработчик для POST /users/removeTeam
+func (h *TeamHandler) RemoveMembership(w http.ResponseWriter, r *http.Request) {
+	h.changeMembership(w, r, h.teamService.RemoveMembership)
+}
+
+// SetPrimaryTeam обработчик для POST /users/setPrimaryTeam
+func (h *TeamHandler) SetPrimaryTeam(w http.ResponseWriter, r *http.Request) {
+	h.changeMembership(w, r, h.teamService.SetPrimaryTeam)
+}
+
+// changeMembership разбирает запрос {user_id, team_name} и применяет change
+func (h *TeamHandler) changeMembership(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, userID, teamName string) ([]domain.TeamMembership, error),
+) {
+	var req struct {
+		UserID   string `json:"user_id"`
+		TeamName string `json:"team_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	memberships, err := change(r.Context(), req.UserID, req.TeamName)
+	h.writeMemberships(w, req.UserID, memberships, err)
+}
+
+// writeMemberships пишет в ответ команды пользователя или ошибку
+func (h *TeamHandler) writeMemberships(w http.ResponseWriter, userID string, memberships []domain.TeamMembership, err error) {
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			} else if domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			} else if domErr.Code == domain.ErrorCodeForbidden {
+				statusCode = http.StatusForbidden
+			} else if domErr.Code == domain.ErrorCodeUnauthorized {
+				statusCode = http.StatusUnauthorized
 			}
-			return members
-		}(),
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"teams":   memberships,
 	})
 }
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/service"
+)
+
+// Заголовки, по которым определяются организация и автор запроса
+const (
+	HeaderAPIKey       = "X-API-Key"
+	HeaderOrganization = "X-Organization"
+	HeaderActor        = "X-Actor-ID"
+)
+
+// TenantMiddleware определяет организацию и автора запроса по API-ключу
+// (X-API-Key или Authorization: Bearer) и кладет их в контекст; запросы без
+// ключа отклоняются. Ключ пользователя действует от его имени, ключ без
+// пользователя — от имени администратора организации.
+// С trustOrgHeader (сервис за доверенным шлюзом, который сам проверяет клиентов)
+// запрос без ключа относится к организации из заголовка X-Organization, а без
+// него — к организации по умолчанию; автора называет заголовок X-Actor-ID.
+// Без него автор не установлен, и изменения команд с проверкой прав отклоняются.
+// SCIM-токены на обычный API не действуют, см. WrapScim.
+type TenantMiddleware struct {
+	orgService     *service.OrganizationService
+	trustOrgHeader bool
+}
+
+// NewTenantMiddleware создает middleware организаций
+func NewTenantMiddleware(orgService *service.OrganizationService, trustOrgHeader bool) *TenantMiddleware {
+	return &TenantMiddleware{
+		orgService:     orgService,
+		trustOrgHeader: trustOrgHeader,
+	}
+}
+
+// Wrap оборачивает обработчик next
+func (m *TenantMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID, actor, err := m.resolve(r)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		ctx := domain.WithOrganization(r.Context(), orgID)
+		if actor != nil {
+			ctx = domain.WithActor(ctx, *actor)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve определяет организацию и автора запроса по заголовкам; автор nil,
+// если его личность не установлена
+func (m *TenantMiddleware) resolve(r *http.Request) (string, *domain.Actor, error) {
+	if key := apiKey(r); key != "" {
+		owner, err := m.orgService.ResolveAPIKey(r.Context(), key)
+		if err != nil {
+			return "", nil, err
+		}
+		if owner.Scope != domain.APIKeyScopeAPI {
+			return "", nil, domain.NewError(domain.ErrorCodeForbidden, "scim token is valid only for /scim/v2")
+		}
+		// Ключ одной организации не открывает данные другой
+		if header := r.Header.Get(HeaderOrganization); header != "" && header != owner.OrgID {
+			return "", nil, domain.NewError(domain.ErrorCodeForbidden, "api key does not belong to organization "+header)
+		}
+		// Ключ не действует от чужого имени
+		if header := r.Header.Get(HeaderActor); header != "" && header != owner.UserID {
+			return "", nil, domain.NewError(domain.ErrorCodeForbidden, "api key does not belong to user "+header)
+		}
+		return owner.OrgID, &domain.Actor{UserID: owner.UserID}, nil
+	}
+	if !m.trustOrgHeader {
+		return "", nil, domain.NewError(domain.ErrorCodeUnauthorized, "api key is required")
+	}
+
+	orgID := domain.DefaultOrganization
+	if header := r.Header.Get(HeaderOrganization); header != "" {
+		var err error
+		if orgID, err = m.orgService.ResolveOrganization(r.Context(), header); err != nil {
+			return "", nil, err
+		}
+	}
+	if header := strings.TrimSpace(r.Header.Get(HeaderActor)); header != "" {
+		return orgID, &domain.Actor{UserID: header}, nil
+	}
+	return orgID, nil, nil
+}
+
+// WrapScim оборачивает обработчики SCIM. Они принимают только SCIM-токен
+// организации в Authorization: Bearer, в том числе за доверенным шлюзом:
+// identity provider меняет составы всех команд и не должен входить по ключу,
+// выданному для обычного API. Запрос по токену действует от имени
+// администратора организации.
+func (m *TenantMiddleware) WrapScim(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		owner, err := m.resolveScim(r)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		ctx := domain.WithActor(domain.WithOrganization(r.Context(), owner.OrgID), domain.Actor{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolveScim определяет организацию по SCIM-токену
+func (m *TenantMiddleware) resolveScim(r *http.Request) (*domain.APIKey, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, domain.NewError(domain.ErrorCodeUnauthorized, "scim bearer token is required")
+	}
+	owner, err := m.orgService.ResolveAPIKey(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	if owner.Scope != domain.APIKeyScopeSCIM {
+		return nil, domain.NewError(domain.ErrorCodeForbidden, "api key is not a scim token")
+	}
+	return owner, nil
+}
+
+// apiKey достает ключ из X-API-Key или из Authorization: Bearer
+func apiKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(HeaderAPIKey)); key != "" {
+		return key
+	}
+	return bearerToken(r)
+}
+
+// bearerToken достает токен из Authorization: Bearer
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return ""
+}
+
+// writeTenantError пишет ошибку определения организации
+func writeTenantError(w http.ResponseWriter, err error) {
+	domErr, ok := err.(domain.DomainError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	statusCode := http.StatusUnauthorized
+	if domErr.Code == domain.ErrorCodeForbidden {
+		statusCode = http.StatusForbidden
+	}
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+	})
+}
@@ -34,7 +34,141 @@ func (h *UserHandler) SetActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.SetActive(r.Context(), req.UserID, req.IsActive)
+	user, handovers, err := h.userService.SetActive(r.Context(), req.UserID, req.IsActive)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusNotFound
+			if domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": map[string]interface{}{
+			"user_id":   user.UserID,
+			"username":  user.Username,
+			"team_name": user.TeamName,
+			"is_active": user.IsActive,
+		},
+		"handovers": handovers,
+	})
+}
+
+// SetWorkingHours обработчик POST /users/setWorkingHours
+func (h *UserHandler) SetWorkingHours(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID        string `json:"user_id"`
+		Timezone      string `json:"timezone"`
+		WorkStartHour int    `json:"work_start_hour"`
+		WorkEndHour   int    `json:"work_end_hour"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetWorkingHours(r.Context(), req.UserID, req.Timezone, req.WorkStartHour, req.WorkEndHour)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": map[string]interface{}{
+			"user_id":         user.UserID,
+			"username":        user.Username,
+			"team_name":       user.TeamName,
+			"is_active":       user.IsActive,
+			"timezone":        user.Timezone,
+			"work_start_hour": user.WorkStartHour,
+			"work_end_hour":   user.WorkEndHour,
+		},
+	})
+}
+
+// SetCapacity обработчик POST /users/setCapacity
+// max_open_reviews = null сбрасывает персональный лимит к значению команды
+func (h *UserHandler) SetCapacity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": map[string]interface{}{
+			"user_id":          user.UserID,
+			"username":         user.Username,
+			"team_name":        user.TeamName,
+			"is_active":        user.IsActive,
+			"max_open_reviews": user.MaxOpenReviews,
+		},
+	})
+}
+
+// SetSkills обработчик POST /users/setSkills
+func (h *UserHandler) SetSkills(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string   `json:"user_id"`
+		Skills []string `json:"skills"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetSkills(r.Context(), req.UserID, req.Skills)
 	if err != nil {
 		if domErr, ok := err.(domain.DomainError); ok {
 			w.Header().Set("Content-Type", "application/json")
@@ -56,11 +190,56 @@ func (h *UserHandler) SetActive(w http.ResponseWriter, r *http.Request) {
 			"username":  user.Username,
 			"team_name": user.TeamName,
 			"is_active": user.IsActive,
+			"skills":    user.Skills,
+		},
+	})
+}
+
+// SetSeniority обработчик POST /users/setSeniority
+func (h *UserHandler) SetSeniority(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID    string `json:"user_id"`
+		Seniority string `json:"seniority"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetSeniority(r.Context(), req.UserID, req.Seniority)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusBadRequest
+			if domErr.Code == domain.ErrorCodeNotFound {
+				statusCode = http.StatusNotFound
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": map[string]interface{}{
+			"user_id":   user.UserID,
+			"username":  user.Username,
+			"team_name": user.TeamName,
+			"is_active": user.IsActive,
+			"seniority": user.Seniority,
 		},
 	})
 }
 
 // GetReview обработчик GET /users/getReview
+// Необязательный repository оставляет только PR этого репозитория
 func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
@@ -68,7 +247,7 @@ func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := h.prService.GetReviewsForUser(r.Context(), userID)
+	prs, err := h.prService.GetReviewsForUser(r.Context(), userID, r.URL.Query().Get("repository"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -80,6 +259,7 @@ func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 	prList := make([]interface{}, len(prs))
 	for i, pr := range prs {
 		prList[i] = map[string]interface{}{
+			"repository":        pr.Repository,
 			"pull_request_id":   pr.PullRequestID,
 			"pull_request_name": pr.PullRequestName,
 			"author_id":         pr.AuthorID,
@@ -92,3 +272,47 @@ func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 		"pull_requests": prList,
 	})
 }
+
+// Offboard обработчик POST /users/offboard
+func (h *UserHandler) Offboard(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID    string `json:"user_id"`
+		Anonymize bool   `json:"anonymize"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.userService.Offboard(r.Context(), req.UserID, req.Anonymize)
+	if err != nil {
+		if domErr, ok := err.(domain.DomainError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			statusCode := http.StatusNotFound
+			if domErr.Code == domain.ErrorCodeUserDeparted {
+				statusCode = http.StatusConflict
+			}
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: ErrorDetail{Code: string(domErr.Code), Message: domErr.Message},
+			})
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": map[string]interface{}{
+			"user_id":     result.User.UserID,
+			"username":    result.User.Username,
+			"is_active":   result.User.IsActive,
+			"departed_at": result.User.DepartedAt,
+		},
+		"handovers":  result.Handovers,
+		"anonymized": result.Anonymized,
+	})
+}
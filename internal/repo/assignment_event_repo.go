@@ -0,0 +1,19 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// AssignmentEventRepository интерфейс истории решений о назначении ревьюверов
+type AssignmentEventRepository interface {
+	// RecordAssignmentEvent сохраняет событие вместе с объяснением
+	RecordAssignmentEvent(ctx context.Context, event *domain.AssignmentEvent) error
+
+	// GetAssignmentEvent получает событие по ID
+	GetAssignmentEvent(ctx context.Context, eventID int64) (*domain.AssignmentEvent, error)
+
+	// GetAssignmentEvents получает события PR в хронологическом порядке
+	GetAssignmentEvents(ctx context.Context, repository, prID string) ([]domain.AssignmentEvent, error)
+}
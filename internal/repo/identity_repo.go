@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// IdentityRepository интерфейс внешних учетных записей пользователей
+type IdentityRepository interface {
+	// LinkIdentity привязывает учетную запись к пользователю, заменяя его прежнюю
+	// у того же провайдера. Запись другого пользователя не перепривязывается.
+	LinkIdentity(ctx context.Context, identity *domain.UserIdentity) error
+
+	// UnlinkIdentity отвязывает учетную запись пользователя у провайдера
+	UnlinkIdentity(ctx context.Context, userID, provider string) error
+
+	// GetIdentities получает учетные записи пользователя
+	GetIdentities(ctx context.Context, userID string) ([]domain.UserIdentity, error)
+
+	// FindIdentities получает учетные записи провайдера по внешним ID
+	FindIdentities(ctx context.Context, provider string, externalIDs []string) ([]domain.UserIdentity, error)
+}
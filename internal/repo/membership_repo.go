@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// MembershipRepository интерфейс членства пользователей в командах.
+// Основное членство следует за users.team_name; здесь меняются дополнительные.
+type MembershipRepository interface {
+	// GetMemberships получает команды пользователя, основную первой
+	GetMemberships(ctx context.Context, userID string) ([]domain.TeamMembership, error)
+
+	// AddMembership добавляет пользователя в команду как дополнительного участника
+	AddMembership(ctx context.Context, userID, teamName string) error
+
+	// RemoveMembership исключает пользователя из дополнительной команды
+	RemoveMembership(ctx context.Context, userID, teamName string) error
+
+	// SetRole задает роль участника команды
+	SetRole(ctx context.Context, userID, teamName, role string) error
+
+	// GetTeamRoles получает роли всех участников команды по их ID
+	GetTeamRoles(ctx context.Context, teamName string) (map[string]string, error)
+
+	// SetPrimaryTeam делает команду основной; прежняя основная остается дополнительной
+	SetPrimaryTeam(ctx context.Context, userID, teamName string) error
+}
@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// MoveRepository интерфейс истории переходов пользователей между командами
+type MoveRepository interface {
+	// RecordMove сохраняет переход
+	RecordMove(ctx context.Context, move *domain.TeamMove) error
+
+	// GetMovesByUser получает переходы пользователя в хронологическом порядке
+	GetMovesByUser(ctx context.Context, userID string) ([]domain.TeamMove, error)
+}
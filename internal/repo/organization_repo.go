@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// ErrNotFound — запрошенной организации или API-ключа нет. Остальные ошибки
+// репозитория означают сбой хранилища, а не отказ в доступе.
+var ErrNotFound = errors.New("not found")
+
+// OrganizationRepository интерфейс организаций и их API-ключей.
+// В отличие от остальных репозиториев не ограничен организацией из контекста.
+type OrganizationRepository interface {
+	// CreateOrganization создает организацию; существующая не перезаписывается
+	CreateOrganization(ctx context.Context, org *domain.Organization) error
+
+	// GetOrganization получает организацию по ID (ErrNotFound, если ее нет)
+	GetOrganization(ctx context.Context, orgID string) (*domain.Organization, error)
+
+	// ListOrganizations получает все организации
+	ListOrganizations(ctx context.Context) ([]domain.Organization, error)
+
+	// CreateAPIKey сохраняет хеш нового API-ключа и его владельца
+	CreateAPIKey(ctx context.Context, keyHash string, owner *domain.APIKey) error
+
+	// DeleteAPIKey отзывает API-ключ по хешу (ErrNotFound для неизвестного ключа)
+	DeleteAPIKey(ctx context.Context, keyHash string) error
+
+	// GetAPIKey получает владельца API-ключа по хешу (ErrNotFound для неизвестного ключа)
+	GetAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error)
+}
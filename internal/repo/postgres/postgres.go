@@ -3,11 +3,15 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,30 +19,73 @@ type Repository struct {
 	db *pgxpool.Pool
 }
 
+// querier — общее у пула и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// txKey — ключ контекста, под которым InTx хранит открытую транзакцию
+type txKey struct{}
+
 func New(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// ======================== TRANSACTIONS ========================
+
+// q возвращает транзакцию, открытую InTx, или пул
+func (r *Repository) q(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+func (r *Repository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Внутри другой транзакции Begin открывает точку сохранения
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // ======================== USER REPOSITORY ========================
 
+// userColumns — общий список колонок для выборок пользователей (порядок совпадает со scanUser).
+// Пользователь без команды (команда удалена) получает пустое team_name.
+const userColumns = `user_id, username, COALESCE(team_name, '') AS team_name, is_active, timezone, work_start_hour, work_end_hour, max_open_reviews, skills, seniority, COALESCE(email, '') AS email, departed_at, created_at, updated_at`
+
 func (r *Repository) CreateOrUpdateUser(ctx context.Context, user *domain.User) error {
 	query := `
-        INSERT INTO users (user_id, username, team_name, is_active, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        ON CONFLICT (user_id) DO UPDATE
-        SET username = $2, team_name = $3, is_active = $4, updated_at = $6
+        INSERT INTO users (org_id, user_id, username, team_name, is_active, timezone, work_start_hour, work_end_hour,
+                           skills, seniority, email, created_at, updated_at)
+        VALUES ($13, $1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12)
+        ON CONFLICT (org_id, user_id) DO UPDATE
+        SET username = $2, team_name = NULLIF($3, ''), is_active = $4,
+            timezone = $5, work_start_hour = $6, work_end_hour = $7,
+            skills = $8, seniority = $9, email = NULLIF($10, ''), updated_at = $12
     `
 	now := time.Now()
-	_, err := r.db.Exec(ctx, query, user.UserID, user.Username, user.TeamName, user.IsActive, now, now)
+	_, err := r.q(ctx).Exec(ctx, query,
+		user.UserID, user.Username, user.TeamName, user.IsActive,
+		user.Timezone, user.WorkStartHour, user.WorkEndHour,
+		textArray(user.Skills), user.Seniority, user.Email, now, now, orgID(ctx),
+	)
 	return err
 }
 
 func (r *Repository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
-	query := `SELECT user_id, username, team_name, is_active, created_at, updated_at FROM users WHERE user_id = $1`
-	u := &domain.User{}
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE user_id = $1 AND org_id = $2`
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, userID, orgID(ctx)))
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
@@ -46,50 +93,190 @@ func (r *Repository) GetUserByID(ctx context.Context, userID string) (*domain.Us
 }
 
 func (r *Repository) GetUsersByTeam(ctx context.Context, teamName string) ([]domain.User, error) {
-	query := `SELECT user_id, username, team_name, is_active, created_at, updated_at FROM users WHERE team_name = $1 ORDER BY user_id`
-	return r.scanUsers(ctx, query, teamName)
+	// Вместе с основными участниками — дополнительные
+	query := `SELECT ` + userColumns + ` FROM users
+        WHERE org_id = $2 AND user_id IN (SELECT user_id FROM team_memberships WHERE org_id = $2 AND team_name = $1)
+        ORDER BY user_id`
+	return r.scanUsers(ctx, query, teamName, orgID(ctx))
+}
+
+func (r *Repository) ListUsers(ctx context.Context) ([]domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE org_id = $1 AND departed_at IS NULL ORDER BY user_id`
+	return r.scanUsers(ctx, query, orgID(ctx))
 }
 
 func (r *Repository) GetActiveUsers(ctx context.Context, teamName string) ([]domain.User, error) {
-	query := `SELECT user_id, username, team_name, is_active, created_at, updated_at FROM users WHERE team_name = $1 AND is_active = true ORDER BY user_id`
-	return r.scanUsers(ctx, query, teamName)
+	query := `SELECT ` + userColumns + ` FROM users
+        WHERE org_id = $2 AND user_id IN (SELECT user_id FROM team_memberships WHERE org_id = $2 AND team_name = $1)
+          AND is_active = true
+        ORDER BY user_id`
+	return r.scanUsers(ctx, query, teamName, orgID(ctx))
 }
 
 func (r *Repository) SetUserActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
 	query := `
         UPDATE users
         SET is_active = $1, updated_at = $2
-        WHERE user_id = $3
-        RETURNING user_id, username, team_name, is_active, created_at, updated_at
-    `
-	u := &domain.User{}
-	err := r.db.QueryRow(ctx, query, isActive, time.Now(), userID).Scan(
-		&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
-	)
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, isActive, time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return u, nil
+}
+
+func (r *Repository) SetUserWorkingHours(ctx context.Context, userID, timezone string, startHour, endHour int) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET timezone = $1, work_start_hour = $2, work_end_hour = $3, updated_at = $4
+        WHERE user_id = $5 AND org_id = $6
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, timezone, startHour, endHour, time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return u, nil
+}
+
+func (r *Repository) SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpenReviews *int) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET max_open_reviews = $1, updated_at = $2
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, maxOpenReviews, time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return u, nil
+}
+
+func (r *Repository) SetUserSkills(ctx context.Context, userID string, skills []string) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET skills = $1, updated_at = $2
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, textArray(skills), time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return u, nil
+}
+
+func (r *Repository) SetUserSeniority(ctx context.Context, userID, seniority string) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET seniority = $1, updated_at = $2
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, seniority, time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return u, nil
+}
+
+func (r *Repository) SetUserTeam(ctx context.Context, userID, teamName string) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET team_name = $1, updated_at = $2
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(r.q(ctx).QueryRow(ctx, query, teamName, time.Now(), userID, orgID(ctx)))
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
 	return u, nil
 }
 
+func (r *Repository) SetUserDeparted(ctx context.Context, userID string, departedAt time.Time) (*domain.User, error) {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE users
+        SET is_active = false, team_name = NULL, departed_at = COALESCE(departed_at, $1), updated_at = $1
+        WHERE user_id = $2 AND org_id = $3
+        RETURNING ` + userColumns
+	u, err := scanUser(tx.QueryRow(ctx, query, departedAt, userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM team_memberships WHERE user_id = $1 AND org_id = $2`, userID, orgID(ctx)); err != nil {
+		return nil, err
+	}
+	return u, tx.Commit(ctx)
+}
+
+func (r *Repository) AnonymizeUser(ctx context.Context, userID string) (*domain.User, error) {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE users
+        SET username = $1, email = NULL, updated_at = $2
+        WHERE user_id = $3 AND org_id = $4
+        RETURNING ` + userColumns
+	u, err := scanUser(tx.QueryRow(ctx, query, domain.AnonymizedUsername, time.Now(), userID, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	// Внешние логины и адреса — тоже персональные данные
+	if _, err := tx.Exec(ctx, `DELETE FROM user_identities WHERE user_id = $1 AND org_id = $2`, userID, orgID(ctx)); err != nil {
+		return nil, err
+	}
+	return u, tx.Commit(ctx)
+}
+
 func (r *Repository) GetAllUsersByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
 	if len(userIDs) == 0 {
 		return []domain.User{}, nil
 	}
-	query := `SELECT user_id, username, team_name, is_active, created_at, updated_at FROM users WHERE user_id = ANY($1) ORDER BY user_id`
-	return r.scanUsers(ctx, query, userIDs)
+	query := `SELECT ` + userColumns + ` FROM users WHERE org_id = $2 AND user_id = ANY($1) ORDER BY user_id`
+	return r.scanUsers(ctx, query, userIDs, orgID(ctx))
 }
 
 // ======================== TEAM REPOSITORY ========================
 
+// teamPolicyColumns — колонки настроек команды (порядок совпадает с teamPolicyDest)
+const teamPolicyColumns = `default_max_open_reviews, assignment_strategy, require_senior, shadow_junior,
+    affinity_window_days, affinity_weight, min_reviewers, require_lead`
+
+func teamPolicyDest(p *domain.TeamPolicy) []interface{} {
+	return []interface{}{
+		&p.DefaultMaxOpenReviews, &p.Strategy, &p.RequireSenior, &p.ShadowJunior,
+		&p.AffinityWindowDays, &p.AffinityWeight, &p.MinReviewers, &p.RequireLead,
+	}
+}
+
+// teamPolicyArgs — значения настроек в порядке teamPolicyColumns
+func teamPolicyArgs(p *domain.TeamPolicy) []interface{} {
+	return []interface{}{
+		p.DefaultMaxOpenReviews, p.Strategy, p.RequireSenior, p.ShadowJunior,
+		p.AffinityWindowDays, p.AffinityWeight, p.MinReviewers, p.RequireLead,
+	}
+}
+
 func (r *Repository) CreateTeam(ctx context.Context, team *domain.Team) error {
-	query := `INSERT INTO teams (team_name, created_at, updated_at) VALUES ($1, $2, $3) ON CONFLICT (team_name) DO NOTHING`
-	_, err := r.db.Exec(ctx, query, team.TeamName, time.Now(), time.Now())
+	query := `
+        INSERT INTO teams (org_id, team_name, parent_team, ` + teamPolicyColumns + `, created_at, updated_at)
+        VALUES ($13, $1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        ON CONFLICT (org_id, team_name) DO NOTHING
+    `
+	args := append([]interface{}{team.TeamName, team.ParentTeam}, teamPolicyArgs(&team.Policy)...)
+	_, err := r.q(ctx).Exec(ctx, query, append(args, time.Now(), time.Now(), orgID(ctx))...)
 	if err != nil {
 		return err
 	}
-	var exists bool
-	err = r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`, team.TeamName).Scan(&exists)
+	exists, err := r.TeamExists(ctx, team.TeamName)
 	if err == nil && !exists {
 		return domain.NewError(domain.ErrorCodeTeamExists, "team already exists")
 	}
@@ -97,9 +284,11 @@ func (r *Repository) CreateTeam(ctx context.Context, team *domain.Team) error {
 }
 
 func (r *Repository) GetTeamByName(ctx context.Context, teamName string) (*domain.Team, error) {
-	query := `SELECT team_name, created_at, updated_at FROM teams WHERE team_name = $1`
+	query := `SELECT team_name, COALESCE(parent_team, ''), ` + teamPolicyColumns + `, created_at, updated_at FROM teams
+        WHERE team_name = $1 AND org_id = $2`
 	t := &domain.Team{}
-	err := r.db.QueryRow(ctx, query, teamName).Scan(&t.TeamName, &t.CreatedAt, &t.UpdatedAt)
+	dest := append([]interface{}{&t.TeamName, &t.ParentTeam}, teamPolicyDest(&t.Policy)...)
+	err := r.q(ctx).QueryRow(ctx, query, teamName, orgID(ctx)).Scan(append(dest, &t.CreatedAt, &t.UpdatedAt)...)
 	if err != nil {
 		return nil, fmt.Errorf("team not found: %w", err)
 	}
@@ -110,97 +299,420 @@ func (r *Repository) GetTeamByName(ctx context.Context, teamName string) (*domai
 	return t, nil
 }
 
+func (r *Repository) GetAllTeams(ctx context.Context) ([]domain.Team, error) {
+	query := `SELECT team_name, COALESCE(parent_team, ''), ` + teamPolicyColumns + `, created_at, updated_at FROM teams
+        WHERE org_id = $1 ORDER BY team_name`
+	rows, err := r.q(ctx).Query(ctx, query, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var teams []domain.Team
+	for rows.Next() {
+		t := domain.Team{}
+		dest := append([]interface{}{&t.TeamName, &t.ParentTeam}, teamPolicyDest(&t.Policy)...)
+		if err := rows.Scan(append(dest, &t.CreatedAt, &t.UpdatedAt)...); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range teams {
+		if teams[i].Members, err = r.GetUsersByTeam(ctx, teams[i].TeamName); err != nil {
+			return nil, err
+		}
+	}
+	return teams, nil
+}
+
 func (r *Repository) TeamExists(ctx context.Context, teamName string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`, teamName).Scan(&exists)
+	err := r.q(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1 AND org_id = $2)`,
+		teamName, orgID(ctx)).Scan(&exists)
 	return exists, err
 }
 
+func (r *Repository) RenameTeam(ctx context.Context, teamName, newTeamName string) error {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Участники и правила переезжают каскадом по внешним ключам
+	tag, err := tx.Exec(ctx, `UPDATE teams SET team_name = $2, updated_at = $3 WHERE team_name = $1 AND org_id = $4`,
+		teamName, newTeamName, time.Now(), orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team not found: %s", teamName)
+	}
+
+	// Обязательные команды репозиториев хранятся массивом, без внешнего ключа
+	if _, err := tx.Exec(ctx, `
+        UPDATE repositories SET required_teams = array_replace(required_teams, $1, $2)
+        WHERE org_id = $3 AND $1 = ANY(required_teams)
+    `, teamName, newTeamName, orgID(ctx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string) error {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Для кого команда основная — остаются без команды, для остальных снимаем дополнительное членство
+	if _, err := tx.Exec(ctx, `
+        UPDATE users SET team_name = NULL, updated_at = $3
+        WHERE org_id = $4 AND team_name = $1 AND user_id = ANY($2)
+    `, teamName, userIDs, time.Now(), orgID(ctx)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM team_memberships WHERE org_id = $3 AND team_name = $1 AND user_id = ANY($2)
+    `, teamName, userIDs, orgID(ctx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) CountOpenPRs(ctx context.Context, teamName string) (int, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM pull_requests pr
+        JOIN users u ON u.org_id = pr.org_id AND u.user_id = pr.author_id
+        WHERE pr.org_id = $2 AND u.team_name = $1 AND pr.status = 'OPEN'
+    `
+	var count int
+	err := r.q(ctx).QueryRow(ctx, query, teamName, orgID(ctx)).Scan(&count)
+	return count, err
+}
+
+func (r *Repository) DeleteTeam(ctx context.Context, teamName string) error {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// PR участников больше не из кого добирать
+	_, err = tx.Exec(ctx, `
+        DELETE FROM pr_staffing_queue q
+        USING pull_requests pr, users u
+        WHERE q.org_id = $2 AND pr.org_id = q.org_id AND u.org_id = q.org_id
+          AND q.repository = pr.repository AND q.pull_request_id = pr.pull_request_id
+          AND pr.author_id = u.user_id AND u.team_name = $1
+    `, teamName, orgID(ctx))
+	if err != nil {
+		return err
+	}
+
+	// Участники остаются без команды, правила удаляются каскадом
+	tag, err := tx.Exec(ctx, `DELETE FROM teams WHERE team_name = $1 AND org_id = $2`, teamName, orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team not found: %s", teamName)
+	}
+	if _, err := tx.Exec(ctx, `
+        UPDATE repositories SET required_teams = array_remove(required_teams, $1)
+        WHERE org_id = $2 AND $1 = ANY(required_teams)
+    `, teamName, orgID(ctx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) SetParentTeam(ctx context.Context, teamName, parentTeam string) error {
+	tag, err := r.q(ctx).Exec(ctx, `UPDATE teams SET parent_team = NULLIF($2, ''), updated_at = $3 WHERE team_name = $1 AND org_id = $4`,
+		teamName, parentTeam, time.Now(), orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team not found: %s", teamName)
+	}
+	return nil
+}
+
+func (r *Repository) GetSubteams(ctx context.Context, teamName string) ([]string, error) {
+	rows, err := r.q(ctx).Query(ctx, `SELECT team_name FROM teams WHERE org_id = $2 AND parent_team = $1 ORDER BY team_name`,
+		teamName, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (r *Repository) GetTeamAncestors(ctx context.Context, teamName string) ([]string, error) {
+	// Циклы отсекает сервис; глубина ограничена на всякий случай
+	query := `
+        WITH RECURSIVE ancestors(team_name, depth) AS (
+            SELECT parent_team, 1 FROM teams WHERE org_id = $2 AND team_name = $1 AND parent_team IS NOT NULL
+            UNION
+            SELECT t.parent_team, a.depth + 1
+            FROM teams t JOIN ancestors a ON t.org_id = $2 AND t.team_name = a.team_name
+            WHERE t.parent_team IS NOT NULL AND t.parent_team <> $1 AND a.depth < 32
+        )
+        SELECT team_name FROM ancestors ORDER BY depth
+    `
+	rows, err := r.q(ctx).Query(ctx, query, teamName, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
 func (r *Repository) GetTeamMembers(ctx context.Context, teamName string) ([]domain.User, error) {
 	return r.GetUsersByTeam(ctx, teamName)
 }
 
+func (r *Repository) GetTeamPolicy(ctx context.Context, teamName string) (*domain.TeamPolicy, error) {
+	query := `SELECT ` + teamPolicyColumns + ` FROM teams WHERE team_name = $1 AND org_id = $2`
+	p := &domain.TeamPolicy{}
+	if err := r.q(ctx).QueryRow(ctx, query, teamName, orgID(ctx)).Scan(teamPolicyDest(p)...); err != nil {
+		return nil, fmt.Errorf("team not found: %w", err)
+	}
+	return p, nil
+}
+
+func (r *Repository) UpdateTeamPolicy(ctx context.Context, teamName string, policy *domain.TeamPolicy) error {
+	query := `
+        UPDATE teams
+        SET (` + teamPolicyColumns + `, updated_at) = ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        WHERE team_name = $10 AND org_id = $11
+    `
+	args := append(teamPolicyArgs(policy), time.Now(), teamName, orgID(ctx))
+	tag, err := r.q(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team not found: %s", teamName)
+	}
+	return nil
+}
+
 // ======================== PR REPOSITORY ========================
 
 func (r *Repository) CreatePR(ctx context.Context, pr *domain.PullRequest) error {
 	query := `
-        INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-        VALUES ($1, $2, $3, $4, $5)
-        ON CONFLICT (pull_request_id) DO NOTHING
+        INSERT INTO pull_requests (org_id, repository, pull_request_id, pull_request_name, author_id, status, labels, paths, created_at)
+        VALUES ($9, $1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (org_id, repository, pull_request_id) DO NOTHING
     `
-	_, err := r.db.Exec(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, domain.PRStatusOpen, time.Now())
+	tag, err := r.q(ctx).Exec(ctx, query,
+		pr.Repository, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, domain.PRStatusOpen,
+		textArray(pr.Labels), textArray(pr.Paths), time.Now(), orgID(ctx),
+	)
 	if err != nil {
 		return err
 	}
+	// Параллельное создание того же PR успело раньше: его ревьюверов не трогаем
+	if tag.RowsAffected() == 0 {
+		return domain.NewError(domain.ErrorCodePRExists, "PR id already exists in the repository")
+	}
 	if len(pr.AssignedReviewers) > 0 {
-		return r.UpdateReviewers(ctx, pr.PullRequestID, pr.AssignedReviewers)
+		if err := r.UpdateReviewers(ctx, pr.Repository, pr.PullRequestID, pr.AssignedReviewers); err != nil {
+			return err
+		}
+	}
+	if len(pr.ShadowReviewers) > 0 {
+		return r.UpdateShadowReviewers(ctx, pr.Repository, pr.PullRequestID, pr.ShadowReviewers)
 	}
 	return nil
 }
 
-func (r *Repository) GetPRByID(ctx context.Context, prID string) (*domain.PullRequest, error) {
+func (r *Repository) LockPR(ctx context.Context, repository, prID string) error {
+	query := `SELECT 1 FROM pull_requests WHERE org_id = $3 AND repository = $1 AND pull_request_id = $2 FOR UPDATE`
+	var one int
+	err := r.q(ctx).QueryRow(ctx, query, repository, prID, orgID(ctx)).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.NewError(domain.ErrorCodeNotFound, "PR not found")
+	}
+	return err
+}
+
+func (r *Repository) GetPRByID(ctx context.Context, repository, prID string) (*domain.PullRequest, error) {
 	query := `
-        SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
-        FROM pull_requests WHERE pull_request_id = $1
+        SELECT pr.repository, pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.labels, pr.paths,
+               pr.created_at, pr.merged_at,
+               EXISTS(SELECT 1 FROM pr_staffing_queue q
+                      WHERE q.org_id = pr.org_id AND q.repository = pr.repository AND q.pull_request_id = pr.pull_request_id)
+        FROM pull_requests pr WHERE pr.org_id = $3 AND pr.repository = $1 AND pr.pull_request_id = $2
     `
 	pr := &domain.PullRequest{}
-	err := r.db.QueryRow(ctx, query, prID).Scan(
-		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt,
+	err := r.q(ctx).QueryRow(ctx, query, repository, prID, orgID(ctx)).Scan(
+		&pr.Repository, &pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Labels, &pr.Paths,
+		&pr.CreatedAt, &pr.MergedAt, &pr.Understaffed,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("PR not found: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, `SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = $1 ORDER BY reviewer_id`, prID)
+	query = `
+        SELECT reviewer_id, is_shadow FROM pr_reviewers
+        WHERE org_id = $3 AND repository = $1 AND pull_request_id = $2
+        ORDER BY reviewer_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, repository, prID, orgID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		var isShadow bool
+		if err := rows.Scan(&reviewerID, &isShadow); err != nil {
 			return nil, err
 		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		if isShadow {
+			pr.ShadowReviewers = append(pr.ShadowReviewers, reviewerID)
+		} else {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		}
 	}
 	return pr, rows.Err()
 }
 
-func (r *Repository) UpdateReviewers(ctx context.Context, prID string, reviewers []string) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1`, prID)
+func (r *Repository) GetAllPRs(ctx context.Context) ([]domain.PullRequest, error) {
+	query := `
+        SELECT repository, pull_request_id, pull_request_name, author_id, status, labels, paths, created_at, merged_at
+        FROM pull_requests
+        WHERE org_id = $1
+        ORDER BY created_at, repository, pull_request_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// PR индексируются парой репозиторий–ID
+	type prKey struct{ repository, prID string }
+	var prs []domain.PullRequest
+	index := make(map[prKey]int)
+	for rows.Next() {
+		pr := domain.PullRequest{}
+		if err := rows.Scan(&pr.Repository, &pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Labels, &pr.Paths,
+			&pr.CreatedAt, &pr.MergedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[prKey{pr.Repository, pr.PullRequestID}] = len(prs)
+		prs = append(prs, pr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.q(ctx).Query(ctx, `
+        SELECT repository, pull_request_id, reviewer_id, is_shadow FROM pr_reviewers
+        WHERE org_id = $1
+        ORDER BY repository, pull_request_id, reviewer_id
+    `, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key prKey
+		var reviewerID string
+		var isShadow bool
+		if err := rows.Scan(&key.repository, &key.prID, &reviewerID, &isShadow); err != nil {
+			return nil, err
+		}
+		pr := &prs[index[key]]
+		if isShadow {
+			pr.ShadowReviewers = append(pr.ShadowReviewers, reviewerID)
+		} else {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		}
+	}
+	return prs, rows.Err()
+}
+
+func (r *Repository) UpdateReviewers(ctx context.Context, repository, prID string, reviewers []string) error {
+	return r.replaceReviewers(ctx, repository, prID, reviewers, false)
+}
+
+func (r *Repository) UpdateShadowReviewers(ctx context.Context, repository, prID string, reviewers []string) error {
+	return r.replaceReviewers(ctx, repository, prID, reviewers, true)
+}
+
+// replaceReviewers заменяет обычных или «теневых» ревьюверов PR, не трогая другую группу
+func (r *Repository) replaceReviewers(ctx context.Context, repository, prID string, reviewers []string, isShadow bool) error {
+	tx, err := r.q(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if len(reviewers) == 0 {
-		return nil
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE org_id = $4 AND repository = $1 AND pull_request_id = $2 AND is_shadow = $3`,
+		repository, prID, isShadow, orgID(ctx))
+	if err != nil {
+		return err
 	}
 
-	query := `INSERT INTO pr_reviewers (pull_request_id, reviewer_id, assigned_at) VALUES ($1, $2, $3)`
+	query := `
+        INSERT INTO pr_reviewers (org_id, repository, pull_request_id, reviewer_id, is_shadow, assigned_at)
+        VALUES ($6, $1, $2, $3, $4, $5)
+    `
 	now := time.Now()
 	for _, reviewerID := range reviewers {
-		if _, err := r.db.Exec(ctx, query, prID, reviewerID, now); err != nil {
+		if _, err := tx.Exec(ctx, query, repository, prID, reviewerID, isShadow, now, orgID(ctx)); err != nil {
 			return err
 		}
 	}
-	return nil
+	return tx.Commit(ctx)
 }
 
-func (r *Repository) UpdatePRStatus(ctx context.Context, prID string, status string, mergedAt *time.Time) error {
-	query := `UPDATE pull_requests SET status = $1, merged_at = $2 WHERE pull_request_id = $3`
-	_, err := r.db.Exec(ctx, query, status, mergedAt, prID)
+func (r *Repository) UpdatePRStatus(ctx context.Context, repository, prID string, status string, mergedAt *time.Time) error {
+	query := `UPDATE pull_requests SET status = $1, merged_at = $2 WHERE org_id = $5 AND repository = $3 AND pull_request_id = $4`
+	_, err := r.q(ctx).Exec(ctx, query, status, mergedAt, repository, prID, orgID(ctx))
 	return err
 }
 
-func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]domain.PullRequest, error) {
+func (r *Repository) GetPRsByReviewer(ctx context.Context, userID, repository string) ([]domain.PullRequest, error) {
 	query := `
         SELECT DISTINCT
-            pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at
+            pr.repository, pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at
         FROM pull_requests pr
-        JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-        WHERE prr.reviewer_id = $1
+        JOIN pr_reviewers prr
+          ON pr.org_id = prr.org_id AND pr.repository = prr.repository AND pr.pull_request_id = prr.pull_request_id
+        WHERE prr.org_id = $3 AND prr.reviewer_id = $1 AND ($2 = '' OR pr.repository = $2)
         ORDER BY pr.created_at DESC
     `
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := r.q(ctx).Query(ctx, query, userID, repository, orgID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +721,7 @@ func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]dom
 	var prs []domain.PullRequest
 	for rows.Next() {
 		pr := domain.PullRequest{}
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt); err != nil {
+		if err := rows.Scan(&pr.Repository, &pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt); err != nil {
 			return nil, err
 		}
 		prs = append(prs, pr)
@@ -217,17 +729,79 @@ func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]dom
 	return prs, rows.Err()
 }
 
-func (r *Repository) PRExists(ctx context.Context, prID string) (bool, error) {
-	var exists bool
-	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`, prID).Scan(&exists)
-	return exists, err
-}
-
+func (r *Repository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+	query := `
+        SELECT prr.reviewer_id, COUNT(*)
+        FROM pr_reviewers prr
+        JOIN pull_requests pr
+          ON pr.org_id = prr.org_id AND pr.repository = prr.repository AND pr.pull_request_id = prr.pull_request_id
+        WHERE prr.org_id = $3 AND pr.status = $1 AND prr.reviewer_id = ANY($2) AND NOT prr.is_shadow
+        GROUP BY prr.reviewer_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, domain.PRStatusOpen, userIDs, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		counts[userID] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *Repository) CountRecentPairs(ctx context.Context, authorID string, since time.Time) (map[string]int, error) {
+	query := `
+        SELECT prr.reviewer_id, COUNT(*)
+        FROM pr_reviewers prr
+        JOIN pull_requests pr
+          ON pr.org_id = prr.org_id AND pr.repository = prr.repository AND pr.pull_request_id = prr.pull_request_id
+        WHERE prr.org_id = $3 AND pr.author_id = $1 AND pr.created_at >= $2 AND NOT prr.is_shadow
+        GROUP BY prr.reviewer_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, authorID, since, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reviewerID string
+		var count int
+		if err := rows.Scan(&reviewerID, &count); err != nil {
+			return nil, err
+		}
+		counts[reviewerID] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *Repository) PRExists(ctx context.Context, repository, prID string) (bool, error) {
+	var exists bool
+	err := r.q(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE org_id = $3 AND repository = $1 AND pull_request_id = $2)`,
+		repository, prID, orgID(ctx)).Scan(&exists)
+	return exists, err
+}
+
 // ======================== STATS REPOSITORY ========================
 
-func (r *Repository) GetReviewerStats(ctx context.Context) ([]repo.ReviewerStats, error) {
-	query := `SELECT reviewer_id, COUNT(*) FROM pr_reviewers GROUP BY reviewer_id ORDER BY COUNT(*) DESC, reviewer_id`
-	rows, err := r.db.Query(ctx, query)
+func (r *Repository) GetReviewerStats(ctx context.Context, repository string) ([]repo.ReviewerStats, error) {
+	query := `
+        SELECT reviewer_id, COUNT(*) FROM pr_reviewers
+        WHERE org_id = $2 AND ($1 = '' OR repository = $1)
+        GROUP BY reviewer_id
+        ORDER BY COUNT(*) DESC, reviewer_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, repository, orgID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -244,9 +818,40 @@ func (r *Repository) GetReviewerStats(ctx context.Context) ([]repo.ReviewerStats
 	return stats, rows.Err()
 }
 
-func (r *Repository) GetPRStats(ctx context.Context) (map[string]int, error) {
-	query := `SELECT status, COUNT(*) FROM pull_requests GROUP BY status`
-	rows, err := r.db.Query(ctx, query)
+func (r *Repository) GetTeamStats(ctx context.Context, repository string) ([]repo.TeamStats, error) {
+	query := `
+        SELECT t.team_name, COALESCE(t.parent_team, ''),
+               (SELECT COUNT(*) FROM users u WHERE u.org_id = t.org_id AND u.team_name = t.team_name),
+               (SELECT COUNT(*) FROM pr_reviewers rv JOIN users u ON u.org_id = rv.org_id AND u.user_id = rv.reviewer_id
+                WHERE u.org_id = t.org_id AND u.team_name = t.team_name AND ($1 = '' OR rv.repository = $1)),
+               (SELECT COUNT(*) FROM pull_requests pr JOIN users u ON u.org_id = pr.org_id AND u.user_id = pr.author_id
+                WHERE u.org_id = t.org_id AND u.team_name = t.team_name AND ($1 = '' OR pr.repository = $1)),
+               (SELECT COUNT(*) FROM pull_requests pr JOIN users u ON u.org_id = pr.org_id AND u.user_id = pr.author_id
+                WHERE u.org_id = t.org_id AND u.team_name = t.team_name AND pr.status = 'OPEN' AND ($1 = '' OR pr.repository = $1))
+        FROM teams t
+        WHERE t.org_id = $2
+        ORDER BY t.team_name
+    `
+	rows, err := r.q(ctx).Query(ctx, query, repository, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []repo.TeamStats
+	for rows.Next() {
+		s := repo.TeamStats{}
+		if err := rows.Scan(&s.TeamName, &s.ParentTeam, &s.Members, &s.AssignmentCount, &s.AuthoredPRs, &s.OpenPRs); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func (r *Repository) GetPRStats(ctx context.Context, repository string) (map[string]int, error) {
+	query := `SELECT status, COUNT(*) FROM pull_requests WHERE org_id = $2 AND ($1 = '' OR repository = $1) GROUP BY status`
+	rows, err := r.q(ctx).Query(ctx, query, repository, orgID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -264,10 +869,636 @@ func (r *Repository) GetPRStats(ctx context.Context) (map[string]int, error) {
 	return stats, rows.Err()
 }
 
+// ======================== STAFFING QUEUE REPOSITORY ========================
+
+func (r *Repository) Enqueue(ctx context.Context, repository, prID string, missing int, reason domain.ErrorCode) error {
+	query := `
+        INSERT INTO pr_staffing_queue (org_id, repository, pull_request_id, missing_reviewers, reason, enqueued_at)
+        VALUES ($6, $1, $2, $3, $4, $5)
+        ON CONFLICT (org_id, repository, pull_request_id) DO UPDATE
+        SET missing_reviewers = $3, reason = $4
+    `
+	_, err := r.q(ctx).Exec(ctx, query, repository, prID, missing, string(reason), time.Now(), orgID(ctx))
+	return err
+}
+
+func (r *Repository) Dequeue(ctx context.Context, repository, prID string) error {
+	_, err := r.q(ctx).Exec(ctx, `DELETE FROM pr_staffing_queue WHERE org_id = $3 AND repository = $1 AND pull_request_id = $2`,
+		repository, prID, orgID(ctx))
+	return err
+}
+
+func (r *Repository) MarkAttempt(ctx context.Context, repository, prID string, missing int, reason domain.ErrorCode) error {
+	query := `
+        UPDATE pr_staffing_queue
+        SET missing_reviewers = $1, reason = $2, attempts = attempts + 1, last_attempt_at = $3
+        WHERE org_id = $6 AND repository = $4 AND pull_request_id = $5
+    `
+	_, err := r.q(ctx).Exec(ctx, query, missing, string(reason), time.Now(), repository, prID, orgID(ctx))
+	return err
+}
+
+func (r *Repository) ListQueued(ctx context.Context) ([]domain.StaffingRequest, error) {
+	query := `
+        SELECT repository, pull_request_id, missing_reviewers, reason, attempts, enqueued_at, last_attempt_at
+        FROM pr_staffing_queue
+        WHERE org_id = $1
+        ORDER BY enqueued_at, repository, pull_request_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queue []domain.StaffingRequest
+	for rows.Next() {
+		q := domain.StaffingRequest{}
+		var reason string
+		if err := rows.Scan(&q.Repository, &q.PullRequestID, &q.MissingReviewers, &reason, &q.Attempts, &q.EnqueuedAt, &q.LastAttemptAt); err != nil {
+			return nil, err
+		}
+		q.Reason = domain.ErrorCode(reason)
+		queue = append(queue, q)
+	}
+	return queue, rows.Err()
+}
+
+// ======================== RULE REPOSITORY ========================
+
+func (r *Repository) CreateRule(ctx context.Context, rule *domain.ExclusionRule) error {
+	// Повторное добавление того же правила возвращает существующее
+	query := `
+        INSERT INTO assignment_rules (org_id, team_name, kind, subject, target, created_at)
+        VALUES ($6, $1, $2, $3, $4, $5)
+        ON CONFLICT (org_id, team_name, kind, subject, target) DO UPDATE SET team_name = EXCLUDED.team_name
+        RETURNING rule_id, created_at
+    `
+	return r.q(ctx).QueryRow(ctx, query, rule.TeamName, rule.Kind, rule.Subject, rule.Target, time.Now(), orgID(ctx)).
+		Scan(&rule.RuleID, &rule.CreatedAt)
+}
+
+func (r *Repository) GetRule(ctx context.Context, ruleID int64) (*domain.ExclusionRule, error) {
+	query := `
+        SELECT rule_id, team_name, kind, subject, target, created_at
+        FROM assignment_rules
+        WHERE rule_id = $1 AND org_id = $2
+    `
+	rule := &domain.ExclusionRule{}
+	err := r.q(ctx).QueryRow(ctx, query, ruleID, orgID(ctx)).
+		Scan(&rule.RuleID, &rule.TeamName, &rule.Kind, &rule.Subject, &rule.Target, &rule.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("rule not found: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *Repository) DeleteRule(ctx context.Context, ruleID int64) error {
+	tag, err := r.q(ctx).Exec(ctx, `DELETE FROM assignment_rules WHERE rule_id = $1 AND org_id = $2`, ruleID, orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("rule not found: %d", ruleID)
+	}
+	return nil
+}
+
+func (r *Repository) GetRulesByTeam(ctx context.Context, teamName string) ([]domain.ExclusionRule, error) {
+	query := `
+        SELECT rule_id, team_name, kind, subject, target, created_at
+        FROM assignment_rules
+        WHERE org_id = $2 AND team_name = $1
+        ORDER BY rule_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, teamName, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.ExclusionRule
+	for rows.Next() {
+		rule := domain.ExclusionRule{}
+		if err := rows.Scan(&rule.RuleID, &rule.TeamName, &rule.Kind, &rule.Subject, &rule.Target, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// ======================== ASSIGNMENT EVENT REPOSITORY ========================
+
+func (r *Repository) RecordAssignmentEvent(ctx context.Context, event *domain.AssignmentEvent) error {
+	// nil-объяснение сохраняется как NULL
+	var explanation []byte
+	if event.Explanation != nil {
+		var err error
+		if explanation, err = json.Marshal(event.Explanation); err != nil {
+			return err
+		}
+	}
+	query := `
+        INSERT INTO assignment_events (org_id, repository, pull_request_id, kind, reviewers, explanation, created_at)
+        VALUES ($7, $1, $2, $3, $4, $5, $6)
+        RETURNING event_id, created_at
+    `
+	return r.q(ctx).QueryRow(ctx, query, event.Repository, event.PullRequestID, event.Kind, textArray(event.Reviewers), explanation, time.Now(), orgID(ctx)).
+		Scan(&event.EventID, &event.CreatedAt)
+}
+
+// assignmentEventColumns — колонки события назначения (порядок совпадает с scanAssignmentEvent)
+const assignmentEventColumns = `event_id, repository, pull_request_id, kind, reviewers, explanation, created_at`
+
+func scanAssignmentEvent(row rowScanner) (*domain.AssignmentEvent, error) {
+	event := &domain.AssignmentEvent{}
+	var explanation []byte
+	if err := row.Scan(&event.EventID, &event.Repository, &event.PullRequestID, &event.Kind, &event.Reviewers, &explanation, &event.CreatedAt); err != nil {
+		return nil, err
+	}
+	if explanation != nil {
+		event.Explanation = &domain.Assignment{}
+		if err := json.Unmarshal(explanation, event.Explanation); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+func (r *Repository) GetAssignmentEvent(ctx context.Context, eventID int64) (*domain.AssignmentEvent, error) {
+	query := `SELECT ` + assignmentEventColumns + ` FROM assignment_events WHERE event_id = $1 AND org_id = $2`
+	return scanAssignmentEvent(r.q(ctx).QueryRow(ctx, query, eventID, orgID(ctx)))
+}
+
+func (r *Repository) GetAssignmentEvents(ctx context.Context, repository, prID string) ([]domain.AssignmentEvent, error) {
+	query := `
+        SELECT ` + assignmentEventColumns + ` FROM assignment_events
+        WHERE org_id = $3 AND repository = $1 AND pull_request_id = $2
+        ORDER BY event_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, repository, prID, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.AssignmentEvent
+	for rows.Next() {
+		event, err := scanAssignmentEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+// ======================== MOVE REPOSITORY ========================
+
+func (r *Repository) RecordMove(ctx context.Context, move *domain.TeamMove) error {
+	handovers, err := json.Marshal(move.Handovers)
+	if err != nil {
+		return err
+	}
+	var fromTeam, toTeam *string
+	if move.FromTeam != "" {
+		fromTeam = &move.FromTeam
+	}
+	if move.ToTeam != "" {
+		toTeam = &move.ToTeam
+	}
+	query := `
+        INSERT INTO team_moves (org_id, user_id, from_team, to_team, handovers, moved_at)
+        VALUES ($6, $1, $2, $3, $4, $5)
+        RETURNING move_id, moved_at
+    `
+	return r.q(ctx).QueryRow(ctx, query, move.UserID, fromTeam, toTeam, handovers, time.Now(), orgID(ctx)).
+		Scan(&move.MoveID, &move.MovedAt)
+}
+
+func (r *Repository) GetMovesByUser(ctx context.Context, userID string) ([]domain.TeamMove, error) {
+	query := `
+        SELECT move_id, user_id, COALESCE(from_team, ''), COALESCE(to_team, ''), handovers, moved_at
+        FROM team_moves
+        WHERE org_id = $2 AND user_id = $1
+        ORDER BY move_id
+    `
+	rows, err := r.q(ctx).Query(ctx, query, userID, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []domain.TeamMove
+	for rows.Next() {
+		move := domain.TeamMove{}
+		var handovers []byte
+		if err := rows.Scan(&move.MoveID, &move.UserID, &move.FromTeam, &move.ToTeam, &handovers, &move.MovedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(handovers, &move.Handovers); err != nil {
+			return nil, err
+		}
+		moves = append(moves, move)
+	}
+	return moves, rows.Err()
+}
+
+// ======================== MEMBERSHIP REPOSITORY ========================
+
+func (r *Repository) GetMemberships(ctx context.Context, userID string) ([]domain.TeamMembership, error) {
+	query := `
+        SELECT user_id, team_name, is_primary, role, created_at
+        FROM team_memberships
+        WHERE org_id = $2 AND user_id = $1
+        ORDER BY is_primary DESC, team_name
+    `
+	rows, err := r.q(ctx).Query(ctx, query, userID, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []domain.TeamMembership
+	for rows.Next() {
+		m := domain.TeamMembership{}
+		if err := rows.Scan(&m.UserID, &m.TeamName, &m.IsPrimary, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, rows.Err()
+}
+
+func (r *Repository) AddMembership(ctx context.Context, userID, teamName string) error {
+	_, err := r.q(ctx).Exec(ctx, `
+        INSERT INTO team_memberships (org_id, user_id, team_name, is_primary, created_at)
+        VALUES ($4, $1, $2, false, $3)
+        ON CONFLICT (org_id, user_id, team_name) DO NOTHING
+    `, userID, teamName, time.Now(), orgID(ctx))
+	return err
+}
+
+func (r *Repository) RemoveMembership(ctx context.Context, userID, teamName string) error {
+	tag, err := r.q(ctx).Exec(ctx,
+		`DELETE FROM team_memberships WHERE org_id = $3 AND user_id = $1 AND team_name = $2 AND NOT is_primary`,
+		userID, teamName, orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("membership not found: %s in %s", userID, teamName)
+	}
+	return nil
+}
+
+func (r *Repository) SetRole(ctx context.Context, userID, teamName, role string) error {
+	tag, err := r.q(ctx).Exec(ctx, `UPDATE team_memberships SET role = $3 WHERE org_id = $4 AND user_id = $1 AND team_name = $2`,
+		userID, teamName, role, orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("membership not found: %s in %s", userID, teamName)
+	}
+	return nil
+}
+
+func (r *Repository) GetTeamRoles(ctx context.Context, teamName string) (map[string]string, error) {
+	rows, err := r.q(ctx).Query(ctx, `SELECT user_id, role FROM team_memberships WHERE org_id = $2 AND team_name = $1`,
+		teamName, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[string]string)
+	for rows.Next() {
+		var userID, role string
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = role
+	}
+	return roles, rows.Err()
+}
+
+func (r *Repository) SetPrimaryTeam(ctx context.Context, userID, teamName string) error {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous *string
+	if err := tx.QueryRow(ctx, `SELECT team_name FROM users WHERE user_id = $1 AND org_id = $2`,
+		userID, orgID(ctx)).Scan(&previous); err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	// Триггер переносит основное членство; прежнюю команду возвращаем дополнительной
+	if _, err := tx.Exec(ctx, `UPDATE users SET team_name = $1, updated_at = $2 WHERE user_id = $3 AND org_id = $4`,
+		teamName, time.Now(), userID, orgID(ctx)); err != nil {
+		return err
+	}
+	if previous != nil && *previous != teamName {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO team_memberships (org_id, user_id, team_name, is_primary, created_at)
+            VALUES ($4, $1, $2, false, $3)
+            ON CONFLICT (org_id, user_id, team_name) DO NOTHING
+        `, userID, *previous, time.Now(), orgID(ctx)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ======================== CODE REPOSITORIES ========================
+
+// repositoryColumns — колонки репозитория (порядок совпадает со scanRepository)
+const repositoryColumns = `repo_name, COALESCE(owner_team, ''), strategy, require_senior, require_lead, shadow_junior,
+    min_reviewers, reviewers_count, required_teams, excluded_paths, created_at, updated_at`
+
+func scanRepository(row rowScanner) (*domain.Repository, error) {
+	rp := &domain.Repository{}
+	p := &rp.Policy
+	if err := row.Scan(&rp.Name, &rp.OwnerTeam, &p.Strategy, &p.RequireSenior, &p.RequireLead, &p.ShadowJunior,
+		&p.MinReviewers, &p.ReviewersCount, &p.RequiredTeams, &p.ExcludedPaths, &rp.CreatedAt, &rp.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+func (r *Repository) CreateRepository(ctx context.Context, repository *domain.Repository) error {
+	p := repository.Policy
+	tag, err := r.q(ctx).Exec(ctx, `
+        INSERT INTO repositories (org_id, repo_name, owner_team, strategy, require_senior, require_lead, shadow_junior,
+                                  min_reviewers, reviewers_count, required_teams, excluded_paths, created_at, updated_at)
+        VALUES ($12, $1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+        ON CONFLICT (org_id, repo_name) DO NOTHING
+    `, repository.Name, repository.OwnerTeam, p.Strategy, p.RequireSenior, p.RequireLead, p.ShadowJunior,
+		p.MinReviewers, p.ReviewersCount, textArray(p.RequiredTeams), textArray(p.ExcludedPaths), time.Now(), orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NewError(domain.ErrorCodeRepoExists, "repository already exists")
+	}
+	return nil
+}
+
+func (r *Repository) UpdateRepository(ctx context.Context, repository *domain.Repository) error {
+	p := repository.Policy
+	tag, err := r.q(ctx).Exec(ctx, `
+        UPDATE repositories
+        SET owner_team = NULLIF($2, ''), strategy = $3, require_senior = $4, require_lead = $5,
+            shadow_junior = $6, min_reviewers = $7, reviewers_count = $8, required_teams = $9,
+            excluded_paths = $10, updated_at = $11
+        WHERE repo_name = $1 AND org_id = $12
+    `, repository.Name, repository.OwnerTeam, p.Strategy, p.RequireSenior, p.RequireLead, p.ShadowJunior,
+		p.MinReviewers, p.ReviewersCount, textArray(p.RequiredTeams), textArray(p.ExcludedPaths), time.Now(), orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("repository not found: %s", repository.Name)
+	}
+	return nil
+}
+
+func (r *Repository) GetRepository(ctx context.Context, name string) (*domain.Repository, error) {
+	query := `SELECT ` + repositoryColumns + ` FROM repositories WHERE repo_name = $1 AND org_id = $2`
+	rp, err := scanRepository(r.q(ctx).QueryRow(ctx, query, name, orgID(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("repository not found: %w", err)
+	}
+	return rp, nil
+}
+
+func (r *Repository) ListRepositories(ctx context.Context, ownerTeam string) ([]domain.Repository, error) {
+	query := `SELECT ` + repositoryColumns + ` FROM repositories
+        WHERE org_id = $2 AND ($1 = '' OR owner_team = $1) ORDER BY repo_name`
+	rows, err := r.q(ctx).Query(ctx, query, ownerTeam, orgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repositories := []domain.Repository{}
+	for rows.Next() {
+		rp, err := scanRepository(rows)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, *rp)
+	}
+	return repositories, rows.Err()
+}
+
+// ======================== IDENTITY REPOSITORY ========================
+
+func (r *Repository) LinkIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	tx, err := r.q(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Уже привязанная к пользователю запись остается с прежним created_at
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM user_identities WHERE org_id = $4 AND user_id = $1 AND provider = $2 AND external_id <> $3
+    `, identity.UserID, identity.Provider, identity.ExternalID, orgID(ctx)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO user_identities (org_id, provider, external_id, user_id, created_at)
+        VALUES ($5, $1, $2, $3, $4)
+        ON CONFLICT (org_id, provider, external_id) DO NOTHING
+    `, identity.Provider, identity.ExternalID, identity.UserID, time.Now(), orgID(ctx)); err != nil {
+		return err
+	}
+	var owner string
+	if err := tx.QueryRow(ctx, `
+        SELECT user_id, created_at FROM user_identities WHERE org_id = $3 AND provider = $1 AND external_id = $2
+    `, identity.Provider, identity.ExternalID, orgID(ctx)).Scan(&owner, &identity.CreatedAt); err != nil {
+		return err
+	}
+	if owner != identity.UserID {
+		return domain.NewError(domain.ErrorCodeIdentityTaken,
+			identity.Provider+" identity "+identity.ExternalID+" belongs to "+owner)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) UnlinkIdentity(ctx context.Context, userID, provider string) error {
+	tag, err := r.q(ctx).Exec(ctx, `DELETE FROM user_identities WHERE org_id = $3 AND user_id = $1 AND provider = $2`,
+		userID, provider, orgID(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("identity not found: %s at %s", userID, provider)
+	}
+	return nil
+}
+
+func (r *Repository) GetIdentities(ctx context.Context, userID string) ([]domain.UserIdentity, error) {
+	query := `
+        SELECT user_id, provider, external_id, created_at
+        FROM user_identities
+        WHERE org_id = $2 AND user_id = $1
+        ORDER BY provider
+    `
+	return r.scanIdentities(ctx, query, userID, orgID(ctx))
+}
+
+func (r *Repository) FindIdentities(ctx context.Context, provider string, externalIDs []string) ([]domain.UserIdentity, error) {
+	if len(externalIDs) == 0 {
+		return []domain.UserIdentity{}, nil
+	}
+	query := `
+        SELECT user_id, provider, external_id, created_at
+        FROM user_identities
+        WHERE org_id = $3 AND provider = $1 AND external_id = ANY($2)
+        ORDER BY external_id
+    `
+	return r.scanIdentities(ctx, query, provider, externalIDs, orgID(ctx))
+}
+
+func (r *Repository) scanIdentities(ctx context.Context, query string, args ...interface{}) ([]domain.UserIdentity, error) {
+	rows, err := r.q(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []domain.UserIdentity{}
+	for rows.Next() {
+		i := domain.UserIdentity{}
+		if err := rows.Scan(&i.UserID, &i.Provider, &i.ExternalID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+// ======================== ORGANIZATION REPOSITORY ========================
+
+// Организации и ключи общие для экземпляра и не фильтруются по организации из контекста
+
+func (r *Repository) CreateOrganization(ctx context.Context, org *domain.Organization) error {
+	err := r.q(ctx).QueryRow(ctx, `
+        INSERT INTO organizations (org_id, name, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (org_id) DO NOTHING
+        RETURNING created_at
+    `, org.OrgID, org.Name, time.Now()).Scan(&org.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.NewError(domain.ErrorCodeOrgExists, "organization already exists")
+	}
+	return err
+}
+
+func (r *Repository) GetOrganization(ctx context.Context, orgID string) (*domain.Organization, error) {
+	org := &domain.Organization{}
+	err := r.q(ctx).QueryRow(ctx, `SELECT org_id, name, created_at FROM organizations WHERE org_id = $1`, orgID).
+		Scan(&org.OrgID, &org.Name, &org.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("organization %s: %w", orgID, repo.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *Repository) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	rows, err := r.q(ctx).Query(ctx, `SELECT org_id, name, created_at FROM organizations ORDER BY org_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		org := domain.Organization{}
+		if err := rows.Scan(&org.OrgID, &org.Name, &org.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *Repository) CreateAPIKey(ctx context.Context, keyHash string, owner *domain.APIKey) error {
+	_, err := r.q(ctx).Exec(ctx, `
+        INSERT INTO organization_api_keys (key_hash, org_id, user_id, scope, created_at)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+    `, keyHash, owner.OrgID, owner.UserID, owner.Scope, time.Now())
+	return err
+}
+
+func (r *Repository) DeleteAPIKey(ctx context.Context, keyHash string) error {
+	tag, err := r.q(ctx).Exec(ctx, `DELETE FROM organization_api_keys WHERE key_hash = $1`, keyHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("api key: %w", repo.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	err := r.q(ctx).QueryRow(ctx, `
+        SELECT org_id, COALESCE(user_id, ''), scope FROM organization_api_keys WHERE key_hash = $1
+    `, keyHash).Scan(&key.OrgID, &key.UserID, &key.Scope)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("api key: %w", repo.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
 // ======================== ВСПОМОГАТЕЛЬНЫЕ МЕТОДЫ ========================
 
+// orgID — организация запроса из контекста; по ней фильтруется каждый запрос к данным организаций
+func orgID(ctx context.Context) string {
+	return domain.OrganizationFrom(ctx)
+}
+
+// textArray подменяет nil на пустой срез: pgx кодирует nil как NULL, а колонки TEXT[] — NOT NULL
+func textArray(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// rowScanner — общий интерфейс pgx.Row и pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	u := &domain.User{}
+	err := row.Scan(
+		&u.UserID, &u.Username, &u.TeamName, &u.IsActive,
+		&u.Timezone, &u.WorkStartHour, &u.WorkEndHour, &u.MaxOpenReviews, &u.Skills, &u.Seniority,
+		&u.Email, &u.DepartedAt, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 func (r *Repository) scanUsers(ctx context.Context, query string, args ...interface{}) ([]domain.User, error) {
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := r.q(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -275,11 +1506,11 @@ func (r *Repository) scanUsers(ctx context.Context, query string, args ...interf
 
 	var users []domain.User
 	for rows.Next() {
-		u := domain.User{}
-		if err := rows.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-		users = append(users, u)
+		users = append(users, *u)
 	}
 	return users, rows.Err()
 }
@@ -6,23 +6,43 @@ import (
 	"time"
 )
 
-// PRRepository интерфейс для работы с PR
+// PRRepository интерфейс для работы с PR. PR определяется парой
+// репозиторий–ID; пустой репозиторий — PR, созданные без репозитория.
 type PRRepository interface {
-	// CreatePR создает новый PR
+	// CreatePR создает новый PR; PR_EXISTS, если PR с таким ID уже есть в репозитории
 	CreatePR(ctx context.Context, pr *domain.PullRequest) error
 
-	// GetPRByID получает PR по ID
-	GetPRByID(ctx context.Context, prID string) (*domain.PullRequest, error)
+	// GetPRByID получает PR по ID в репозитории
+	GetPRByID(ctx context.Context, repository, prID string) (*domain.PullRequest, error)
+
+	// LockPR блокирует строку PR до конца транзакции (см. Transactor):
+	// изменения ревьюверов одного PR выполняются по очереди
+	LockPR(ctx context.Context, repository, prID string) error
+
+	// GetAllPRs получает все PR с ревьюверами в порядке создания
+	GetAllPRs(ctx context.Context) ([]domain.PullRequest, error)
 
 	// UpdateReviewers обновляет список ревьюверов
-	UpdateReviewers(ctx context.Context, prID string, reviewers []string) error
+	UpdateReviewers(ctx context.Context, repository, prID string, reviewers []string) error
+
+	// UpdateShadowReviewers обновляет список «теневых» ревьюверов
+	UpdateShadowReviewers(ctx context.Context, repository, prID string, reviewers []string) error
 
 	// UpdatePRStatus обновляет статус PR
-	UpdatePRStatus(ctx context.Context, prID string, status string, mergedAt *time.Time) error
+	UpdatePRStatus(ctx context.Context, repository, prID string, status string, mergedAt *time.Time) error
+
+	// GetPRsByReviewer получает PR, где пользователь назначен ревьювером;
+	// непустой repository оставляет только PR этого репозитория
+	GetPRsByReviewer(ctx context.Context, userID, repository string) ([]domain.PullRequest, error)
+
+	// CountOpenReviews считает открытые PR, на которые назначен каждый из пользователей
+	// («теневые» назначения не учитываются)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
 
-	// GetPRsByReviewer получает PR, где пользователь назначен ревьювером
-	GetPRsByReviewer(ctx context.Context, userID string) ([]domain.PullRequest, error)
+	// CountRecentPairs считает, сколько раз каждый ревьювер назначался на PR автора,
+	// созданные начиная с since («теневые» назначения не учитываются)
+	CountRecentPairs(ctx context.Context, authorID string, since time.Time) (map[string]int, error)
 
 	// PRExists проверяет существование PR
-	PRExists(ctx context.Context, prID string) (bool, error)
+	PRExists(ctx context.Context, repository, prID string) (bool, error)
 }
@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// RepositoryRepository интерфейс репозиториев кода
+type RepositoryRepository interface {
+	// CreateRepository создает репозиторий; существующий не перезаписывается
+	CreateRepository(ctx context.Context, repository *domain.Repository) error
+
+	// UpdateRepository заменяет команду-владельца и переопределения политики
+	UpdateRepository(ctx context.Context, repository *domain.Repository) error
+
+	// GetRepository получает репозиторий по имени
+	GetRepository(ctx context.Context, name string) (*domain.Repository, error)
+
+	// ListRepositories получает репозитории; непустой ownerTeam оставляет только репозитории команды
+	ListRepositories(ctx context.Context, ownerTeam string) ([]domain.Repository, error)
+}
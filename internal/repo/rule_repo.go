@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// RuleRepository интерфейс для работы с правилами исключения ревьюверов
+type RuleRepository interface {
+	// CreateRule создает правило и заполняет его ID
+	CreateRule(ctx context.Context, rule *domain.ExclusionRule) error
+
+	// GetRule получает правило по ID
+	GetRule(ctx context.Context, ruleID int64) (*domain.ExclusionRule, error)
+
+	// DeleteRule удаляет правило
+	DeleteRule(ctx context.Context, ruleID int64) error
+
+	// GetRulesByTeam получает правила команды
+	GetRulesByTeam(ctx context.Context, teamName string) ([]domain.ExclusionRule, error)
+}
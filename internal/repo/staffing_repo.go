@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// StaffingQueueRepository интерфейс очереди недоукомплектованных PR
+type StaffingQueueRepository interface {
+	// Enqueue ставит PR в очередь или обновляет недостачу, если он уже там
+	Enqueue(ctx context.Context, repository, prID string, missing int, reason domain.ErrorCode) error
+
+	// Dequeue убирает PR из очереди
+	Dequeue(ctx context.Context, repository, prID string) error
+
+	// MarkAttempt фиксирует очередную попытку добора и оставшуюся недостачу
+	MarkAttempt(ctx context.Context, repository, prID string, missing int, reason domain.ErrorCode) error
+
+	// ListQueued получает очередь в порядке постановки
+	ListQueued(ctx context.Context) ([]domain.StaffingRequest, error)
+}
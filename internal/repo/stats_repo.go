@@ -8,10 +8,24 @@ type ReviewerStats struct {
 	AssignmentCount int
 }
 
+// TeamStats — статистика команды без учета подкоманд
+type TeamStats struct {
+	TeamName        string
+	ParentTeam      string
+	Members         int
+	AssignmentCount int // назначения участников ревьюверами
+	AuthoredPRs     int
+	OpenPRs         int
+}
+
+// StatsRepository — статистика; непустой repository учитывает только PR этого репозитория
 type StatsRepository interface {
 	// GetReviewerStats получает статистику по ревьюверам
-	GetReviewerStats(ctx context.Context) ([]ReviewerStats, error)
+	GetReviewerStats(ctx context.Context, repository string) ([]ReviewerStats, error)
+
+	// GetTeamStats получает статистику по каждой команде
+	GetTeamStats(ctx context.Context, repository string) ([]TeamStats, error)
 
 	// GetPRStats получает статистику по PR
-	GetPRStats(ctx context.Context) (map[string]int, error)
+	GetPRStats(ctx context.Context, repository string) (map[string]int, error)
 }
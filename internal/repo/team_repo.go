@@ -13,9 +13,39 @@ type TeamRepository interface {
 	// GetTeamByName получает команду по имени
 	GetTeamByName(ctx context.Context, teamName string) (*domain.Team, error)
 
+	// GetAllTeams получает все команды с участниками
+	GetAllTeams(ctx context.Context) ([]domain.Team, error)
+
 	// TeamExists проверяет существование команды
 	TeamExists(ctx context.Context, teamName string) (bool, error)
 
+	// RenameTeam переименовывает команду вместе с ссылками на нее
+	RenameTeam(ctx context.Context, teamName, newTeamName string) error
+
+	// RemoveTeamMembers исключает пользователей из команды, оставляя их без команды
+	RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string) error
+
+	// CountOpenPRs считает открытые PR, авторы которых состоят в команде
+	CountOpenPRs(ctx context.Context, teamName string) (int, error)
+
+	// DeleteTeam удаляет команду; участники остаются без команды, их PR — вне очереди добора
+	DeleteTeam(ctx context.Context, teamName string) error
+
+	// SetParentTeam задает родительскую команду (пусто — команда верхнего уровня)
+	SetParentTeam(ctx context.Context, teamName, parentTeam string) error
+
+	// GetSubteams получает имена непосредственных подкоманд
+	GetSubteams(ctx context.Context, teamName string) ([]string, error)
+
+	// GetTeamAncestors получает цепочку родительских команд, начиная с ближайшей
+	GetTeamAncestors(ctx context.Context, teamName string) ([]string, error)
+
 	// GetTeamMembers получает членов команды
 	GetTeamMembers(ctx context.Context, teamName string) ([]domain.User, error)
+
+	// GetTeamPolicy получает настройки назначения команды
+	GetTeamPolicy(ctx context.Context, teamName string) (*domain.TeamPolicy, error)
+
+	// UpdateTeamPolicy сохраняет настройки назначения команды
+	UpdateTeamPolicy(ctx context.Context, teamName string, policy *domain.TeamPolicy) error
 }
@@ -0,0 +1,11 @@
+package repo
+
+import "context"
+
+// Transactor выполняет несколько вызовов репозиториев в одной транзакции
+type Transactor interface {
+	// InTx вызывает fn в транзакции: вызовы репозиториев с переданным в fn
+	// контекстом идут в нее. Ошибка fn откатывает транзакцию; вложенный InTx
+	// откатывает только свою часть.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -3,6 +3,7 @@ package repo
 import (
 	"context"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"time"
 )
 
 // UserRepository интерфейс для работы с пользователями
@@ -13,15 +14,39 @@ type UserRepository interface {
 	// GetUserByID получает пользователя по ID
 	GetUserByID(ctx context.Context, userID string) (*domain.User, error)
 
-	// GetUsersByTeam получает всех пользователей команды
+	// GetUsersByTeam получает всех пользователей команды, включая дополнительных участников
 	GetUsersByTeam(ctx context.Context, teamName string) ([]domain.User, error)
 
-	// GetActiveUsers получает активных пользователей команды
+	// GetActiveUsers получает активных пользователей команды, включая дополнительных участников
 	GetActiveUsers(ctx context.Context, teamName string) ([]domain.User, error)
 
 	// SetUserActive устанавливает флаг активности
 	SetUserActive(ctx context.Context, userID string, isActive bool) (*domain.User, error)
 
+	// SetUserWorkingHours обновляет часовой пояс и рабочее окно пользователя
+	SetUserWorkingHours(ctx context.Context, userID, timezone string, startHour, endHour int) (*domain.User, error)
+
+	// SetUserMaxOpenReviews задает персональный лимит открытых ревью (nil — значение команды)
+	SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpenReviews *int) (*domain.User, error)
+
+	// SetUserSkills заменяет навыки пользователя
+	SetUserSkills(ctx context.Context, userID string, skills []string) (*domain.User, error)
+
+	// SetUserSeniority задает уровень пользователя
+	SetUserSeniority(ctx context.Context, userID, seniority string) (*domain.User, error)
+
+	// SetUserTeam переводит пользователя в команду
+	SetUserTeam(ctx context.Context, userID, teamName string) (*domain.User, error)
+
+	// SetUserDeparted помечает пользователя ушедшим: деактивирует и исключает из команды
+	SetUserDeparted(ctx context.Context, userID string, departedAt time.Time) (*domain.User, error)
+
+	// AnonymizeUser стирает персональные данные пользователя, сохраняя его ID для статистики
+	AnonymizeUser(ctx context.Context, userID string) (*domain.User, error)
+
+	// ListUsers получает всех пользователей, кроме ушедших
+	ListUsers(ctx context.Context) ([]domain.User, error)
+
 	// GetAllUsersByIDs получает пользователей по списку ID
 	GetAllUsersByIDs(ctx context.Context, userIDs []string) ([]domain.User, error)
 }
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// IdentityService сервис внешних учетных записей: интеграции переводят
+// логины GitHub, GitLab, Slack и адреса почты в user_id сервиса
+type IdentityService struct {
+	userRepo     repo.UserRepository
+	identityRepo repo.IdentityRepository
+}
+
+// NewIdentityService создает сервис учетных записей
+func NewIdentityService(userRepo repo.UserRepository, identityRepo repo.IdentityRepository) *IdentityService {
+	return &IdentityService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+	}
+}
+
+// LinkIdentity привязывает учетную запись провайдера к пользователю. Прежняя
+// запись пользователя у этого провайдера заменяется; запись, привязанная
+// к другому пользователю, не перехватывается (IDENTITY_TAKEN).
+func (s *IdentityService) LinkIdentity(ctx context.Context, userID, provider, externalID string) (*domain.UserIdentity, error) {
+	externalID, err := domain.NormalizeIdentity(provider, externalID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if user.DepartedAt != nil {
+		return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed")
+	}
+
+	identity := &domain.UserIdentity{UserID: userID, Provider: provider, ExternalID: externalID}
+	if err := s.identityRepo.LinkIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// UnlinkIdentity отвязывает учетную запись пользователя у провайдера
+func (s *IdentityService) UnlinkIdentity(ctx context.Context, userID, provider string) error {
+	if err := s.identityRepo.UnlinkIdentity(ctx, userID, provider); err != nil {
+		return domain.NewError(domain.ErrorCodeNotFound, "identity not found")
+	}
+	return nil
+}
+
+// GetIdentities получает учетные записи пользователя
+func (s *IdentityService) GetIdentities(ctx context.Context, userID string) ([]domain.UserIdentity, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return s.identityRepo.GetIdentities(ctx, userID)
+}
+
+// Resolve переводит внешние ID провайдера в учетные записи. Внешние ID,
+// не привязанные ни к кому, возвращаются вторым значением в исходном виде.
+// Ушедшие пользователи тоже находятся: на них ссылаются старые PR.
+func (s *IdentityService) Resolve(ctx context.Context, provider string, externalIDs []string) ([]domain.UserIdentity, []string, error) {
+	if len(externalIDs) == 0 {
+		return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "external_id is required")
+	}
+	normalized := make([]string, len(externalIDs))
+	for i, externalID := range externalIDs {
+		var err error
+		if normalized[i], err = domain.NormalizeIdentity(provider, externalID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	identities, err := s.identityRepo.FindIdentities(ctx, provider, normalized)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		found[identity.ExternalID] = true
+	}
+	unresolved := []string{}
+	for i, externalID := range externalIDs {
+		if !found[normalized[i]] {
+			unresolved = append(unresolved, externalID)
+		}
+	}
+	return identities, unresolved, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// apiKeyPrefix отличает ключи сервиса от прочих секретов в конфигурации клиентов
+const apiKeyPrefix = "prk_"
+
+// OrganizationService сервис организаций: создание, API-ключи и определение
+// организации запроса
+type OrganizationService struct {
+	orgRepo  repo.OrganizationRepository
+	userRepo repo.UserRepository
+}
+
+// NewOrganizationService создает сервис организаций
+func NewOrganizationService(orgRepo repo.OrganizationRepository, userRepo repo.UserRepository) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+	}
+}
+
+// CreateOrganization создает организацию (ORGANIZATION_EXISTS, если она уже есть)
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *domain.Organization) error {
+	org.OrgID = strings.TrimSpace(org.OrgID)
+	if err := domain.ValidateOrganizationID(org.OrgID); err != nil {
+		return err
+	}
+	if org.Name = strings.TrimSpace(org.Name); org.Name == "" {
+		org.Name = org.OrgID
+	}
+	return s.orgRepo.CreateOrganization(ctx, org)
+}
+
+// ListOrganizations получает все организации
+func (s *OrganizationService) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	return s.orgRepo.ListOrganizations(ctx)
+}
+
+// IssueAPIKey выпускает API-ключ организации: с userID — ключ, действующий от
+// имени этого пользователя, без него — ключ администратора организации.
+// Ключ возвращается только здесь: хранится лишь его хеш.
+func (s *OrganizationService) IssueAPIKey(ctx context.Context, orgID, userID string) (string, error) {
+	if err := s.checkOrganization(ctx, orgID); err != nil {
+		return "", err
+	}
+	if userID != "" {
+		if _, err := s.userRepo.GetUserByID(domain.WithOrganization(ctx, orgID), userID); err != nil {
+			return "", domain.NewError(domain.ErrorCodeNotFound, "user not found")
+		}
+	}
+	return s.issue(ctx, &domain.APIKey{OrgID: orgID, UserID: userID, Scope: domain.APIKeyScopeAPI})
+}
+
+// IssueScimToken выпускает SCIM-токен организации для identity provider'а:
+// он действует только на /scim/v2, а обычный API-ключ — только вне его
+func (s *OrganizationService) IssueScimToken(ctx context.Context, orgID string) (string, error) {
+	if err := s.checkOrganization(ctx, orgID); err != nil {
+		return "", err
+	}
+	return s.issue(ctx, &domain.APIKey{OrgID: orgID, Scope: domain.APIKeyScopeSCIM})
+}
+
+// checkOrganization проверяет, что организация существует
+func (s *OrganizationService) checkOrganization(ctx context.Context, orgID string) error {
+	_, err := s.orgRepo.GetOrganization(ctx, orgID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return domain.NewError(domain.ErrorCodeNotFound, "organization not found")
+	}
+	return err
+}
+
+// issue создает ключ владельца owner и сохраняет его хеш
+func (s *OrganizationService) issue(ctx context.Context, owner *domain.APIKey) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	if err := s.orgRepo.CreateAPIKey(ctx, hashAPIKey(key), owner); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// RevokeAPIKey отзывает API-ключ
+func (s *OrganizationService) RevokeAPIKey(ctx context.Context, key string) error {
+	err := s.orgRepo.DeleteAPIKey(ctx, hashAPIKey(key))
+	if errors.Is(err, repo.ErrNotFound) {
+		return domain.NewError(domain.ErrorCodeNotFound, "api key not found")
+	}
+	return err
+}
+
+// ResolveAPIKey получает владельца API-ключа (UNAUTHORIZED для неизвестного
+// ключа; сбой хранилища возвращается как есть)
+func (s *OrganizationService) ResolveAPIKey(ctx context.Context, key string) (*domain.APIKey, error) {
+	apiKey, err := s.orgRepo.GetAPIKey(ctx, hashAPIKey(key))
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, domain.NewError(domain.ErrorCodeUnauthorized, "invalid api key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// ResolveOrganization проверяет организацию, названную в заголовке запроса
+// (UNAUTHORIZED для неизвестной; сбой хранилища возвращается как есть)
+func (s *OrganizationService) ResolveOrganization(ctx context.Context, orgID string) (string, error) {
+	org, err := s.orgRepo.GetOrganization(ctx, orgID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return "", domain.NewError(domain.ErrorCodeUnauthorized, "unknown organization: "+orgID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return org.OrgID, nil
+}
+
+// hashAPIKey — SHA-256 ключа в hex, в таком виде ключи хранятся в БД
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOrgRepo возвращает err на любой запрос организации или ключа
+type stubOrgRepo struct {
+	repo.OrganizationRepository
+	err error
+}
+
+func (r stubOrgRepo) GetOrganization(context.Context, string) (*domain.Organization, error) {
+	return nil, r.err
+}
+
+func (r stubOrgRepo) GetAPIKey(context.Context, string) (*domain.APIKey, error) {
+	return nil, r.err
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		unauthorized bool
+	}{
+		{name: "not found", err: fmt.Errorf("api key: %w", repo.ErrNotFound), unauthorized: true},
+		{name: "storage failure", err: errors.New("connection refused"), unauthorized: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewOrganizationService(stubOrgRepo{err: tt.err}, nil)
+
+			_, keyErr := svc.ResolveAPIKey(context.Background(), "prk_key")
+			_, orgErr := svc.ResolveOrganization(context.Background(), "acme")
+			for _, err := range []error{keyErr, orgErr} {
+				require.Error(t, err)
+				var domErr domain.DomainError
+				if tt.unauthorized {
+					require.True(t, errors.As(err, &domErr))
+					assert.Equal(t, domain.ErrorCodeUnauthorized, domErr.Code)
+				} else {
+					// Сбой хранилища не выдается за отказ в доступе: middleware ответит 500
+					assert.False(t, errors.As(err, &domErr))
+					assert.ErrorIs(t, err, tt.err)
+				}
+			}
+		})
+	}
+}
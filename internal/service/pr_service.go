@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
 	"time"
@@ -13,71 +14,172 @@ import (
 type PRService struct {
 	prRepo        repo.PRRepository
 	userRepo      repo.UserRepository
+	queueRepo     repo.StaffingQueueRepository
+	transactor    repo.Transactor
 	assignmentSvc *ReviewerAssignmentService
+	notifier      StaffingNotifier
 }
 
 // NewPRService создает новый сервис PR
 func NewPRService(
 	prRepo repo.PRRepository,
 	userRepo repo.UserRepository,
+	queueRepo repo.StaffingQueueRepository,
+	transactor repo.Transactor,
 	assignmentSvc *ReviewerAssignmentService,
+	notifier StaffingNotifier,
 ) *PRService {
 	return &PRService{
 		prRepo:        prRepo,
 		userRepo:      userRepo,
+		queueRepo:     queueRepo,
+		transactor:    transactor,
 		assignmentSvc: assignmentSvc,
+		notifier:      notifier,
 	}
 }
 
+// CreatePRRequest — параметры создания PR
+type CreatePRRequest struct {
+	Repository         string // пусто — PR вне репозиториев
+	PullRequestID      string
+	Name               string
+	AuthorID           string
+	Labels             []string
+	Paths              []string
+	RequestedReviewers []string // назначаются обязательно, остальные подбираются автоматически
+}
+
 // CreatePR создает новый PR и назначает ревьюверов
-func (s *PRService) CreatePR(ctx context.Context, prID, name, authorID string) (*domain.PullRequest, error) {
-	// Проверяем существование PR
-	exists, err := s.prRepo.PRExists(ctx, prID)
+func (s *PRService) CreatePR(ctx context.Context, req CreatePRRequest) (*domain.PullRequest, *domain.Assignment, error) {
+	// Проверяем существование PR, чтобы не подбирать ревьюверов зря; параллельное
+	// создание того же PR отсекает CreatePR внутри транзакции
+	exists, err := s.prRepo.PRExists(ctx, req.Repository, req.PullRequestID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if exists {
-		return nil, domain.NewError(domain.ErrorCodePRExists, "PR id already exists")
+		return nil, nil, domain.NewError(domain.ErrorCodePRExists, "PR id already exists in the repository")
 	}
 
-	// Проверяем существование автора
-	_, err = s.userRepo.GetUserByID(ctx, authorID)
+	pr, assignment, required, reason, err := s.planAssignment(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// PR, его назначение и место в очереди сохраняются вместе: воркер не
+	// увидит PR без записи в очереди, а сбой не оставит PR без истории
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.prRepo.CreatePR(ctx, pr); err != nil {
+			if _, ok := err.(domain.DomainError); ok {
+				return err
+			}
+			return fmt.Errorf("failed to create PR: %w", err)
+		}
+		if err := s.assignmentSvc.RecordAssignment(ctx, pr, domain.AssignmentEventCreate, pr.AssignedReviewers, assignment); err != nil {
+			return fmt.Errorf("failed to record assignment: %w", err)
+		}
+
+		// Недоукомплектованный PR ставим в очередь на добор
+		if missing := required - len(pr.AssignedReviewers); missing > 0 {
+			if err := s.queueRepo.Enqueue(ctx, pr.Repository, pr.PullRequestID, missing, reason); err != nil {
+				return fmt.Errorf("failed to enqueue PR: %w", err)
+			}
+			pr.Understaffed = true
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, domain.NewError(domain.ErrorCodeNotFound, "author not found")
+		return nil, nil, err
 	}
 
-	// Создаем PR
-	pr := &domain.PullRequest{
-		PullRequestID:   prID,
-		PullRequestName: name,
-		AuthorID:        authorID,
+	return pr, assignment, nil
+}
+
+// PreviewAssignment подбирает ревьюверов для гипотетического PR, ничего не сохраняя.
+// PullRequestID и Name запроса не используются.
+func (s *PRService) PreviewAssignment(ctx context.Context, req CreatePRRequest) (*domain.PullRequest, *domain.Assignment, error) {
+	pr, assignment, required, _, err := s.planAssignment(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	pr.Understaffed = len(pr.AssignedReviewers) < required
+	return pr, assignment, nil
+}
+
+// planAssignment строит PR из запроса и подбирает на него ревьюверов; required —
+// сколько ревьюверов нужно PR по политике. Если все кандидаты упёрлись в лимит,
+// ошибки нет: ревьюверов меньше нужного, а reason объясняет недостачу.
+func (s *PRService) planAssignment(ctx context.Context, req CreatePRRequest) (
+	pr *domain.PullRequest,
+	assignment *domain.Assignment,
+	required int,
+	reason domain.ErrorCode,
+	err error,
+) {
+	// Проверяем существование автора
+	if _, err := s.userRepo.GetUserByID(ctx, req.AuthorID); err != nil {
+		return nil, nil, 0, "", domain.NewError(domain.ErrorCodeNotFound, "author not found")
+	}
+
+	pr = &domain.PullRequest{
+		Repository:      req.Repository,
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.Name,
+		AuthorID:        req.AuthorID,
 		Status:          domain.PRStatusOpen,
+		Labels:          domain.NormalizeTags(req.Labels),
+		Paths:           domain.NormalizePaths(req.Paths),
 		CreatedAt:       time.Now(),
 	}
 
-	// Назначаем ревьюверов
-	reviewers, err := s.assignmentSvc.AssignReviewers(ctx, pr)
+	// Запрошенные вручную ревьюверы проходят те же проверки, что и автоматические
+	requested := make([]string, 0, len(req.RequestedReviewers))
+	seen := make(map[string]bool, len(req.RequestedReviewers))
+	for _, userID := range req.RequestedReviewers {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if _, err := s.assignmentSvc.ValidateReviewer(ctx, pr, userID); err != nil {
+			return nil, nil, 0, "", err
+		}
+		requested = append(requested, userID)
+	}
+	required, err = s.assignmentSvc.RequiredReviewers(ctx, pr)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, "", err
+	}
+	if len(requested) > required {
+		return nil, nil, 0, "", domain.NewError(domain.ErrorCodeInvalidInput, "too many requested reviewers")
 	}
-	pr.AssignedReviewers = reviewers
+	pr.AssignedReviewers = requested
 
-	// Сохраняем PR
-	if err := s.prRepo.CreatePR(ctx, pr); err != nil {
-		return nil, fmt.Errorf("failed to create PR: %w", err)
+	reason = domain.ErrorCodeNoCandidate
+	assignment, err = s.assignmentSvc.AssignReviewers(ctx, pr)
+	var domErr domain.DomainError
+	switch {
+	case errors.As(err, &domErr) && domErr.Code == domain.ErrorCodeNoCapacity:
+		// Ревьюверов нет, но объяснение, кто упёрся в лимит, сохраняем
+		reason = domain.ErrorCodeNoCapacity
+	case err != nil:
+		return nil, nil, 0, "", err
 	}
+	assignment.Requested = requested
+	pr.AssignedReviewers = append(requested, assignment.Reviewers...)
+	pr.ShadowReviewers = assignment.ShadowReviewers
 
-	return pr, nil
+	return pr, assignment, required, reason, nil
 }
 
-// GetPR получает PR по ID
-func (s *PRService) GetPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	return s.prRepo.GetPRByID(ctx, prID)
+// GetPR получает PR по ID в репозитории
+func (s *PRService) GetPR(ctx context.Context, repository, prID string) (*domain.PullRequest, error) {
+	return s.prRepo.GetPRByID(ctx, repository, prID)
 }
 
 // MergePR помечает PR как MERGED (идемпотентно)
-func (s *PRService) MergePR(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	pr, err := s.prRepo.GetPRByID(ctx, prID)
+func (s *PRService) MergePR(ctx context.Context, repository, prID string) (*domain.PullRequest, error) {
+	pr, err := s.prRepo.GetPRByID(ctx, repository, prID)
 	if err != nil {
 		return nil, domain.NewError(domain.ErrorCodeNotFound, "PR not found")
 	}
@@ -89,31 +191,101 @@ func (s *PRService) MergePR(ctx context.Context, prID string) (*domain.PullReque
 
 	// Обновляем статус
 	now := time.Now()
-	if err := s.prRepo.UpdatePRStatus(ctx, prID, domain.PRStatusMerged, &now); err != nil {
+	if err := s.prRepo.UpdatePRStatus(ctx, repository, prID, domain.PRStatusMerged, &now); err != nil {
 		return nil, err
 	}
 
+	// Смердженному PR ревьюверы больше не нужны, а у его ревьюверов освободился лимит
+	if err := s.queueRepo.Dequeue(ctx, repository, prID); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+
 	pr.Status = domain.PRStatusMerged
 	pr.MergedAt = &now
+	pr.Understaffed = false
 	return pr, nil
 }
 
-// ReassignReviewer переназначает ревьювера на PR
-func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*domain.PullRequest, string, error) {
-	newReviewerID, err := s.assignmentSvc.ReassignReviewer(ctx, prID, oldReviewerID)
+// ReassignReviewer переназначает ревьювера на PR; newReviewerID пуст — замена подбирается
+// автоматически. Новый ревьювер — единственный в assignment.Reviewers.
+func (s *PRService) ReassignReviewer(ctx context.Context, repository, prID, oldReviewerID, newReviewerID string) (*domain.PullRequest, *domain.Assignment, error) {
+	assignment, err := s.assignmentSvc.ReassignReviewer(ctx, repository, prID, oldReviewerID, newReviewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// У снятого ревьювера освободился лимит
+	s.notifier.Notify()
+
+	pr, err := s.prRepo.GetPRByID(ctx, repository, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pr, assignment, nil
+}
+
+// AddReviewer вручную назначает ревьювера на PR
+func (s *PRService) AddReviewer(ctx context.Context, repository, prID, userID string) (*domain.PullRequest, error) {
+	if err := s.assignmentSvc.AddReviewer(ctx, repository, prID, userID); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.prRepo.GetPRByID(ctx, repository, prID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	pr, err := s.prRepo.GetPRByID(ctx, prID)
+	// Добранный вручную PR больше не ждет в очереди
+	required, err := s.assignmentSvc.RequiredReviewers(ctx, pr)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if len(pr.AssignedReviewers) >= required && pr.Understaffed {
+		if err := s.queueRepo.Dequeue(ctx, repository, prID); err != nil {
+			return nil, err
+		}
+		pr.Understaffed = false
 	}
+	return pr, nil
+}
+
+// RemoveReviewer снимает ревьювера с PR без замены. PR не ставится в очередь
+// добора: снятие без замены — осознанное решение
+func (s *PRService) RemoveReviewer(ctx context.Context, repository, prID, userID string) (*domain.PullRequest, error) {
+	if err := s.assignmentSvc.RemoveReviewer(ctx, repository, prID, userID); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+
+	return s.prRepo.GetPRByID(ctx, repository, prID)
+}
+
+// GetReviewsForUser получает PR, где пользователь назначен ревьювером;
+// непустой repository оставляет только PR этого репозитория
+func (s *PRService) GetReviewsForUser(ctx context.Context, userID, repository string) ([]domain.PullRequest, error) {
+	return s.prRepo.GetPRsByReviewer(ctx, userID, repository)
+}
+
+// GetAssignmentHistory получает сохраненные решения о назначении ревьюверов на PR
+func (s *PRService) GetAssignmentHistory(ctx context.Context, repository, prID string) ([]domain.AssignmentEvent, error) {
+	exists, err := s.prRepo.PRExists(ctx, repository, prID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "PR not found")
+	}
+	return s.assignmentSvc.GetAssignmentEvents(ctx, repository, prID)
+}
 
-	return pr, newReviewerID, nil
+// ReplayAssignment воспроизводит сохраненное решение о назначении по его seed'у
+// и снимку кандидатов
+func (s *PRService) ReplayAssignment(ctx context.Context, eventID int64) (*domain.AssignmentEvent, *domain.Assignment, error) {
+	return s.assignmentSvc.Replay(ctx, eventID)
 }
 
-// GetReviewsForUser получает PR, где пользователь назначен ревьювером
-func (s *PRService) GetReviewsForUser(ctx context.Context, userID string) ([]domain.PullRequest, error) {
-	return s.prRepo.GetPRsByReviewer(ctx, userID)
+// GetStaffingQueue получает PR, ожидающие добора ревьюверов
+func (s *PRService) GetStaffingQueue(ctx context.Context) ([]domain.StaffingRequest, error) {
+	return s.queueRepo.ListQueued(ctx)
 }
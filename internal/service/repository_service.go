@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// RepositoryService сервис репозиториев кода: команда-владелец и
+// переопределения политики назначения для PR репозитория
+type RepositoryService struct {
+	repoRepo repo.RepositoryRepository
+	teamRepo repo.TeamRepository
+}
+
+// NewRepositoryService создает сервис репозиториев
+func NewRepositoryService(repoRepo repo.RepositoryRepository, teamRepo repo.TeamRepository) *RepositoryService {
+	return &RepositoryService{
+		repoRepo: repoRepo,
+		teamRepo: teamRepo,
+	}
+}
+
+// CreateRepository регистрирует репозиторий (REPOSITORY_EXISTS, если он уже есть)
+func (s *RepositoryService) CreateRepository(ctx context.Context, repository *domain.Repository) error {
+	repository.Name = strings.TrimSpace(repository.Name)
+	if repository.Name == "" {
+		return domain.NewError(domain.ErrorCodeInvalidInput, "repository is required")
+	}
+	if err := s.validate(ctx, repository); err != nil {
+		return err
+	}
+	return s.repoRepo.CreateRepository(ctx, repository)
+}
+
+// UpdateRepository заменяет команду-владельца и переопределения политики.
+// Новая политика действует на назначения, сделанные после изменения.
+func (s *RepositoryService) UpdateRepository(ctx context.Context, repository *domain.Repository) (*domain.Repository, error) {
+	if _, err := s.GetRepository(ctx, repository.Name); err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, repository); err != nil {
+		return nil, err
+	}
+	if err := s.repoRepo.UpdateRepository(ctx, repository); err != nil {
+		return nil, err
+	}
+	return s.repoRepo.GetRepository(ctx, repository.Name)
+}
+
+// GetRepository получает репозиторий по имени
+func (s *RepositoryService) GetRepository(ctx context.Context, name string) (*domain.Repository, error) {
+	repository, err := s.repoRepo.GetRepository(ctx, name)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "repository not found")
+	}
+	return repository, nil
+}
+
+// ListRepositories получает репозитории, при непустом ownerTeam — только репозитории команды
+func (s *RepositoryService) ListRepositories(ctx context.Context, ownerTeam string) ([]domain.Repository, error) {
+	return s.repoRepo.ListRepositories(ctx, ownerTeam)
+}
+
+// validate проверяет команду-владельца, обязательные команды и переопределения политики
+func (s *RepositoryService) validate(ctx context.Context, repository *domain.Repository) error {
+	if repository.OwnerTeam != "" {
+		exists, err := s.teamRepo.TeamExists(ctx, repository.OwnerTeam)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.NewError(domain.ErrorCodeNotFound, "owner team not found")
+		}
+	}
+	if err := repository.Policy.Validate(); err != nil {
+		return err
+	}
+	for _, team := range repository.Policy.RequiredTeams {
+		exists, err := s.teamRepo.TeamExists(ctx, team)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.NewError(domain.ErrorCodeNotFound, "required team not found: "+team)
+		}
+	}
+	return nil
+}
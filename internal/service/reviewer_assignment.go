@@ -2,18 +2,33 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
 	"math/rand"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
 )
 
 // ReviewerAssignmentService сервис назначения ревьюверов
 type ReviewerAssignmentService struct {
-	userRepo repo.UserRepository
-	teamRepo repo.TeamRepository
-	prRepo   repo.PRRepository
+	userRepo       repo.UserRepository
+	teamRepo       repo.TeamRepository
+	prRepo         repo.PRRepository
+	ruleRepo       repo.RuleRepository
+	eventRepo      repo.AssignmentEventRepository
+	membershipRepo repo.MembershipRepository
+	repoRepo       repo.RepositoryRepository
+	transactor     repo.Transactor
+	now            func() time.Time
+
+	// Источник seed'ов: каждый подбор перемешивает кандидатов своим генератором,
+	// seed которого сохраняется в снимке для воспроизведения
+	mu  sync.Mutex
+	rng *rand.Rand
 }
 
 // NewReviewerAssignmentService создает новый сервис
@@ -21,112 +36,262 @@ func NewReviewerAssignmentService(
 	userRepo repo.UserRepository,
 	teamRepo repo.TeamRepository,
 	prRepo repo.PRRepository,
+	ruleRepo repo.RuleRepository,
+	eventRepo repo.AssignmentEventRepository,
+	membershipRepo repo.MembershipRepository,
+	repoRepo repo.RepositoryRepository,
+	transactor repo.Transactor,
+	source rand.Source,
 ) *ReviewerAssignmentService {
 	return &ReviewerAssignmentService{
-		userRepo: userRepo,
-		teamRepo: teamRepo,
-		prRepo:   prRepo,
+		userRepo:       userRepo,
+		teamRepo:       teamRepo,
+		prRepo:         prRepo,
+		ruleRepo:       ruleRepo,
+		eventRepo:      eventRepo,
+		membershipRepo: membershipRepo,
+		repoRepo:       repoRepo,
+		transactor:     transactor,
+		now:            time.Now,
+		rng:            rand.New(source),
 	}
 }
 
-// AssignReviewers назначает до 2 ревьюверов на PR
-func (s *ReviewerAssignmentService) AssignReviewers(ctx context.Context, pr *domain.PullRequest) ([]string, error) {
-	// Получаем информацию об авторе
+// AssignReviewers подбирает ревьюверов на новый PR. Запрошенные вручную ревьюверы
+// (уже лежащие в pr.AssignedReviewers) сохраняются, подбираются только недостающие
+// до числа ревьюверов из политики PR. В результате — только подобранные.
+func (s *ReviewerAssignmentService) AssignReviewers(ctx context.Context, pr *domain.PullRequest) (*domain.Assignment, error) {
+	return s.pickReviewers(ctx, pr, true)
+}
+
+// RequiredReviewers возвращает, сколько ревьюверов нужно PR по политике команды
+// автора с переопределениями репозитория
+func (s *ReviewerAssignmentService) RequiredReviewers(ctx context.Context, pr *domain.PullRequest) (int, error) {
 	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
 	if err != nil {
-		return nil, fmt.Errorf("author not found: %w", err)
+		return 0, domain.NewError(domain.ErrorCodeNotFound, "author not found")
+	}
+	policy, err := s.prPolicy(ctx, author.TeamName, pr)
+	if err != nil {
+		return 0, err
+	}
+	return policy.ReviewersCount, nil
+}
+
+// ValidateReviewer проверяет, что пользователя можно вручную назначить ревьювером PR:
+// он существует, активен, состоит (основной или дополнительной командой) в команде
+// автора, в одной из ее родительских или в обязательной команде репозитория,
+// не является автором и не попадает под правила исключения ни команды автора,
+// ни команды, через которую он допущен
+func (s *ReviewerAssignmentService) ValidateReviewer(ctx context.Context, pr *domain.PullRequest, userID string) (*domain.User, error) {
+	reviewer, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "reviewer not found: "+userID)
+	}
+	if reviewer.UserID == pr.AuthorID {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "author cannot review own PR")
+	}
+	if !reviewer.IsActive {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "reviewer is inactive: "+userID)
 	}
 
-	// Получаем активных членов команды автора, исключая автора
-	candidates, err := s.userRepo.GetActiveUsers(ctx, author.TeamName)
+	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "author not found")
+	}
+	policy, err := s.prPolicy(ctx, author.TeamName, pr)
 	if err != nil {
 		return nil, err
 	}
 
-	// Фильтруем: исключаем автора
-	var availableCandidates []domain.User
-	for _, candidate := range candidates {
-		if candidate.UserID != pr.AuthorID {
-			availableCandidates = append(availableCandidates, candidate)
+	// Участники родительских команд тоже могут ревьюить: к ним эскалирует подбор;
+	// участники обязательных команд репозитория — тоже
+	team, err := s.sharedTeam(ctx, author.TeamName, reviewer.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if team == "" {
+		for _, required := range policy.RequiredTeams {
+			ok, err := s.inTeam(ctx, required, []string{userID})
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				team = required
+				break
+			}
 		}
 	}
+	if team == "" {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "reviewer is not in author's team: "+userID)
+	}
 
-	// Выбираем до 2 ревьюверов
-	reviewersCount := 2
-	if len(availableCandidates) < 2 {
-		reviewersCount = len(availableCandidates)
+	rules, err := s.teamRules(ctx, author.TeamName, team)
+	if err != nil {
+		return nil, err
+	}
+	if rule := domain.FirstViolation(rules, *reviewer, policy.Scope(pr)); rule != nil {
+		return nil, domain.NewError(domain.ErrorCodeRuleViolation, rule.Describe())
 	}
+	return reviewer, nil
+}
 
-	// Перемешиваем и выбираем
-	rand.Shuffle(len(availableCandidates), func(i, j int) {
-		availableCandidates[i], availableCandidates[j] = availableCandidates[j], availableCandidates[i]
-	})
+// FillReviewers добирает ревьюверов на PR до требуемого количества, не трогая
+// уже назначенных. В результате — только новые ревьюверы.
+func (s *ReviewerAssignmentService) FillReviewers(ctx context.Context, pr *domain.PullRequest) (*domain.Assignment, error) {
+	return s.pickReviewers(ctx, pr, false)
+}
 
-	var assignedReviewers []string
-	for i := 0; i < reviewersCount; i++ {
-		assignedReviewers = append(assignedReviewers, availableCandidates[i].UserID)
+// pickReviewers добирает ревьюверов до числа из политики PR из команды автора
+// (при нехватке — и из родительских команд), исключая автора
+// и уже назначенных на PR; withShadow разрешает добавить «теневого» junior'а.
+// При NO_CAPACITY вместе с ошибкой возвращается объяснение: по нему видно,
+// кто упёрся в лимит.
+func (s *ReviewerAssignmentService) pickReviewers(
+	ctx context.Context,
+	pr *domain.PullRequest,
+	withShadow bool,
+) (*domain.Assignment, error) {
+	// Получаем информацию об авторе
+	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, fmt.Errorf("author not found: %w", err)
 	}
 
-	return assignedReviewers, nil
-}
+	policy, err := s.prPolicy(ctx, author.TeamName, pr)
+	if err != nil {
+		return nil, err
+	}
+	count := policy.ReviewersCount - len(pr.AssignedReviewers)
+	if count <= 0 && !withShadow {
+		return &domain.Assignment{}, nil
+	}
+	strategy, err := StrategyFor(policy.TeamPolicy)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &domain.Assignment{Strategy: strategy.Name()}
+	pr = policy.Scope(pr)
 
-// ReassignReviewer переназначает ревьювера
-func (s *ReviewerAssignmentService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (string, error) {
-	// Проверяем что PR существует и не merged
-	pr, err := s.prRepo.GetPRByID(ctx, prID)
+	// Кандидаты — команда автора без автора, уже назначенных, неактивных и запрещенных
+	// правилами и тех, кто уже достиг лимита открытых ревью; если их не хватает —
+	// добавляются кандидаты родительских команд
+	availableCandidates, withCapacity, openReviews, err := s.gatherEscalated(ctx, author.TeamName, author.TeamName, pr, "", count, policy.TeamPolicy, assignment)
 	if err != nil {
-		return "", domain.NewError(domain.ErrorCodeNotFound, "PR not found")
+		return nil, err
 	}
 
-	if pr.Status == domain.PRStatusMerged {
-		return "", domain.NewError(domain.ErrorCodePRMerged, "cannot reassign on merged PR")
+	// Обязательные команды репозитория, от которых еще нет ревьювера, добавляют своих кандидатов
+	var needTeams []domain.TeamCandidates
+	if count > 0 {
+		needTeams, availableCandidates, withCapacity, err = s.gatherRequiredTeams(ctx, author.TeamName, *policy, pr,
+			availableCandidates, withCapacity, openReviews, assignment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	recentPairs, err := s.countRecentPairs(ctx, pr.AuthorID, policy.TeamPolicy)
+	if err != nil {
+		return nil, err
 	}
 
-	// Проверяем что старый ревьювер назначен
-	found := false
-	for _, reviewer := range pr.AssignedReviewers {
-		if reviewer == oldReviewerID {
-			found = true
-			break
+	// Лимит мешает, только если ревьюверы еще нужны; иначе подбирается лишь shadow
+	if count > 0 && len(availableCandidates) > 0 && len(withCapacity) == 0 {
+		return assignment, domain.NewError(domain.ErrorCodeNoCapacity, "all candidates have reached their open review limit")
+	}
+
+	// Если команда требует senior'а, а среди уже назначенных его нет, он идет первым
+	needSenior := false
+	if policy.RequireSenior {
+		hasSenior, err := s.hasSenior(ctx, pr.AssignedReviewers)
+		if err != nil {
+			return nil, err
 		}
+		needSenior = !hasSenior
 	}
-	if !found {
-		return "", domain.NewError(domain.ErrorCodeNotAssigned, "reviewer is not assigned to this PR")
+
+	// Если команда требует lead'а, а среди уже назначенных его нет, он идет первым
+	needLead := false
+	if policy.RequireLead {
+		hasLead, err := s.hasLead(ctx, author.TeamName, pr.AssignedReviewers)
+		if err != nil {
+			return nil, err
+		}
+		needLead = !hasLead
 	}
 
-	// Получаем команду старого ревьювера
-	oldReviewer, err := s.userRepo.GetUserByID(ctx, oldReviewerID)
+	// Ранжируем и берем первых count с учетом политики наставничества
+	snapshot, err := s.snapshot(policy.TeamPolicy, pr, withCapacity, openReviews, recentPairs)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Count = max(count, 0)
+	snapshot.NeedSenior = needSenior
+	snapshot.NeedLead = needLead
+	snapshot.NeedTeams = needTeams
+	snapshot.WithShadow = withShadow
+	decided, err := decide(snapshot)
 	if err != nil {
-		return "", domain.NewError(domain.ErrorCodeNotFound, "old reviewer not found")
+		return nil, err
 	}
+	decided.Pool, decided.Excluded = assignment.Pool, assignment.Excluded
+	decided.EscalatedTo = assignment.EscalatedTo
+
+	return decided, nil
+}
+
+// ReassignReviewer переназначает ревьювера. Если newReviewerID пуст, замена
+// подбирается автоматически, иначе назначается указанный пользователь.
+// Новый ревьювер — единственный в assignment.Reviewers.
+func (s *ReviewerAssignmentService) ReassignReviewer(ctx context.Context, repository, prID, oldReviewerID, newReviewerID string) (*domain.Assignment, error) {
+	var assignment *domain.Assignment
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		assignment, err = s.reassignReviewer(ctx, repository, prID, oldReviewerID, newReviewerID)
+		return err
+	})
+	return assignment, err
+}
 
-	// Получаем активных членов его команды, исключая его самого
-	candidates, err := s.userRepo.GetActiveUsers(ctx, oldReviewer.TeamName)
+// reassignReviewer — ReassignReviewer внутри транзакции
+func (s *ReviewerAssignmentService) reassignReviewer(ctx context.Context, repository, prID, oldReviewerID, newReviewerID string) (*domain.Assignment, error) {
+	pr, err := s.openPR(ctx, repository, prID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	excluded := map[string]bool{
-		oldReviewerID: true,
-		pr.AuthorID:   true,
+	// Проверяем что старый ревьювер назначен
+	if !contains(pr.AssignedReviewers, oldReviewerID) {
+		return nil, domain.NewError(domain.ErrorCodeNotAssigned, "reviewer is not assigned to this PR")
 	}
-	for _, r := range pr.AssignedReviewers {
-		excluded[r] = true
+
+	// Получаем команду старого ревьювера
+	oldReviewer, err := s.userRepo.GetUserByID(ctx, oldReviewerID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "old reviewer not found")
 	}
 
-	var availableCandidates []domain.User
-	for _, candidate := range candidates {
-		if !excluded[candidate.UserID] && candidate.IsActive {
-			availableCandidates = append(availableCandidates, candidate)
-		}
+	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "author not found")
+	}
+	team, err := s.replacementTeam(ctx, author, oldReviewer)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(availableCandidates) == 0 {
-		return "", domain.NewError(domain.ErrorCodeNoCandidate, "no active replacement candidate in team")
+	var assignment *domain.Assignment
+	if newReviewerID == "" {
+		assignment, err = s.pickReplacement(ctx, pr, author, oldReviewer, team)
+	} else {
+		err = s.validateReplacement(ctx, pr, oldReviewer, team, newReviewerID)
+		assignment = &domain.Assignment{Reviewers: []string{newReviewerID}, Strategy: domain.StrategyManual}
+	}
+	if err != nil {
+		return nil, err
 	}
+	newReviewerID = assignment.Reviewers[0]
 
-	// Выбираем случайного кандидата
-	newReviewerID := availableCandidates[rand.Intn(len(availableCandidates))].UserID
 	// Обновляем список ревьюверов
 	newReviewers := make([]string, 0, len(pr.AssignedReviewers))
 	for _, reviewer := range pr.AssignedReviewers {
@@ -137,11 +302,894 @@ func (s *ReviewerAssignmentService) ReassignReviewer(ctx context.Context, prID,
 		}
 	}
 
-	if err := s.prRepo.UpdateReviewers(ctx, prID, newReviewers); err != nil {
+	if err := s.prRepo.UpdateReviewers(ctx, repository, prID, newReviewers); err != nil {
+		return nil, err
+	}
+	// Повышенный до ревьювера «теневой» junior больше не в тени
+	if contains(pr.ShadowReviewers, newReviewerID) {
+		if err := s.prRepo.UpdateShadowReviewers(ctx, repository, prID, without(pr.ShadowReviewers, newReviewerID)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.RecordAssignment(ctx, pr, domain.AssignmentEventReassign, assignment.Reviewers, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// AddReviewer вручную назначает указанного пользователя дополнительным ревьювером PR
+func (s *ReviewerAssignmentService) AddReviewer(ctx context.Context, repository, prID, userID string) error {
+	return s.transactor.InTx(ctx, func(ctx context.Context) error {
+		return s.addReviewer(ctx, repository, prID, userID)
+	})
+}
+
+// addReviewer — AddReviewer внутри транзакции
+func (s *ReviewerAssignmentService) addReviewer(ctx context.Context, repository, prID, userID string) error {
+	pr, err := s.openPR(ctx, repository, prID)
+	if err != nil {
+		return err
+	}
+	if contains(pr.AssignedReviewers, userID) {
+		return domain.NewError(domain.ErrorCodeAssigned, "reviewer is already assigned to this PR")
+	}
+	if _, err := s.ValidateReviewer(ctx, pr, userID); err != nil {
+		return err
+	}
+
+	if err := s.prRepo.UpdateReviewers(ctx, repository, prID, append(pr.AssignedReviewers, userID)); err != nil {
+		return err
+	}
+	if contains(pr.ShadowReviewers, userID) {
+		if err := s.prRepo.UpdateShadowReviewers(ctx, repository, prID, without(pr.ShadowReviewers, userID)); err != nil {
+			return err
+		}
+	}
+
+	assignment := &domain.Assignment{Reviewers: []string{userID}, Strategy: domain.StrategyManual}
+	return s.RecordAssignment(ctx, pr, domain.AssignmentEventAdd, assignment.Reviewers, assignment)
+}
+
+// RemoveReviewer снимает ревьювера с PR без замены, соблюдая минимум ревьюверов,
+// требования senior'а и lead'а из политики команды и обязательные команды репозитория
+func (s *ReviewerAssignmentService) RemoveReviewer(ctx context.Context, repository, prID, userID string) error {
+	return s.transactor.InTx(ctx, func(ctx context.Context) error {
+		return s.removeReviewer(ctx, repository, prID, userID)
+	})
+}
+
+// removeReviewer — RemoveReviewer внутри транзакции
+func (s *ReviewerAssignmentService) removeReviewer(ctx context.Context, repository, prID, userID string) error {
+	pr, err := s.openPR(ctx, repository, prID)
+	if err != nil {
+		return err
+	}
+	if !contains(pr.AssignedReviewers, userID) {
+		return domain.NewError(domain.ErrorCodeNotAssigned, "reviewer is not assigned to this PR")
+	}
+
+	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return domain.NewError(domain.ErrorCodeNotFound, "author not found")
+	}
+	policy, err := s.prPolicy(ctx, author.TeamName, pr)
+	if err != nil {
+		return err
+	}
+
+	remaining := without(pr.AssignedReviewers, userID)
+	if len(remaining) < policy.MinReviewers {
+		return domain.NewError(domain.ErrorCodeMinReviewers,
+			fmt.Sprintf("team requires at least %d reviewer(s); use reassign instead", policy.MinReviewers))
+	}
+	if policy.RequireSenior {
+		hadSenior, err := s.hasSenior(ctx, pr.AssignedReviewers)
+		if err != nil {
+			return err
+		}
+		hasSenior, err := s.hasSenior(ctx, remaining)
+		if err != nil {
+			return err
+		}
+		if hadSenior && !hasSenior {
+			return domain.NewError(domain.ErrorCodeMinReviewers, "team requires a senior reviewer; use reassign instead")
+		}
+	}
+	if policy.RequireLead {
+		hadLead, err := s.hasLead(ctx, author.TeamName, pr.AssignedReviewers)
+		if err != nil {
+			return err
+		}
+		hasLead, err := s.hasLead(ctx, author.TeamName, remaining)
+		if err != nil {
+			return err
+		}
+		if hadLead && !hasLead {
+			return domain.NewError(domain.ErrorCodeMinReviewers, "team requires a lead reviewer; use reassign instead")
+		}
+	}
+	for _, team := range policy.RequiredTeams {
+		had, err := s.inTeam(ctx, team, pr.AssignedReviewers)
+		if err != nil {
+			return err
+		}
+		has, err := s.inTeam(ctx, team, remaining)
+		if err != nil {
+			return err
+		}
+		if had && !has {
+			return domain.NewError(domain.ErrorCodeMinReviewers, "repository requires a reviewer from team "+team+"; use reassign instead")
+		}
+	}
+
+	if err := s.prRepo.UpdateReviewers(ctx, repository, prID, remaining); err != nil {
+		return err
+	}
+	return s.RecordAssignment(ctx, pr, domain.AssignmentEventRemove, []string{userID}, nil)
+}
+
+// HandOverReviews переназначает открытые ревью user на других участников его
+// команды; при sameTeam — только ревью PR авторов из команды user. Ревью, которым
+// не нашлось замены, возвращаются с пустым ReplacedBy и остаются за user.
+func (s *ReviewerAssignmentService) HandOverReviews(ctx context.Context, user *domain.User, sameTeam bool) ([]domain.ReviewHandover, error) {
+	prs, err := s.prRepo.GetPRsByReviewer(ctx, user.UserID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	handovers := []domain.ReviewHandover{}
+	for _, summary := range prs {
+		if summary.Status != domain.PRStatusOpen {
+			continue
+		}
+		pr, err := s.prRepo.GetPRByID(ctx, summary.Repository, summary.PullRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(pr.AssignedReviewers, user.UserID) {
+			continue // «теневое» ревью
+		}
+		if sameTeam {
+			author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
+			if err != nil {
+				return nil, err
+			}
+			if author.TeamName != user.TeamName {
+				continue
+			}
+		}
+
+		handover := domain.ReviewHandover{Repository: pr.Repository, PullRequestID: pr.PullRequestID}
+		assignment, err := s.ReassignReviewer(ctx, pr.Repository, pr.PullRequestID, user.UserID, "")
+		var domErr domain.DomainError
+		switch {
+		case err == nil:
+			handover.ReplacedBy = assignment.Reviewers[0]
+		case errors.As(err, &domErr) &&
+			(domErr.Code == domain.ErrorCodeNoCandidate || domErr.Code == domain.ErrorCodeNoCapacity):
+			// Замены нет — ревью остается за пользователем
+		default:
+			return nil, err
+		}
+		handovers = append(handovers, handover)
+	}
+	return handovers, nil
+}
+
+// ReleaseReviewer снимает пользователя с PR — и с основных, и с «теневых»
+// ревьюверов — без проверок политики команды. Возвращает, был ли он основным ревьювером.
+func (s *ReviewerAssignmentService) ReleaseReviewer(ctx context.Context, pr *domain.PullRequest, userID string) (bool, error) {
+	if contains(pr.ShadowReviewers, userID) {
+		pr.ShadowReviewers = without(pr.ShadowReviewers, userID)
+		if err := s.prRepo.UpdateShadowReviewers(ctx, pr.Repository, pr.PullRequestID, pr.ShadowReviewers); err != nil {
+			return false, err
+		}
+	}
+	if !contains(pr.AssignedReviewers, userID) {
+		return false, nil
+	}
+	pr.AssignedReviewers = without(pr.AssignedReviewers, userID)
+	if err := s.prRepo.UpdateReviewers(ctx, pr.Repository, pr.PullRequestID, pr.AssignedReviewers); err != nil {
+		return false, err
+	}
+	return true, s.RecordAssignment(ctx, pr, domain.AssignmentEventRemove, []string{userID}, nil)
+}
+
+// RecordAssignment сохраняет решение о назначении на pr вместе с объяснением
+func (s *ReviewerAssignmentService) RecordAssignment(
+	ctx context.Context,
+	pr *domain.PullRequest,
+	kind string,
+	reviewers []string,
+	explanation *domain.Assignment,
+) error {
+	return s.eventRepo.RecordAssignmentEvent(ctx, &domain.AssignmentEvent{
+		Repository:    pr.Repository,
+		PullRequestID: pr.PullRequestID,
+		Kind:          kind,
+		Reviewers:     reviewers,
+		Explanation:   explanation,
+	})
+}
+
+// Replay воспроизводит сохраненное решение по его снимку. Возвращает событие
+// и результат повторного подбора.
+func (s *ReviewerAssignmentService) Replay(ctx context.Context, eventID int64) (*domain.AssignmentEvent, *domain.Assignment, error) {
+	event, err := s.eventRepo.GetAssignmentEvent(ctx, eventID)
+	if err != nil {
+		return nil, nil, domain.NewError(domain.ErrorCodeNotFound, "assignment event not found")
+	}
+	if event.Explanation == nil || event.Explanation.Snapshot == nil {
+		return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "assignment event has no snapshot to replay")
+	}
+	replayed, err := decide(event.Explanation.Snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, replayed, nil
+}
+
+// GetAssignmentEvents получает историю решений о назначении на PR
+func (s *ReviewerAssignmentService) GetAssignmentEvents(ctx context.Context, repository, prID string) ([]domain.AssignmentEvent, error) {
+	return s.eventRepo.GetAssignmentEvents(ctx, repository, prID)
+}
+
+// prPolicy получает политику команды teamName с переопределениями репозитория PR
+func (s *ReviewerAssignmentService) prPolicy(ctx context.Context, teamName string, pr *domain.PullRequest) (*domain.PRPolicy, error) {
+	policy, err := s.teamRepo.GetTeamPolicy(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	var overrides domain.RepositoryPolicy
+	if pr.Repository != "" {
+		repository, err := s.repoRepo.GetRepository(ctx, pr.Repository)
+		if err != nil {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "repository not found: "+pr.Repository)
+		}
+		overrides = repository.Policy
+	}
+	applied := overrides.Apply(*policy)
+	return &applied, nil
+}
+
+// openPR получает PR, который еще можно менять
+func (s *ReviewerAssignmentService) openPR(ctx context.Context, repository, prID string) (*domain.PullRequest, error) {
+	// Блокировка до конца транзакции: параллельные изменения ревьюверов PR
+	// (в том числе добор воркером) читают его состав только после нашего
+	if err := s.prRepo.LockPR(ctx, repository, prID); err != nil {
+		return nil, err
+	}
+	pr, err := s.prRepo.GetPRByID(ctx, repository, prID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "PR not found")
+	}
+	if pr.Status == domain.PRStatusMerged {
+		return nil, domain.NewError(domain.ErrorCodePRMerged, "cannot change reviewers on merged PR")
+	}
+	return pr, nil
+}
+
+// validateReplacement проверяет выбранную вручную замену oldReviewer;
+// team — команда, от имени которой oldReviewer ревьюит PR
+func (s *ReviewerAssignmentService) validateReplacement(
+	ctx context.Context,
+	pr *domain.PullRequest,
+	oldReviewer *domain.User,
+	team string,
+	newReviewerID string,
+) error {
+	if contains(pr.AssignedReviewers, newReviewerID) {
+		return domain.NewError(domain.ErrorCodeAssigned, "reviewer is already assigned to this PR")
+	}
+	newReviewer, err := s.ValidateReviewer(ctx, pr, newReviewerID)
+	if err != nil {
+		return err
+	}
+
+	policy, err := s.prPolicy(ctx, team, pr)
+	if err != nil {
+		return err
+	}
+	remaining := without(pr.AssignedReviewers, oldReviewer.UserID)
+
+	// Уходящего senior'а может заменить только senior, если иначе на PR их не останется
+	if policy.RequireSenior && oldReviewer.IsSenior() && !newReviewer.IsSenior() {
+		hasSenior, err := s.hasSenior(ctx, remaining)
+		if err != nil {
+			return err
+		}
+		if !hasSenior {
+			return domain.NewError(domain.ErrorCodeInvalidInput, "team requires a senior reviewer: "+newReviewerID+" is not senior")
+		}
+	}
+
+	// То же для lead'а
+	if policy.RequireLead {
+		wasLead, err := s.hasLead(ctx, team, []string{oldReviewer.UserID})
+		if err != nil {
+			return err
+		}
+		isLead, err := s.hasLead(ctx, team, []string{newReviewerID})
+		if err != nil {
+			return err
+		}
+		hasLead, err := s.hasLead(ctx, team, remaining)
+		if err != nil {
+			return err
+		}
+		if wasLead && !isLead && !hasLead {
+			return domain.NewError(domain.ErrorCodeInvalidInput, "team requires a lead reviewer: "+newReviewerID+" is not a lead")
+		}
+	}
+	return nil
+}
+
+// pickReplacement подбирает замену oldReviewer из команды team по ее стратегии;
+// правила исключения команды автора действуют и на кандидатов команды team
+func (s *ReviewerAssignmentService) pickReplacement(
+	ctx context.Context,
+	pr *domain.PullRequest,
+	author *domain.User,
+	oldReviewer *domain.User,
+	team string,
+) (*domain.Assignment, error) {
+	policy, err := s.prPolicy(ctx, team, pr)
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := StrategyFor(policy.TeamPolicy)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &domain.Assignment{Strategy: strategy.Name()}
+	pr = policy.Scope(pr)
+
+	// Кандидаты — активные члены команды, кроме старого ревьювера;
+	// если ни у кого нет запаса по лимиту — и члены родительских команд
+	availableCandidates, withCapacity, openReviews, err := s.gatherEscalated(ctx, author.TeamName, team, pr, oldReviewer.UserID, 1, policy.TeamPolicy, assignment)
+	if err != nil {
+		return nil, err
+	}
+	if len(availableCandidates) == 0 {
+		return nil, domain.NewError(domain.ErrorCodeNoCandidate, "no active replacement candidate in team")
+	}
+
+	recentPairs, err := s.countRecentPairs(ctx, pr.AuthorID, policy.TeamPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Уходящего senior'а заменяет senior, если иначе на PR их не останется
+	if policy.RequireSenior && oldReviewer.IsSenior() {
+		hasSenior, err := s.hasSenior(ctx, without(pr.AssignedReviewers, oldReviewer.UserID))
+		if err != nil {
+			return nil, err
+		}
+		if !hasSenior {
+			anySenior := false
+			for _, candidate := range availableCandidates {
+				if candidate.IsSenior() {
+					anySenior = true
+				} else {
+					assignment.Exclude(candidate.UserID, domain.ExcludedNotSenior, "team requires a senior reviewer")
+				}
+			}
+			if !anySenior {
+				return nil, domain.NewError(domain.ErrorCodeNoCandidate, "no active senior replacement candidate in team")
+			}
+			var seniors []domain.User
+			for _, candidate := range withCapacity {
+				if candidate.IsSenior() {
+					seniors = append(seniors, candidate)
+				}
+			}
+			withCapacity = seniors
+		}
+	}
+
+	// Уходящего lead'а заменяет lead, если иначе на PR их не останется
+	if policy.RequireLead {
+		wasLead, err := s.hasLead(ctx, team, []string{oldReviewer.UserID})
+		if err != nil {
+			return nil, err
+		}
+		hasLead, err := s.hasLead(ctx, team, without(pr.AssignedReviewers, oldReviewer.UserID))
+		if err != nil {
+			return nil, err
+		}
+		if wasLead && !hasLead {
+			anyLead := false
+			for _, candidate := range availableCandidates {
+				if candidate.IsLead() {
+					anyLead = true
+				} else {
+					assignment.Exclude(candidate.UserID, domain.ExcludedNotLead, "team requires a lead reviewer")
+				}
+			}
+			if !anyLead {
+				return nil, domain.NewError(domain.ErrorCodeNoCandidate, "no active lead replacement candidate in team")
+			}
+			var leads []domain.User
+			for _, candidate := range withCapacity {
+				if candidate.IsLead() {
+					leads = append(leads, candidate)
+				}
+			}
+			withCapacity = leads
+		}
+	}
+
+	if len(withCapacity) == 0 {
+		return nil, domain.NewError(domain.ErrorCodeNoCapacity, "all replacement candidates have reached their open review limit")
+	}
+
+	// Берем лучшего по стратегии кандидата, по возможности из тех, кто сейчас на работе
+	snapshot, err := s.snapshot(policy.TeamPolicy, pr, withCapacity, openReviews, recentPairs)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Count = 1
+	decided, err := decide(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	decided.Pool, decided.Excluded = assignment.Pool, assignment.Excluded
+	decided.EscalatedTo = assignment.EscalatedTo
+	return decided, nil
+}
+
+// gatherEscalated собирает кандидатов команды teamName (см. gatherCandidates) и
+// отсеивает достигших лимита. Пока кандидатов с запасом по лимиту меньше need,
+// в пул добавляются кандидаты родительских команд, от ближайшей к корню;
+// добавленные команды записываются в assignment.EscalatedTo. Правила команды
+// автора authorTeam действуют на кандидатов всех этих команд.
+func (s *ReviewerAssignmentService) gatherEscalated(
+	ctx context.Context,
+	authorTeam string,
+	teamName string,
+	pr *domain.PullRequest,
+	replaced string,
+	need int,
+	policy domain.TeamPolicy,
+	assignment *domain.Assignment,
+) (available, withCapacity []domain.User, openReviews map[string]int, err error) {
+	ancestors, err := s.teamRepo.GetTeamAncestors(ctx, teamName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	openReviews = make(map[string]int)
+	for i, team := range append([]string{teamName}, ancestors...) {
+		if i > 0 {
+			if len(withCapacity) >= need {
+				break
+			}
+			assignment.EscalatedTo = append(assignment.EscalatedTo, team)
+		}
+
+		candidates, err := s.gatherCandidates(ctx, authorTeam, team, pr, replaced, assignment)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		counts, err := s.countOpenReviews(ctx, candidates)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for userID, count := range counts {
+			openReviews[userID] = count
+		}
+		available = append(available, candidates...)
+		withCapacity = append(withCapacity, filterByCapacity(candidates, counts, policy, assignment)...)
+	}
+	return available, withCapacity, openReviews, nil
+}
+
+// replacementTeam определяет команду, из которой подбирается замена oldReviewer:
+// ближайшую к автору PR команду иерархии, в которой oldReviewer состоит, а если
+// такой нет — его основную команду
+func (s *ReviewerAssignmentService) replacementTeam(
+	ctx context.Context,
+	author *domain.User,
+	oldReviewer *domain.User,
+) (string, error) {
+	team, err := s.sharedTeam(ctx, author.TeamName, oldReviewer.UserID)
+	if err != nil {
+		return "", err
+	}
+	if team == "" {
+		return oldReviewer.TeamName, nil
+	}
+	return team, nil
+}
+
+// sharedTeam возвращает ближайшую команду из teamName и ее родительских, в которой
+// состоит пользователь; пусто — не состоит ни в одной
+func (s *ReviewerAssignmentService) sharedTeam(ctx context.Context, teamName, userID string) (string, error) {
+	if teamName == "" {
+		return "", nil
+	}
+	memberships, err := s.membershipRepo.GetMemberships(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	ancestors, err := s.teamRepo.GetTeamAncestors(ctx, teamName)
+	if err != nil {
 		return "", err
 	}
+	for _, team := range append([]string{teamName}, ancestors...) {
+		for _, m := range memberships {
+			if m.TeamName == team {
+				return team, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// hasSenior сообщает, есть ли senior среди пользователей
+func (s *ReviewerAssignmentService) hasSenior(ctx context.Context, userIDs []string) (bool, error) {
+	users, err := s.userRepo.GetAllUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return false, err
+	}
+	for _, u := range users {
+		if u.IsSenior() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasLead сообщает, есть ли lead команды teamName среди пользователей
+func (s *ReviewerAssignmentService) hasLead(ctx context.Context, teamName string, userIDs []string) (bool, error) {
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, teamName)
+	if err != nil {
+		return false, err
+	}
+	for _, userID := range userIDs {
+		if roles[userID] == domain.TeamRoleLead {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inTeam сообщает, состоит ли кто-то из пользователей в команде teamName
+func (s *ReviewerAssignmentService) inTeam(ctx context.Context, teamName string, userIDs []string) (bool, error) {
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, teamName)
+	if err != nil {
+		return false, err
+	}
+	for _, userID := range userIDs {
+		if _, ok := roles[userID]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// gatherRequiredTeams добавляет в пул кандидатов обязательных команд репозитория,
+// от которых на PR еще нет ревьювера, и возвращает эти команды с их кандидатами
+// с запасом по лимиту. Кандидаты, уже собранные из команды автора, не дублируются.
+func (s *ReviewerAssignmentService) gatherRequiredTeams(
+	ctx context.Context,
+	authorTeam string,
+	policy domain.PRPolicy,
+	pr *domain.PullRequest,
+	available, withCapacity []domain.User,
+	openReviews map[string]int,
+	assignment *domain.Assignment,
+) ([]domain.TeamCandidates, []domain.User, []domain.User, error) {
+	var needTeams []domain.TeamCandidates
+	for _, team := range policy.RequiredTeams {
+		represented, err := s.inTeam(ctx, team, pr.AssignedReviewers)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if represented {
+			continue
+		}
+
+		candidates, err := s.gatherCandidates(ctx, authorTeam, team, pr, "", assignment)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		counts, err := s.countOpenReviews(ctx, candidates)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		need := domain.TeamCandidates{TeamName: team, Candidates: []string{}}
+		for _, candidate := range filterByCapacity(candidates, counts, policy.TeamPolicy, assignment) {
+			need.Candidates = append(need.Candidates, candidate.UserID)
+			if containsUser(withCapacity, candidate.UserID) {
+				continue
+			}
+			openReviews[candidate.UserID] = counts[candidate.UserID]
+			withCapacity = append(withCapacity, candidate)
+		}
+		for _, candidate := range candidates {
+			if !containsUser(available, candidate.UserID) {
+				available = append(available, candidate)
+			}
+		}
+		needTeams = append(needTeams, need)
+	}
+	return needTeams, available, withCapacity, nil
+}
+
+// selectReviewers берет первых count кандидатов из ранжированного списка;
+// при needLead первым берется лучший по рангу lead, при needSenior — лучший
+// senior, если lead им не является, затем — лучший кандидат каждой
+// обязательной команды, от которой среди выбранных еще никого нет
+func selectReviewers(
+	scores []domain.CandidateScore,
+	users map[string]domain.User,
+	count int,
+	needSenior, needLead bool,
+	needTeams []domain.TeamCandidates,
+) []string {
+	if count <= 0 {
+		return nil
+	}
+
+	var selected []string
+	taken := make(map[string]bool, count)
+	takeFirst := func(match func(domain.User) bool) {
+		for _, score := range scores {
+			if len(selected) >= count {
+				return
+			}
+			if !taken[score.UserID] && match(users[score.UserID]) {
+				selected = append(selected, score.UserID)
+				taken[score.UserID] = true
+				return
+			}
+		}
+	}
+	if needLead {
+		takeFirst(domain.User.IsLead)
+	}
+	if needSenior && (len(selected) == 0 || !users[selected[0]].IsSenior()) {
+		takeFirst(domain.User.IsSenior)
+	}
+	for _, team := range needTeams {
+		inTeam := func(u domain.User) bool { return contains(team.Candidates, u.UserID) }
+		represented := false
+		for _, userID := range selected {
+			represented = represented || inTeam(users[userID])
+		}
+		if !represented {
+			takeFirst(inTeam)
+		}
+	}
+	for _, score := range scores {
+		if len(selected) >= count {
+			break
+		}
+		if !taken[score.UserID] {
+			selected = append(selected, score.UserID)
+			taken[score.UserID] = true
+		}
+	}
+	return selected
+}
+
+// selectShadow выбирает лучшего по рангу junior'а, не попавшего в ревьюверы
+func selectShadow(scores []domain.CandidateScore, users map[string]domain.User, reviewers []string) []string {
+	taken := make(map[string]bool, len(reviewers))
+	for _, r := range reviewers {
+		taken[r] = true
+	}
+	for _, score := range scores {
+		if !taken[score.UserID] && users[score.UserID].IsJunior() {
+			return []string{score.UserID}
+		}
+	}
+	return nil
+}
+
+// gatherCandidates собирает кандидатов из команды teamName с их ролями в ней и
+// записывает в assignment пул и причину исключения каждого отсеянного.
+// Действуют правила и teamName, и команды автора authorTeam. replaced —
+// снимаемый ревьювер, если есть.
+func (s *ReviewerAssignmentService) gatherCandidates(
+	ctx context.Context,
+	authorTeam string,
+	teamName string,
+	pr *domain.PullRequest,
+	replaced string,
+	assignment *domain.Assignment,
+) ([]domain.User, error) {
+	members, err := s.userRepo.GetUsersByTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.teamRules(ctx, authorTeam, teamName)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.User
+	for _, member := range members {
+		member.Role = roles[member.UserID]
+		assignment.Pool = append(assignment.Pool, member.UserID)
+		switch {
+		case member.UserID == pr.AuthorID:
+			assignment.Exclude(member.UserID, domain.ExcludedAuthor, "")
+		case member.UserID == replaced:
+			assignment.Exclude(member.UserID, domain.ExcludedReplaced, "")
+		case contains(pr.AssignedReviewers, member.UserID), contains(pr.ShadowReviewers, member.UserID):
+			assignment.Exclude(member.UserID, domain.ExcludedAssigned, "")
+		case !member.IsActive:
+			assignment.Exclude(member.UserID, domain.ExcludedInactive, "")
+		default:
+			if rule := domain.FirstViolation(rules, member, pr); rule != nil {
+				assignment.Exclude(member.UserID, domain.ExcludedRule, rule.Describe())
+				continue
+			}
+			candidates = append(candidates, member)
+		}
+	}
+	return candidates, nil
+}
+
+// teamRules получает правила исключения команды автора и команды кандидата
+// (одной и той же, если кандидат из команды автора)
+func (s *ReviewerAssignmentService) teamRules(ctx context.Context, authorTeam, candidateTeam string) ([]domain.ExclusionRule, error) {
+	rules, err := s.ruleRepo.GetRulesByTeam(ctx, authorTeam)
+	if err != nil {
+		return nil, err
+	}
+	if candidateTeam == authorTeam || candidateTeam == "" {
+		return rules, nil
+	}
+	more, err := s.ruleRepo.GetRulesByTeam(ctx, candidateTeam)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, more...), nil
+}
 
-	return newReviewerID, nil
+// countOpenReviews считает открытые ревью кандидатов
+func (s *ReviewerAssignmentService) countOpenReviews(ctx context.Context, candidates []domain.User) (map[string]int, error) {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.UserID
+	}
+	return s.prRepo.CountOpenReviews(ctx, ids)
+}
+
+// countRecentPairs считает назначения на PR автора в окне affinity команды.
+// Пары нужны только стратегии affinity; для остальных запрос не выполняется.
+func (s *ReviewerAssignmentService) countRecentPairs(ctx context.Context, authorID string, policy domain.TeamPolicy) (map[string]int, error) {
+	if policy.Strategy != domain.StrategyAffinity {
+		return map[string]int{}, nil
+	}
+	since := s.now().AddDate(0, 0, -policy.AffinityWindowDays)
+	return s.prRepo.CountRecentPairs(ctx, authorID, since)
+}
+
+// filterByCapacity исключает кандидатов, у которых открытых ревью уже не меньше лимита,
+// и записывает их в assignment
+func filterByCapacity(
+	candidates []domain.User,
+	openReviews map[string]int,
+	policy domain.TeamPolicy,
+	assignment *domain.Assignment,
+) []domain.User {
+	var result []domain.User
+	for _, candidate := range candidates {
+		if limit, ok := candidate.OpenReviewsLimit(policy); ok && openReviews[candidate.UserID] >= limit {
+			assignment.Exclude(candidate.UserID, domain.ExcludedAtCapacity,
+				fmt.Sprintf("%d open reviews, limit %d", openReviews[candidate.UserID], limit))
+			continue
+		}
+		result = append(result, candidate)
+	}
+	return result
+}
+
+// snapshot фиксирует входные данные подбора с новым seed'ом
+func (s *ReviewerAssignmentService) snapshot(
+	policy domain.TeamPolicy,
+	pr *domain.PullRequest,
+	candidates []domain.User,
+	openReviews map[string]int,
+	recentPairs map[string]int,
+) (*domain.AssignmentSnapshot, error) {
+	now := s.now()
+	snapshot := &domain.AssignmentSnapshot{
+		Seed:       s.nextSeed(),
+		Policy:     policy,
+		Labels:     pr.Labels,
+		Candidates: make([]domain.CandidateSnapshot, len(candidates)),
+	}
+	for i, candidate := range candidates {
+		onHours, err := candidate.IsWorkingAt(now)
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", candidate.UserID, err)
+		}
+		snapshot.Candidates[i] = domain.CandidateSnapshot{
+			UserID:      candidate.UserID,
+			Seniority:   candidate.Seniority,
+			Role:        candidate.Role,
+			Skills:      candidate.Skills,
+			OpenReviews: openReviews[candidate.UserID],
+			RecentPairs: recentPairs[candidate.UserID],
+			OnHours:     onHours,
+		}
+	}
+	return snapshot, nil
+}
+
+// nextSeed выдает seed для очередного подбора
+func (s *ReviewerAssignmentService) nextSeed() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Int63()
+}
+
+// decide — чистое ядро подбора: по снимку выбирает ревьюверов без обращения
+// к репозиториям и часам, поэтому одинаковый снимок всегда дает одинаковый результат
+func decide(snapshot *domain.AssignmentSnapshot) (*domain.Assignment, error) {
+	strategy, err := StrategyFor(snapshot.Policy)
+	if err != nil {
+		return nil, err
+	}
+	scores := rank(strategy, snapshot)
+
+	users := make(map[string]domain.User, len(snapshot.Candidates))
+	for _, candidate := range snapshot.Candidates {
+		users[candidate.UserID] = domain.User{
+			UserID:    candidate.UserID,
+			Seniority: candidate.Seniority,
+			Role:      candidate.Role,
+			Skills:    candidate.Skills,
+		}
+	}
+
+	assignment := &domain.Assignment{Strategy: strategy.Name(), Scores: scores, Snapshot: snapshot}
+	assignment.Reviewers = selectReviewers(scores, users, snapshot.Count, snapshot.NeedSenior, snapshot.NeedLead, snapshot.NeedTeams)
+	if snapshot.WithShadow && snapshot.Policy.ShadowJunior {
+		assignment.ShadowReviewers = selectShadow(scores, users, assignment.Reviewers)
+	}
+	return assignment, nil
+}
+
+// rank оценивает кандидатов снимка стратегией и упорядочивает их: сначала те,
+// у кого было рабочее время, затем по убыванию балла. Равные по баллу кандидаты
+// остаются в порядке перемешивания генератором с seed'ом снимка.
+func rank(strategy Strategy, snapshot *domain.AssignmentSnapshot) []domain.CandidateScore {
+	shuffled := make([]domain.CandidateSnapshot, len(snapshot.Candidates))
+	copy(shuffled, snapshot.Candidates)
+	rng := rand.New(rand.NewSource(snapshot.Seed))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	scores := make([]domain.CandidateScore, len(shuffled))
+	for i, candidate := range shuffled {
+		scores[i] = strategy.Score(CandidateFeatures{
+			User:          domain.User{UserID: candidate.UserID, Seniority: candidate.Seniority, Skills: candidate.Skills},
+			OpenReviews:   candidate.OpenReviews,
+			MatchedSkills: matchSkills(candidate.Skills, snapshot.Labels),
+			RecentPairs:   candidate.RecentPairs,
+		})
+		scores[i].OnHours = candidate.OnHours
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].OnHours != scores[j].OnHours {
+			return scores[i].OnHours
+		}
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
 }
 
 // PickRandomReviewers выбирает N случайных активных членов команды
@@ -151,9 +1199,40 @@ func (s *ReviewerAssignmentService) PickRandomReviewers(candidates []domain.User
 	}
 
 	result := make([]domain.User, count)
-	perm := rand.Perm(len(candidates))
+	perm := rand.New(rand.NewSource(s.nextSeed())).Perm(len(candidates))
 	for i := 0; i < count; i++ {
 		result[i] = candidates[perm[i]]
 	}
 	return result
 }
+
+// contains сообщает, есть ли id в списке
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsUser сообщает, есть ли пользователь с userID в списке
+func containsUser(users []domain.User, userID string) bool {
+	for _, u := range users {
+		if u.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// without возвращает копию списка без id
+func without(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			result = append(result, v)
+		}
+	}
+	return result
+}
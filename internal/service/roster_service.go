@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// RosterColumns — столбцы CSV-реестра в порядке выгрузки
+var RosterColumns = []string{"user_id", "username", "team_name", "is_active"}
+
+// rosterRow — строка реестра вместе с результатом ее проверки
+type rosterRow struct {
+	user    domain.User
+	current *domain.User // nil — новый пользователь
+	result  domain.RosterImportRow
+}
+
+// RosterService — массовый импорт и выгрузка пользователей в CSV
+type RosterService struct {
+	teamRepo      repo.TeamRepository
+	userRepo      repo.UserRepository
+	moveRepo      repo.MoveRepository
+	assignmentSvc *ReviewerAssignmentService
+	teamSvc       *TeamService
+	notifier      StaffingNotifier
+}
+
+// NewRosterService создает сервис реестра
+func NewRosterService(
+	teamRepo repo.TeamRepository,
+	userRepo repo.UserRepository,
+	moveRepo repo.MoveRepository,
+	assignmentSvc *ReviewerAssignmentService,
+	teamSvc *TeamService,
+	notifier StaffingNotifier,
+) *RosterService {
+	return &RosterService{
+		teamRepo:      teamRepo,
+		userRepo:      userRepo,
+		moveRepo:      moveRepo,
+		assignmentSvc: assignmentSvc,
+		teamSvc:       teamSvc,
+		notifier:      notifier,
+	}
+}
+
+// Import создает и обновляет пользователей по CSV со столбцами RosterColumns
+// (порядок — по заголовку). Отсутствующие команды создаются с политикой по
+// умолчанию, пустой team_name оставляет пользователя без команды. Остальные
+// атрибуты существующих пользователей не меняются; при деактивации их ревью
+// передаются коллегам, как в SetActive. Смена основной команды — ошибка строки
+// без allowMove; с ним автор запроса должен иметь право на перевод, как в
+// MoveUser. Если хоть одна строка содержит ошибку или задан dryRun, ничего
+// не применяется.
+func (s *RosterService) Import(ctx context.Context, r io.Reader, dryRun, allowMove bool) (*domain.RosterImport, error) {
+	rows, err := parseRoster(r)
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.RosterImport{Rows: make([]domain.RosterImportRow, 0, len(rows)), CreatedTeams: []string{}}
+	createTeams, err := s.check(ctx, rows, allowMove)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.result.Error != "" {
+			report.Errors++
+		}
+		report.Rows = append(report.Rows, row.result)
+	}
+	report.CreatedTeams = append(report.CreatedTeams, createTeams...)
+	if report.Errors > 0 || dryRun {
+		return report, nil
+	}
+
+	for _, teamName := range createTeams {
+		if err := s.teamRepo.CreateTeam(ctx, &domain.Team{TeamName: teamName, Policy: domain.DefaultTeamPolicy()}); err != nil {
+			return nil, fmt.Errorf("failed to create team %s: %w", teamName, err)
+		}
+	}
+	for _, row := range rows {
+		if err := s.apply(ctx, row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.result.Row, err)
+		}
+	}
+	s.notifier.Notify()
+	report.Applied = true
+	return report, nil
+}
+
+// Export пишет в w пользователей, кроме ушедших, в формате Import
+func (s *RosterService) Export(ctx context.Context, w io.Writer) error {
+	users, err := s.userRepo.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(RosterColumns); err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := out.Write([]string{user.UserID, user.Username, user.TeamName, strconv.FormatBool(user.IsActive)}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// parseRoster читает CSV; ошибки отдельных строк записываются в их результат
+func parseRoster(r io.Reader) ([]*rosterRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "invalid CSV header: "+err.Error())
+	}
+
+	// Заголовок задает порядок столбцов; Excel добавляет BOM в начало файла
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, column := range RosterColumns {
+		if _, ok := index[column]; !ok {
+			return nil, domain.NewError(domain.ErrorCodeInvalidInput, "CSV header must contain column "+column)
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+
+	var rows []*rosterRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		row := &rosterRow{result: domain.RosterImportRow{Row: line}}
+		rows = append(rows, row)
+		if err != nil {
+			if !errors.Is(err, csv.ErrFieldCount) {
+				return nil, domain.NewError(domain.ErrorCodeInvalidInput, "invalid CSV: "+err.Error())
+			}
+			row.result.Error = fmt.Sprintf("expected %d fields, got %d", len(header), len(record))
+			continue
+		}
+
+		field := func(column string) string {
+			return strings.TrimSpace(record[index[column]])
+		}
+		row.user = domain.User{
+			UserID:   field("user_id"),
+			Username: field("username"),
+			TeamName: field("team_name"),
+		}
+		row.result.UserID = row.user.UserID
+		if row.user.IsActive, err = strconv.ParseBool(field("is_active")); err != nil {
+			row.result.Error = "is_active must be true or false"
+		}
+	}
+	return rows, nil
+}
+
+// check проверяет строки, определяет их действия и возвращает команды, которые
+// нужно создать. Переводы без права на них отклоняют весь импорт.
+func (s *RosterService) check(ctx context.Context, rows []*rosterRow, allowMove bool) ([]string, error) {
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.user.UserID != "" {
+			ids = append(ids, row.user.UserID)
+		}
+	}
+	existing, err := s.userRepo.GetAllUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string]*domain.User, len(existing))
+	for i := range existing {
+		users[existing[i].UserID] = &existing[i]
+	}
+
+	var createTeams []string
+	knownTeams := make(map[string]bool)
+	seen := make(map[string]int)
+	for _, row := range rows {
+		if row.result.Error != "" {
+			continue
+		}
+		userID := row.user.UserID
+		if userID == "" {
+			row.result.Error = "user_id is required"
+			continue
+		}
+		if first, ok := seen[userID]; ok {
+			row.result.Error = fmt.Sprintf("user is already listed in row %d", first)
+			continue
+		}
+		seen[userID] = row.result.Row
+
+		row.current = users[userID]
+		if row.current != nil && row.current.DepartedAt != nil {
+			row.result.Error = "user has departed"
+			continue
+		}
+
+		if teamName := row.user.TeamName; teamName != "" {
+			if _, ok := knownTeams[teamName]; !ok {
+				exists, err := s.teamRepo.TeamExists(ctx, teamName)
+				if err != nil {
+					return nil, err
+				}
+				knownTeams[teamName] = exists
+				if !exists {
+					createTeams = append(createTeams, teamName)
+				}
+			}
+		}
+
+		switch current := row.current; {
+		case current == nil:
+			row.result.Action = domain.RosterCreate
+		case current.TeamName != row.user.TeamName:
+			row.result.Action = domain.RosterMove
+		case row.user.Username != "" && current.Username != row.user.Username,
+			current.IsActive != row.user.IsActive:
+			row.result.Action = domain.RosterUpdate
+		default:
+			row.result.Action = domain.RosterUnchanged
+		}
+
+		if row.result.Action == domain.RosterMove {
+			if !allowMove {
+				row.result.Error = fmt.Sprintf("user moves from team %q to team %q; set allow_move to move users",
+					row.current.TeamName, row.user.TeamName)
+				continue
+			}
+			// Как в MoveUser: право нужно на обе команды. Создаваемую команду
+			// еще никто не ведет, как и в CreateTeam.
+			teams := []string{row.current.TeamName}
+			if knownTeams[row.user.TeamName] {
+				teams = append(teams, row.user.TeamName)
+			}
+			for _, teamName := range teams {
+				if teamName == "" {
+					continue
+				}
+				if err := s.teamSvc.authorize(ctx, teamName); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return createTeams, nil
+}
+
+// apply сохраняет проверенную строку
+func (s *RosterService) apply(ctx context.Context, row *rosterRow) error {
+	current := row.current
+	if current == nil {
+		user := row.user
+		if user.Username == "" {
+			user.Username = user.UserID
+		}
+		user.WorkStartHour, user.WorkEndHour = domain.DefaultWorkStartHour, domain.DefaultWorkEndHour
+		if err := normalizeMember(&user); err != nil {
+			return err
+		}
+		return s.userRepo.CreateOrUpdateUser(ctx, &user)
+	}
+	if row.result.Action == domain.RosterUnchanged {
+		return nil
+	}
+
+	// Пустое имя в строке не стирает текущее
+	user := *current
+	if row.user.Username != "" {
+		user.Username = row.user.Username
+	}
+	user.TeamName = row.user.TeamName
+	user.IsActive = row.user.IsActive
+	if err := s.userRepo.CreateOrUpdateUser(ctx, &user); err != nil {
+		return err
+	}
+
+	if current.TeamName != user.TeamName {
+		move := &domain.TeamMove{UserID: user.UserID, FromTeam: current.TeamName, ToTeam: user.TeamName, Handovers: []domain.ReviewHandover{}}
+		if err := s.moveRepo.RecordMove(ctx, move); err != nil {
+			return fmt.Errorf("failed to record team move: %w", err)
+		}
+	}
+	if current.IsActive && !user.IsActive {
+		if _, err := s.assignmentSvc.HandOverReviews(ctx, &user, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// ScimService — провижининг пользователей и команд из identity provider'а.
+// Группы SCIM соответствуют командам. Права на изменение команд проверяются
+// так же, как в TeamService: автора запроса определяет SCIM-токен.
+type ScimService struct {
+	teamRepo       repo.TeamRepository
+	userRepo       repo.UserRepository
+	membershipRepo repo.MembershipRepository
+	moveRepo       repo.MoveRepository
+	teamSvc        *TeamService
+	notifier       StaffingNotifier
+}
+
+// NewScimService создает сервис провижининга
+func NewScimService(
+	teamRepo repo.TeamRepository,
+	userRepo repo.UserRepository,
+	membershipRepo repo.MembershipRepository,
+	moveRepo repo.MoveRepository,
+	teamSvc *TeamService,
+	notifier StaffingNotifier,
+) *ScimService {
+	return &ScimService{
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		moveRepo:       moveRepo,
+		teamSvc:        teamSvc,
+		notifier:       notifier,
+	}
+}
+
+// CreateUser заводит пользователя без команды; в команды его добавляют группы
+func (s *ScimService) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	if user.UserID == "" {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "userName is required")
+	}
+	existing, err := s.userRepo.GetAllUsersByIDs(ctx, []string{user.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		if existing[0].DepartedAt != nil {
+			return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed: "+user.UserID)
+		}
+		return nil, domain.NewError(domain.ErrorCodeUserExists, "user already exists: "+user.UserID)
+	}
+
+	user.TeamName = ""
+	if err := normalizeMember(user); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.CreateOrUpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserByID(ctx, user.UserID)
+}
+
+// GetUser получает пользователя; ушедшие для IdP удалены
+func (s *ScimService) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil || user.DepartedAt != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return user, nil
+}
+
+// ListUsers получает всех пользователей, кроме ушедших
+func (s *ScimService) ListUsers(ctx context.Context) ([]domain.User, error) {
+	return s.userRepo.ListUsers(ctx)
+}
+
+// CreateGroup создает команду с политикой по умолчанию и участниками memberIDs
+func (s *ScimService) CreateGroup(ctx context.Context, teamName string, memberIDs []string) (*domain.Team, error) {
+	if teamName == "" {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "displayName is required")
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, domain.NewError(domain.ErrorCodeTeamExists, "team already exists: "+teamName)
+	}
+	members, err := s.provisionedUsers(ctx, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.teamRepo.CreateTeam(ctx, &domain.Team{TeamName: teamName, Policy: domain.DefaultTeamPolicy()}); err != nil {
+		return nil, err
+	}
+	if err := s.addMembers(ctx, teamName, members); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+	return s.GetGroup(ctx, teamName)
+}
+
+// GetGroup получает команду с участниками
+func (s *ScimService) GetGroup(ctx context.Context, teamName string) (*domain.Team, error) {
+	team, err := s.teamRepo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	return team, nil
+}
+
+// ListGroups получает все команды с участниками
+func (s *ScimService) ListGroups(ctx context.Context) ([]domain.Team, error) {
+	return s.teamRepo.GetAllTeams(ctx)
+}
+
+// AddGroupMembers добавляет пользователей в команду. Для пользователя без
+// команды она становится основной, для остальных — дополнительной.
+func (s *ScimService) AddGroupMembers(ctx context.Context, teamName string, userIDs []string) error {
+	if _, err := s.GetGroup(ctx, teamName); err != nil {
+		return err
+	}
+	if err := s.teamSvc.authorize(ctx, teamName); err != nil {
+		return err
+	}
+	members, err := s.provisionedUsers(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.addMembers(ctx, teamName, members); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	return nil
+}
+
+// RemoveGroupMembers исключает пользователей из команды. Если команда была
+// основной, а у пользователя есть дополнительные, основной становится первая из них.
+func (s *ScimService) RemoveGroupMembers(ctx context.Context, teamName string, userIDs []string) error {
+	team, err := s.GetGroup(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if err := s.teamSvc.authorize(ctx, teamName); err != nil {
+		return err
+	}
+	primary := make(map[string]bool, len(team.Members))
+	for _, member := range team.Members {
+		primary[member.UserID] = member.TeamName == teamName
+	}
+	var removed []string
+	for _, userID := range userIDs {
+		if _, ok := primary[userID]; ok {
+			removed = append(removed, userID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := s.teamRepo.RemoveTeamMembers(ctx, teamName, removed); err != nil {
+		return err
+	}
+	for _, userID := range removed {
+		if !primary[userID] {
+			continue
+		}
+		memberships, err := s.membershipRepo.GetMemberships(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(memberships) == 0 {
+			continue
+		}
+		toTeam := memberships[0].TeamName
+		if _, err := s.userRepo.SetUserTeam(ctx, userID, toTeam); err != nil {
+			return err
+		}
+		if err := s.recordMove(ctx, userID, teamName, toTeam); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetGroupMembers заменяет состав команды на userIDs
+func (s *ScimService) SetGroupMembers(ctx context.Context, teamName string, userIDs []string) error {
+	team, err := s.GetGroup(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	desired := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		desired[userID] = true
+	}
+	var removed []string
+	for _, member := range team.Members {
+		if !desired[member.UserID] {
+			removed = append(removed, member.UserID)
+		}
+	}
+	if err := s.AddGroupMembers(ctx, teamName, userIDs); err != nil {
+		return err
+	}
+	return s.RemoveGroupMembers(ctx, teamName, removed)
+}
+
+// RenameGroup переименовывает команду
+func (s *ScimService) RenameGroup(ctx context.Context, teamName, newTeamName string) error {
+	if newTeamName == "" || newTeamName == teamName {
+		return nil
+	}
+	if err := s.teamSvc.authorize(ctx, teamName); err != nil {
+		return err
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, newTeamName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.NewError(domain.ErrorCodeTeamExists, "team already exists: "+newTeamName)
+	}
+	if err := s.teamRepo.RenameTeam(ctx, teamName, newTeamName); err != nil {
+		return domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	return nil
+}
+
+// DeleteGroup удаляет команду так же, как /team/delete с политикой reject:
+// пока у участников есть открытые PR, удаление отклоняется (TEAM_HAS_OPEN_PRS)
+func (s *ScimService) DeleteGroup(ctx context.Context, teamName string) error {
+	_, err := s.teamSvc.DeleteTeam(ctx, teamName, domain.TeamDeleteReject)
+	return err
+}
+
+// provisionedUsers получает пользователей по ID; все должны существовать и не быть ушедшими
+func (s *ScimService) provisionedUsers(ctx context.Context, userIDs []string) ([]domain.User, error) {
+	users, err := s.userRepo.GetAllUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(users))
+	for _, user := range users {
+		if user.DepartedAt != nil {
+			return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed: "+user.UserID)
+		}
+		found[user.UserID] = true
+	}
+	for _, userID := range userIDs {
+		if !found[userID] {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found: "+userID)
+		}
+	}
+	return users, nil
+}
+
+// addMembers добавляет пользователей в команду: основной — тем, у кого ее нет
+func (s *ScimService) addMembers(ctx context.Context, teamName string, users []domain.User) error {
+	for _, user := range users {
+		if user.TeamName == teamName {
+			continue
+		}
+		if user.TeamName != "" {
+			if err := s.membershipRepo.AddMembership(ctx, user.UserID, teamName); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := s.userRepo.SetUserTeam(ctx, user.UserID, teamName); err != nil {
+			return err
+		}
+		if err := s.recordMove(ctx, user.UserID, "", teamName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordMove сохраняет смену основной команды
+func (s *ScimService) recordMove(ctx context.Context, userID, fromTeam, toTeam string) error {
+	move := &domain.TeamMove{UserID: userID, FromTeam: fromTeam, ToTeam: toTeam, Handovers: []domain.ReviewHandover{}}
+	if err := s.moveRepo.RecordMove(ctx, move); err != nil {
+		return fmt.Errorf("failed to record team move: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// SimulationData — история для офлайн-симуляции: команды с участниками и политиками и PR
+type SimulationData struct {
+	Teams        []domain.Team        `json:"teams"`
+	PullRequests []domain.PullRequest `json:"pull_requests"`
+}
+
+// LoadSimulationData выгружает историю из хранилища
+func LoadSimulationData(ctx context.Context, teamRepo repo.TeamRepository, prRepo repo.PRRepository) (*SimulationData, error) {
+	teams, err := teamRepo.GetAllTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prs, err := prRepo.GetAllPRs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SimulationData{Teams: teams, PullRequests: prs}, nil
+}
+
+// SimulationReport — метрики справедливости распределения ревью
+type SimulationReport struct {
+	Strategy              string         `json:"strategy"`
+	PullRequests          int            `json:"pull_requests"`
+	Assignments           int            `json:"assignments"`
+	Understaffed          int            `json:"understaffed"` // PR, получившие меньше ревьюверов, чем нужно
+	Load                  map[string]int `json:"load"`         // ревью на каждого ревьювера
+	Gini                  float64        `json:"gini"`         // 0 — нагрузка поровну, 1 — все на одном
+	MaxConcurrentLoad     int            `json:"max_concurrent_load"`
+	MaxConcurrentReviewer string         `json:"max_concurrent_reviewer"`
+	RepeatPairRate        float64        `json:"repeat_pair_rate"` // доля назначений на автора, которого ревьювер уже ревьюил
+}
+
+// SimulationActual — имя прогона, описывающего фактические назначения из истории
+const SimulationActual = "actual"
+
+// Simulate прогоняет PR истории в порядке создания через стратегию strategy
+// с политиками команд. Состав команд, активность и навыки берутся текущими;
+// правила исключения, ручные назначения и эскалация в родительские команды
+// не учитываются.
+func Simulate(data *SimulationData, strategy string, seed int64) (*SimulationReport, error) {
+	teams := make(map[string]domain.Team, len(data.Teams))
+	teamOf := make(map[string]string)
+	// Пояса загружаются один раз на прогон
+	locations := make(map[string]*time.Location)
+	defaults := domain.DefaultTeamPolicy()
+	for _, team := range data.Teams {
+		team.Policy.Strategy = strategy
+		if team.Policy.AffinityWindowDays == 0 {
+			team.Policy.AffinityWindowDays = defaults.AffinityWindowDays
+		}
+		if _, err := StrategyFor(team.Policy); err != nil {
+			return nil, err
+		}
+		teams[team.TeamName] = team
+		for _, member := range team.Members {
+			if _, ok := locations[member.Timezone]; !ok {
+				loc, err := time.LoadLocation(member.Timezone)
+				if err != nil {
+					return nil, fmt.Errorf("user %s: %w", member.UserID, err)
+				}
+				locations[member.Timezone] = loc
+			}
+			// PR автора подбираются в его основной команде
+			if member.TeamName == team.TeamName {
+				teamOf[member.UserID] = team.TeamName
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	history := newLoadTracker(strategy, data)
+	for _, pr := range sortedByCreation(data.PullRequests) {
+		team, ok := teams[teamOf[pr.AuthorID]]
+		if !ok {
+			history.record(pr, nil)
+			continue
+		}
+
+		var candidates []domain.User
+		for _, member := range team.Members {
+			if member.IsActive && member.UserID != pr.AuthorID {
+				candidates = append(candidates, member)
+			}
+		}
+		openReviews := history.openReviews(pr.CreatedAt)
+		recentPairs := history.recentPairs(pr.AuthorID, pr.CreatedAt.AddDate(0, 0, -team.Policy.AffinityWindowDays))
+		candidates = filterByCapacity(candidates, openReviews, team.Policy, &domain.Assignment{})
+
+		snapshot := &domain.AssignmentSnapshot{
+			Seed:       rng.Int63(),
+			Policy:     team.Policy,
+			Labels:     pr.Labels,
+			Count:      domain.DefaultReviewersCount,
+			NeedSenior: team.Policy.RequireSenior,
+			Candidates: make([]domain.CandidateSnapshot, len(candidates)),
+		}
+		for i, candidate := range candidates {
+			snapshot.Candidates[i] = domain.CandidateSnapshot{
+				UserID:      candidate.UserID,
+				Seniority:   candidate.Seniority,
+				Skills:      candidate.Skills,
+				OpenReviews: openReviews[candidate.UserID],
+				RecentPairs: recentPairs[candidate.UserID],
+				OnHours:     candidate.IsWorkingHour(pr.CreatedAt.In(locations[candidate.Timezone]).Hour()),
+			}
+		}
+		assignment, err := decide(snapshot)
+		if err != nil {
+			return nil, err
+		}
+		history.record(pr, assignment.Reviewers)
+	}
+	return history.report(), nil
+}
+
+// MeasureActual считает те же метрики по фактическим назначениям из истории
+func MeasureActual(data *SimulationData) *SimulationReport {
+	history := newLoadTracker(SimulationActual, data)
+	for _, pr := range sortedByCreation(data.PullRequests) {
+		history.record(pr, pr.AssignedReviewers)
+	}
+	return history.report()
+}
+
+// sortedByCreation возвращает копию PR в порядке создания
+func sortedByCreation(prs []domain.PullRequest) []domain.PullRequest {
+	sorted := make([]domain.PullRequest, len(prs))
+	copy(sorted, prs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	return sorted
+}
+
+// simulatedReview — назначение ревьювера в ходе прогона
+type simulatedReview struct {
+	reviewerID string
+	authorID   string
+	createdAt  time.Time
+	mergedAt   *time.Time
+}
+
+// loadTracker накапливает назначения прогона и считает по ним метрики
+type loadTracker struct {
+	stats      SimulationReport
+	reviews    []simulatedReview
+	population []string // все активные участники команд: нулевая нагрузка тоже входит в Gini
+	seenPairs  map[[2]string]bool
+	repeats    int
+}
+
+func newLoadTracker(strategy string, data *SimulationData) *loadTracker {
+	t := &loadTracker{
+		stats:     SimulationReport{Strategy: strategy, Load: make(map[string]int)},
+		seenPairs: make(map[[2]string]bool),
+	}
+	seen := make(map[string]bool)
+	for _, team := range data.Teams {
+		for _, member := range team.Members {
+			// Участник нескольких команд учитывается один раз
+			if member.IsActive && !seen[member.UserID] {
+				seen[member.UserID] = true
+				t.population = append(t.population, member.UserID)
+			}
+		}
+	}
+	return t
+}
+
+// openReviews считает ревью, открытые у каждого ревьювера в момент at
+func (t *loadTracker) openReviews(at time.Time) map[string]int {
+	open := make(map[string]int)
+	for _, review := range t.reviews {
+		if !review.createdAt.After(at) && (review.mergedAt == nil || review.mergedAt.After(at)) {
+			open[review.reviewerID]++
+		}
+	}
+	return open
+}
+
+// recentPairs считает назначения на PR автора, созданные начиная с since
+func (t *loadTracker) recentPairs(authorID string, since time.Time) map[string]int {
+	pairs := make(map[string]int)
+	for _, review := range t.reviews {
+		if review.authorID == authorID && !review.createdAt.Before(since) {
+			pairs[review.reviewerID]++
+		}
+	}
+	return pairs
+}
+
+// record фиксирует ревьюверов, назначенных на pr
+func (t *loadTracker) record(pr domain.PullRequest, reviewers []string) {
+	t.stats.PullRequests++
+	if len(reviewers) < domain.DefaultReviewersCount {
+		t.stats.Understaffed++
+	}
+
+	open := t.openReviews(pr.CreatedAt)
+	for _, reviewerID := range reviewers {
+		t.stats.Assignments++
+		t.stats.Load[reviewerID]++
+
+		// Пик одновременной нагрузки достигается в момент назначения
+		if concurrent := open[reviewerID] + 1; concurrent > t.stats.MaxConcurrentLoad {
+			t.stats.MaxConcurrentLoad = concurrent
+			t.stats.MaxConcurrentReviewer = reviewerID
+		}
+
+		pair := [2]string{pr.AuthorID, reviewerID}
+		if t.seenPairs[pair] {
+			t.repeats++
+		}
+		t.seenPairs[pair] = true
+
+		t.reviews = append(t.reviews, simulatedReview{
+			reviewerID: reviewerID,
+			authorID:   pr.AuthorID,
+			createdAt:  pr.CreatedAt,
+			mergedAt:   pr.MergedAt,
+		})
+	}
+}
+
+// report завершает подсчет метрик
+func (t *loadTracker) report() *SimulationReport {
+	report := t.stats
+	if report.Assignments > 0 {
+		report.RepeatPairRate = float64(t.repeats) / float64(report.Assignments)
+	}
+
+	loads := make([]int, 0, len(t.population))
+	counted := make(map[string]bool, len(t.population))
+	for _, userID := range t.population {
+		loads = append(loads, report.Load[userID])
+		counted[userID] = true
+	}
+	// Ревьюверы, уже не состоящие в командах, тоже несли нагрузку
+	for userID, load := range report.Load {
+		if !counted[userID] {
+			loads = append(loads, load)
+		}
+	}
+	report.Gini = gini(loads)
+	return &report
+}
+
+// gini считает коэффициент Джини распределения нагрузки
+func gini(loads []int) float64 {
+	n := len(loads)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]int, n)
+	copy(sorted, loads)
+	sort.Ints(sorted)
+
+	var sum, weighted float64
+	for i, load := range sorted {
+		sum += float64(load)
+		weighted += float64(i+1) * float64(load)
+	}
+	if sum == 0 {
+		return 0
+	}
+	return 2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// simulationHistory — три PR автора a в команде с двумя ревьюверами: любая
+// стратегия назначает на каждый PR обоих, поэтому метрики не зависят от seed'а.
+// Фактически PR 1 смержен до создания PR 2, а на PR 2 назначен только r1.
+const simulationHistory = `{
+  "teams": [{
+    "team_name": "core",
+    "members": [
+      {"user_id": "a",  "team_name": "core", "is_active": true},
+      {"user_id": "r1", "team_name": "core", "is_active": true},
+      {"user_id": "r2", "team_name": "core", "is_active": true},
+      {"user_id": "r3", "team_name": "core", "is_active": false}
+    ]
+  }],
+  "pull_requests": [
+    {"pull_request_id": "pr-3", "author_id": "a", "created_at": "2024-01-03T10:00:00Z",
+     "assigned_reviewers": ["r1", "r3"]},
+    {"pull_request_id": "pr-1", "author_id": "a", "created_at": "2024-01-01T10:00:00Z",
+     "merged_at": "2024-01-01T12:00:00Z", "assigned_reviewers": ["r1", "r2"]},
+    {"pull_request_id": "pr-2", "author_id": "a", "created_at": "2024-01-02T10:00:00Z",
+     "assigned_reviewers": ["r1"]}
+  ]
+}`
+
+func loadSimulationHistory(t *testing.T, history string) *SimulationData {
+	t.Helper()
+	var data SimulationData
+	require.NoError(t, json.Unmarshal([]byte(history), &data))
+	return &data
+}
+
+func TestSimulate(t *testing.T) {
+	data := loadSimulationHistory(t, simulationHistory)
+
+	report, err := Simulate(data, "random", 42)
+	require.NoError(t, err)
+
+	assert.Equal(t, "random", report.Strategy)
+	assert.Equal(t, 3, report.PullRequests)
+	assert.Equal(t, 6, report.Assignments)
+	assert.Equal(t, 0, report.Understaffed)
+	assert.Equal(t, map[string]int{"r1": 3, "r2": 3}, report.Load)
+	// Нагрузка a, r1, r2 — 0, 3, 3; неактивный r3 в распределение не входит
+	assert.InDelta(t, 1.0/3, report.Gini, 1e-9)
+	// PR 1 смержен до PR 2, дальше ревью копятся
+	assert.Equal(t, 2, report.MaxConcurrentLoad)
+	assert.Contains(t, []string{"r1", "r2"}, report.MaxConcurrentReviewer)
+	// Новые пары — только на первом PR
+	assert.InDelta(t, 4.0/6, report.RepeatPairRate, 1e-9)
+
+	again, err := Simulate(data, "random", 42)
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+}
+
+func TestSimulateUnknownStrategy(t *testing.T) {
+	_, err := Simulate(loadSimulationHistory(t, simulationHistory), "round-robin", 1)
+	assert.Error(t, err)
+}
+
+func TestSimulateUnknownTimezone(t *testing.T) {
+	data := loadSimulationHistory(t, simulationHistory)
+	data.Teams[0].Members[1].Timezone = "Mars/Olympus"
+
+	_, err := Simulate(data, "random", 1)
+	assert.ErrorContains(t, err, "r1")
+}
+
+func TestSimulateWithoutPullRequests(t *testing.T) {
+	data := loadSimulationHistory(t, simulationHistory)
+	data.PullRequests = nil
+
+	report, err := Simulate(data, "affinity", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.PullRequests)
+	assert.Equal(t, 0, report.Assignments)
+	assert.Empty(t, report.Load)
+	assert.Zero(t, report.Gini)
+	assert.Zero(t, report.MaxConcurrentLoad)
+	assert.Empty(t, report.MaxConcurrentReviewer)
+	assert.Zero(t, report.RepeatPairRate)
+}
+
+func TestSimulateSingleReviewer(t *testing.T) {
+	data := loadSimulationHistory(t, simulationHistory)
+	data.Teams[0].Members = data.Teams[0].Members[:2]
+
+	report, err := Simulate(data, "skills", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Assignments)
+	assert.Equal(t, 3, report.Understaffed)
+	assert.Equal(t, map[string]int{"r1": 3}, report.Load)
+	// Нагрузка a и r1 — 0 и 3
+	assert.InDelta(t, 0.5, report.Gini, 1e-9)
+	assert.Equal(t, 2, report.MaxConcurrentLoad)
+	assert.InDelta(t, 2.0/3, report.RepeatPairRate, 1e-9)
+}
+
+func TestMeasureActual(t *testing.T) {
+	report := MeasureActual(loadSimulationHistory(t, simulationHistory))
+
+	assert.Equal(t, SimulationActual, report.Strategy)
+	assert.Equal(t, 3, report.PullRequests)
+	assert.Equal(t, 5, report.Assignments)
+	assert.Equal(t, 1, report.Understaffed)
+	assert.Equal(t, map[string]int{"r1": 3, "r2": 1, "r3": 1}, report.Load)
+	// Нагрузка a, r1, r2 и ушедшего из активных r3 — 0, 3, 1, 1
+	assert.InDelta(t, 0.45, report.Gini, 1e-9)
+	assert.Equal(t, 2, report.MaxConcurrentLoad)
+	assert.Equal(t, "r1", report.MaxConcurrentReviewer)
+	assert.InDelta(t, 2.0/5, report.RepeatPairRate, 1e-9)
+}
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		name  string
+		loads []int
+		want  float64
+	}{
+		{name: "no reviewers", loads: nil, want: 0},
+		{name: "all zero", loads: []int{0, 0, 0}, want: 0},
+		{name: "single reviewer", loads: []int{5}, want: 0},
+		{name: "equal", loads: []int{2, 2, 2, 2}, want: 0},
+		{name: "all on one", loads: []int{0, 0, 0, 4}, want: 0.75},
+		{name: "unsorted", loads: []int{3, 0, 1, 1}, want: 0.45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, gini(tt.loads), 1e-9)
+		})
+	}
+}
+
+func TestLoadTracker(t *testing.T) {
+	data := loadSimulationHistory(t, simulationHistory)
+	tracker := newLoadTracker("test", data)
+	assert.Equal(t, []string{"a", "r1", "r2"}, tracker.population)
+
+	for _, pr := range sortedByCreation(data.PullRequests) {
+		tracker.record(pr, pr.AssignedReviewers)
+	}
+
+	day := func(d, hour int) time.Time { return time.Date(2024, 1, d, hour, 0, 0, 0, time.UTC) }
+	// PR 1 открыт с 10:00 до 12:00 первого дня
+	assert.Equal(t, map[string]int{"r1": 1, "r2": 1}, tracker.openReviews(day(1, 11)))
+	assert.Empty(t, tracker.openReviews(day(1, 12)))
+	assert.Equal(t, map[string]int{"r1": 2, "r3": 1}, tracker.openReviews(day(3, 10)))
+
+	assert.Equal(t, map[string]int{"r1": 2, "r3": 1}, tracker.recentPairs("a", day(2, 10)))
+	assert.Empty(t, tracker.recentPairs("r1", day(1, 0)))
+
+	// Отчет можно снимать повторно: накопленные данные не меняются
+	first := tracker.report()
+	assert.Equal(t, first, tracker.report())
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+)
+
+// StaffingNotifier получает сигналы о событиях, после которых у недоукомплектованных
+// PR могли появиться кандидаты (активация пользователя, новая команда, освободившийся лимит)
+type StaffingNotifier interface {
+	Notify()
+}
+
+// StaffingWorker фоновый воркер, добирающий ревьюверов на PR из очереди
+type StaffingWorker struct {
+	orgRepo       repo.OrganizationRepository
+	queueRepo     repo.StaffingQueueRepository
+	prRepo        repo.PRRepository
+	transactor    repo.Transactor
+	assignmentSvc *ReviewerAssignmentService
+	interval      time.Duration
+	wake          chan struct{}
+}
+
+// NewStaffingWorker создает воркер; interval — период плановых повторов
+func NewStaffingWorker(
+	orgRepo repo.OrganizationRepository,
+	queueRepo repo.StaffingQueueRepository,
+	prRepo repo.PRRepository,
+	transactor repo.Transactor,
+	assignmentSvc *ReviewerAssignmentService,
+	interval time.Duration,
+) *StaffingWorker {
+	return &StaffingWorker{
+		orgRepo:       orgRepo,
+		queueRepo:     queueRepo,
+		prRepo:        prRepo,
+		transactor:    transactor,
+		assignmentSvc: assignmentSvc,
+		interval:      interval,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Notify просит воркер обработать очередь вне расписания; не блокирует вызывающего
+func (w *StaffingWorker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает очередь по таймеру и по сигналам Notify до отмены ctx
+func (w *StaffingWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+		if err := w.ProcessQueue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("staffing worker: %v", err)
+		}
+	}
+}
+
+// ProcessQueue делает одну попытку добора для каждого PR из очередей всех организаций
+func (w *StaffingWorker) ProcessQueue(ctx context.Context) error {
+	orgs, err := w.orgRepo.ListOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		orgCtx := domain.WithOrganization(ctx, org.OrgID)
+		queue, err := w.queueRepo.ListQueued(orgCtx)
+		if err != nil {
+			return err
+		}
+		for _, item := range queue {
+			if err := w.staff(orgCtx, item.Repository, item.PullRequestID); err != nil {
+				log.Printf("staffing worker: %s: PR %s: %v", org.OrgID, item.PullRequestID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// staff добирает ревьюверов на один PR и обновляет его запись в очереди.
+// PR заблокирован на время добора, чтобы не затереть параллельное
+// переназначение, добавление или снятие ревьювера.
+func (w *StaffingWorker) staff(ctx context.Context, repository, prID string) error {
+	return w.transactor.InTx(ctx, func(ctx context.Context) error {
+		return w.staffLocked(ctx, repository, prID)
+	})
+}
+
+// staffLocked — staff внутри транзакции
+func (w *StaffingWorker) staffLocked(ctx context.Context, repository, prID string) error {
+	if err := w.prRepo.LockPR(ctx, repository, prID); err != nil {
+		return err
+	}
+	pr, err := w.prRepo.GetPRByID(ctx, repository, prID)
+	if err != nil {
+		return err
+	}
+	if pr.Status != domain.PRStatusOpen {
+		return w.queueRepo.Dequeue(ctx, repository, prID)
+	}
+
+	reason := domain.ErrorCodeNoCandidate
+	added, err := w.assignmentSvc.FillReviewers(ctx, pr)
+	var domErr domain.DomainError
+	switch {
+	case errors.As(err, &domErr) && domErr.Code == domain.ErrorCodeNoCapacity:
+		reason = domain.ErrorCodeNoCapacity
+	case err != nil:
+		return err
+	}
+
+	if err == nil && len(added.Reviewers) > 0 {
+		reviewers := append(pr.AssignedReviewers, added.Reviewers...)
+		if err := w.prRepo.UpdateReviewers(ctx, repository, prID, reviewers); err != nil {
+			return err
+		}
+		if err := w.assignmentSvc.RecordAssignment(ctx, pr, domain.AssignmentEventFill, added.Reviewers, added); err != nil {
+			return err
+		}
+		pr.AssignedReviewers = reviewers
+	}
+
+	required, err := w.assignmentSvc.RequiredReviewers(ctx, pr)
+	if err != nil {
+		return err
+	}
+	missing := required - len(pr.AssignedReviewers)
+	if missing <= 0 {
+		return w.queueRepo.Dequeue(ctx, repository, prID)
+	}
+	return w.queueRepo.MarkAttempt(ctx, repository, prID, missing, reason)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+)
+
+// Веса стратегии skills
+const (
+	skillMatchWeight = 1.0  // за каждый навык, совпавший с меткой PR
+	openReviewWeight = 0.25 // штраф за каждое открытое ревью кандидата
+)
+
+// CandidateFeatures — признаки кандидата, по которым стратегия выставляет балл
+type CandidateFeatures struct {
+	User          domain.User
+	OpenReviews   int
+	MatchedSkills []string
+	RecentPairs   int // назначения на PR этого же автора в окне affinity
+}
+
+// Strategy ранжирует кандидатов: чем больше балл, тем предпочтительнее ревьювер.
+// Кандидаты с равным баллом остаются в случайном порядке.
+type Strategy interface {
+	Name() string
+	Score(f CandidateFeatures) domain.CandidateScore
+}
+
+// StrategyFor возвращает стратегию команды; пустое имя — стратегия по умолчанию
+func StrategyFor(policy domain.TeamPolicy) (Strategy, error) {
+	switch policy.Strategy {
+	case "", domain.StrategyRandom:
+		return randomStrategy{}, nil
+	case domain.StrategySkills:
+		return skillsStrategy{}, nil
+	case domain.StrategyAffinity:
+		return affinityStrategy{weight: policy.AffinityWeight}, nil
+	default:
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "unknown strategy: "+policy.Strategy)
+	}
+}
+
+// randomStrategy не различает кандидатов — порядок задает перемешивание
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return domain.StrategyRandom }
+
+func (randomStrategy) Score(f CandidateFeatures) domain.CandidateScore {
+	return domain.CandidateScore{UserID: f.User.UserID}
+}
+
+// skillsStrategy поощряет совпадение навыков с метками PR и штрафует за загрузку
+type skillsStrategy struct{}
+
+func (skillsStrategy) Name() string { return domain.StrategySkills }
+
+func (skillsStrategy) Score(f CandidateFeatures) domain.CandidateScore {
+	skills := skillMatchWeight * float64(len(f.MatchedSkills))
+	load := -openReviewWeight * float64(f.OpenReviews)
+	return domain.CandidateScore{
+		UserID: f.User.UserID,
+		Score:  skills + load,
+		Breakdown: map[string]float64{
+			"skill_match":  skills,
+			"open_reviews": load,
+		},
+	}
+}
+
+// affinityStrategy штрафует за недавние ревью PR того же автора,
+// чтобы знания о коде расходились по команде
+type affinityStrategy struct {
+	weight float64
+}
+
+func (affinityStrategy) Name() string { return domain.StrategyAffinity }
+
+func (a affinityStrategy) Score(f CandidateFeatures) domain.CandidateScore {
+	pairs := -a.weight * float64(f.RecentPairs)
+	return domain.CandidateScore{
+		UserID:    f.User.UserID,
+		Score:     pairs,
+		Breakdown: map[string]float64{"recent_pairs": pairs},
+	}
+}
+
+// matchSkills возвращает навыки пользователя, совпавшие с метками PR
+func matchSkills(skills, labels []string) []string {
+	wanted := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		wanted[label] = true
+	}
+	var matched []string
+	for _, skill := range skills {
+		if wanted[skill] {
+			matched = append(matched, skill)
+		}
+	}
+	return matched
+}
@@ -10,20 +10,43 @@ import (
 
 // TeamService сервис для работы с командами
 type TeamService struct {
-	teamRepo repo.TeamRepository
-	userRepo repo.UserRepository
+	teamRepo       repo.TeamRepository
+	userRepo       repo.UserRepository
+	ruleRepo       repo.RuleRepository
+	moveRepo       repo.MoveRepository
+	membershipRepo repo.MembershipRepository
+	transactor     repo.Transactor
+	assignmentSvc  *ReviewerAssignmentService
+	notifier       StaffingNotifier
 }
 
 // NewTeamService создает новый сервис команд
-func NewTeamService(teamRepo repo.TeamRepository, userRepo repo.UserRepository) *TeamService {
+func NewTeamService(
+	teamRepo repo.TeamRepository,
+	userRepo repo.UserRepository,
+	ruleRepo repo.RuleRepository,
+	moveRepo repo.MoveRepository,
+	membershipRepo repo.MembershipRepository,
+	transactor repo.Transactor,
+	assignmentSvc *ReviewerAssignmentService,
+	notifier StaffingNotifier,
+) *TeamService {
 	return &TeamService{
-		teamRepo: teamRepo,
-		userRepo: userRepo,
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		ruleRepo:       ruleRepo,
+		moveRepo:       moveRepo,
+		membershipRepo: membershipRepo,
+		transactor:     transactor,
+		assignmentSvc:  assignmentSvc,
+		notifier:       notifier,
 	}
 }
 
-// CreateTeam создает команду с участниками
-func (s *TeamService) CreateTeam(ctx context.Context, team *domain.Team) (*domain.Team, error) {
+// CreateTeam создает команду с участниками. Участник, уже состоящий в другой
+// команде, переводится только при allowMove — иначе USER_IN_OTHER_TEAM; переводить
+// может только автор запроса с правом менять состав прежней команды.
+func (s *TeamService) CreateTeam(ctx context.Context, team *domain.Team, allowMove bool) (*domain.Team, error) {
 	// Проверяем существование команды
 	exists, err := s.teamRepo.TeamExists(ctx, team.TeamName)
 	if err != nil {
@@ -33,18 +56,63 @@ func (s *TeamService) CreateTeam(ctx context.Context, team *domain.Team) (*domai
 		return nil, domain.NewError(domain.ErrorCodeTeamExists, "team already exists")
 	}
 
-	// Создаем команду
-	if err := s.teamRepo.CreateTeam(ctx, team); err != nil {
+	defaults := domain.DefaultTeamPolicy()
+	if team.Policy.Strategy == "" {
+		team.Policy.Strategy = defaults.Strategy
+	}
+	if team.Policy.AffinityWindowDays == 0 {
+		team.Policy.AffinityWindowDays = defaults.AffinityWindowDays
+	}
+	if err := team.Policy.Validate(); err != nil {
 		return nil, err
 	}
+	if team.ParentTeam != "" {
+		exists, err := s.teamRepo.TeamExists(ctx, team.ParentTeam)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "parent team not found")
+		}
+	}
 
-	// Создаем/обновляем пользователей
+	// Проверяем рабочие часы и уровни участников до записи в БД
+	if err := s.mergeMembers(ctx, team.Members); err != nil {
+		return nil, err
+	}
 	for i := range team.Members {
-		team.Members[i].TeamName = team.TeamName
-		if err := s.userRepo.CreateOrUpdateUser(ctx, &team.Members[i]); err != nil {
-			return nil, fmt.Errorf("failed to create team member: %w", err)
+		if err := normalizeMember(&team.Members[i]); err != nil {
+			return nil, err
 		}
 	}
+	moves, err := s.planMoves(ctx, team.TeamName, team.Members, allowMove)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeMoves(ctx, moves); err != nil {
+		return nil, err
+	}
+
+	// Команда, ее участники, роли и переходы сохраняются вместе
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.CreateTeam(ctx, team); err != nil {
+			return err
+		}
+		for i := range team.Members {
+			team.Members[i].TeamName = team.TeamName
+			if err := s.userRepo.CreateOrUpdateUser(ctx, &team.Members[i]); err != nil {
+				return fmt.Errorf("failed to create team member: %w", err)
+			}
+		}
+		if err := s.applyRoles(ctx, team.TeamName, team.Members); err != nil {
+			return err
+		}
+		return s.recordMoves(ctx, moves)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
 
 	return team, nil
 }
@@ -55,10 +123,683 @@ func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*domain.Tea
 	if err != nil {
 		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
 	}
-	return team, nil
+	return team, s.fillRoles(ctx, team)
+}
+
+// fillRoles заполняет роли участников в команде team
+func (s *TeamService) fillRoles(ctx context.Context, team *domain.Team) error {
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, team.TeamName)
+	if err != nil {
+		return err
+	}
+	for i := range team.Members {
+		team.Members[i].Role = roles[team.Members[i].UserID]
+	}
+	return nil
+}
+
+// GetTeamTree получает команду вместе со всеми подкомандами и их участниками
+func (s *TeamService) GetTeamTree(ctx context.Context, teamName string) (*domain.Team, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	return team, s.loadSubteams(ctx, team, map[string]bool{teamName: true})
+}
+
+// loadSubteams рекурсивно заполняет team.Subteams; seen защищает от циклов
+func (s *TeamService) loadSubteams(ctx context.Context, team *domain.Team, seen map[string]bool) error {
+	names, err := s.teamRepo.GetSubteams(ctx, team.TeamName)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		subteam, err := s.teamRepo.GetTeamByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := s.fillRoles(ctx, subteam); err != nil {
+			return err
+		}
+		if err := s.loadSubteams(ctx, subteam, seen); err != nil {
+			return err
+		}
+		team.Subteams = append(team.Subteams, *subteam)
+	}
+	return nil
+}
+
+// SetParent делает parentTeam родительской командой teamName; пустой parentTeam
+// делает команду командой верхнего уровня. Циклы в иерархии запрещены.
+// Нужны права на обе команды: подкоманда получает кандидатов родительской.
+func (s *TeamService) SetParent(ctx context.Context, teamName, parentTeam string) (*domain.Team, error) {
+	for _, name := range []string{teamName, parentTeam} {
+		if name == "" {
+			continue
+		}
+		exists, err := s.teamRepo.TeamExists(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found: "+name)
+		}
+		if err := s.authorize(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if parentTeam != "" {
+		if parentTeam == teamName {
+			return nil, domain.NewError(domain.ErrorCodeInvalidInput, "team cannot be its own parent")
+		}
+		ancestors, err := s.teamRepo.GetTeamAncestors(ctx, parentTeam)
+		if err != nil {
+			return nil, err
+		}
+		if contains(ancestors, teamName) {
+			return nil, domain.NewError(domain.ErrorCodeInvalidInput,
+				fmt.Sprintf("team %s is an ancestor of %s", teamName, parentTeam))
+		}
+	}
+
+	if err := s.teamRepo.SetParentTeam(ctx, teamName, parentTeam); err != nil {
+		return nil, err
+	}
+	// У подкоманды могли появиться кандидаты в родительской команде
+	s.notifier.Notify()
+	return s.GetTeam(ctx, teamName)
+}
+
+// SetPolicy заменяет настройки назначения команды
+func (s *TeamService) SetPolicy(ctx context.Context, teamName string, policy *domain.TeamPolicy) (*domain.TeamPolicy, error) {
+	if err := s.authorize(ctx, teamName); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.teamRepo.UpdateTeamPolicy(ctx, teamName, policy); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	s.notifier.Notify()
+	return policy, nil
 }
 
 // GetTeamMembers получает членов команды
 func (s *TeamService) GetTeamMembers(ctx context.Context, teamName string) ([]domain.User, error) {
 	return s.teamRepo.GetTeamMembers(ctx, teamName)
 }
+
+// AddRule добавляет правило исключения ревьюверов в команду
+func (s *TeamService) AddRule(ctx context.Context, rule *domain.ExclusionRule) (*domain.ExclusionRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, rule.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	if err := s.authorize(ctx, rule.TeamName); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRules получает правила исключения команды
+func (s *TeamService) GetRules(ctx context.Context, teamName string) ([]domain.ExclusionRule, error) {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	return s.ruleRepo.GetRulesByTeam(ctx, teamName)
+}
+
+// DeleteRule удаляет правило исключения; снятый запрет может дать кандидатов PR из очереди
+func (s *TeamService) DeleteRule(ctx context.Context, ruleID int64) error {
+	rule, err := s.ruleRepo.GetRule(ctx, ruleID)
+	if err != nil {
+		return domain.NewError(domain.ErrorCodeNotFound, "rule not found")
+	}
+	if err := s.authorize(ctx, rule.TeamName); err != nil {
+		return err
+	}
+	if err := s.ruleRepo.DeleteRule(ctx, ruleID); err != nil {
+		return domain.NewError(domain.ErrorCodeNotFound, "rule not found")
+	}
+	s.notifier.Notify()
+	return nil
+}
+
+// UpdateTeamRequest — изменения команды; пустые поля не меняются
+type UpdateTeamRequest struct {
+	TeamName      string
+	NewTeamName   string
+	AddMembers    []domain.User // новые участники
+	RemoveMembers []string      // исключенные остаются без команды
+	AllowMove     bool          // разрешить перевод в команду участников других команд
+}
+
+// UpdateTeam переименовывает команду и меняет ее состав. Все изменения
+// проверяются до записи.
+func (s *TeamService) UpdateTeam(ctx context.Context, req UpdateTeamRequest) (*domain.Team, error) {
+	team, err := s.GetTeam(ctx, req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, req.TeamName); err != nil {
+		return nil, err
+	}
+
+	rename := req.NewTeamName != "" && req.NewTeamName != req.TeamName
+	if rename {
+		exists, err := s.teamRepo.TeamExists(ctx, req.NewTeamName)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, domain.NewError(domain.ErrorCodeTeamExists, "team already exists: "+req.NewTeamName)
+		}
+	}
+
+	if err := s.mergeMembers(ctx, req.AddMembers); err != nil {
+		return nil, err
+	}
+	for i := range req.AddMembers {
+		if err := normalizeMember(&req.AddMembers[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	moves, err := s.planMoves(ctx, req.TeamName, req.AddMembers, req.AllowMove)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeMoves(ctx, moves); err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]domain.User, len(team.Members))
+	for _, member := range team.Members {
+		members[member.UserID] = member
+	}
+	removed := make([]domain.User, 0, len(req.RemoveMembers))
+	for _, userID := range req.RemoveMembers {
+		member, ok := members[userID]
+		if !ok {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "user is not a team member: "+userID)
+		}
+		removed = append(removed, member)
+	}
+
+	// Все изменения применяются вместе или не применяются вовсе
+	teamName := req.TeamName
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Исключаем до переименования: ревью передаются под прежним именем команды
+		if err := s.removeMembers(ctx, teamName, removed); err != nil {
+			return err
+		}
+		if rename {
+			if err := s.teamRepo.RenameTeam(ctx, teamName, req.NewTeamName); err != nil {
+				return err
+			}
+			teamName = req.NewTeamName
+		}
+		for i := range req.AddMembers {
+			req.AddMembers[i].TeamName = teamName
+			if err := s.userRepo.CreateOrUpdateUser(ctx, &req.AddMembers[i]); err != nil {
+				return fmt.Errorf("failed to add team member: %w", err)
+			}
+		}
+		if err := s.applyRoles(ctx, teamName, req.AddMembers); err != nil {
+			return err
+		}
+		for i := range moves {
+			moves[i].ToTeam = teamName
+		}
+		return s.recordMoves(ctx, moves)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+
+	return s.GetTeam(ctx, teamName)
+}
+
+// removeMembers исключает участников из команды teamName. Их открытые ревью PR
+// этой команды передаются оставшимся участникам, как при переводе; ревью без
+// замены остаются за пользователем. Для кого команда была основной, остаются
+// без команды, и это записывается в историю переходов.
+func (s *TeamService) removeMembers(ctx context.Context, teamName string, removed []domain.User) error {
+	if len(removed) == 0 {
+		return nil
+	}
+
+	moves := make([]domain.TeamMove, 0, len(removed))
+	userIDs := make([]string, len(removed))
+	for i, member := range removed {
+		userIDs[i] = member.UserID
+		primary := member.TeamName == teamName
+
+		// Замену ищем до исключения, пока пользователь еще числится в команде.
+		// Для дополнительного участника передаются ревью PR этой, а не основной команды.
+		member.TeamName = teamName
+		handovers, err := s.assignmentSvc.HandOverReviews(ctx, &member, true)
+		if err != nil {
+			return err
+		}
+		if primary {
+			moves = append(moves, domain.TeamMove{UserID: member.UserID, FromTeam: teamName, Handovers: handovers})
+		}
+	}
+
+	if err := s.teamRepo.RemoveTeamMembers(ctx, teamName, userIDs); err != nil {
+		return err
+	}
+	return s.recordMoves(ctx, moves)
+}
+
+// DeleteTeam удаляет команду. policy определяет судьбу открытых PR участников:
+// reject (по умолчанию) — отказать, detach — удалить, оставив их без добора.
+// Возвращает число открытых PR участников на момент удаления.
+func (s *TeamService) DeleteTeam(ctx context.Context, teamName, policy string) (int, error) {
+	if policy == "" {
+		policy = domain.TeamDeleteReject
+	}
+	if policy != domain.TeamDeleteReject && policy != domain.TeamDeleteDetach {
+		return 0, domain.NewError(domain.ErrorCodeInvalidInput, "unknown open PR policy: "+policy)
+	}
+
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	if err := s.authorize(ctx, teamName); err != nil {
+		return 0, err
+	}
+
+	openPRs, err := s.teamRepo.CountOpenPRs(ctx, teamName)
+	if err != nil {
+		return 0, err
+	}
+	if openPRs > 0 && policy == domain.TeamDeleteReject {
+		return 0, domain.NewError(domain.ErrorCodeTeamHasOpenPR,
+			fmt.Sprintf("team members have %d open PR(s); merge them or delete with policy detach", openPRs))
+	}
+
+	if err := s.teamRepo.DeleteTeam(ctx, teamName); err != nil {
+		return 0, err
+	}
+	return openPRs, nil
+}
+
+// MoveUser переводит пользователя в другую команду. При handover его открытые
+// ревью PR старой команды переназначаются на коллег по старой команде; ревью,
+// которым не нашлось замены, остаются за пользователем. Автор запроса должен
+// иметь право менять состав обеих команд.
+func (s *TeamService) MoveUser(ctx context.Context, userID, teamName string, handover bool) (*domain.TeamMove, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if user.DepartedAt != nil {
+		return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed")
+	}
+	if user.TeamName == teamName {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "user is already in team "+teamName)
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	for _, team := range []string{user.TeamName, teamName} {
+		if team == "" {
+			continue
+		}
+		if err := s.authorize(ctx, team); err != nil {
+			return nil, err
+		}
+	}
+
+	move := &domain.TeamMove{UserID: userID, FromTeam: user.TeamName, ToTeam: teamName, Handovers: []domain.ReviewHandover{}}
+
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Замену ищем до перевода, пока пользователь еще числится в старой команде
+		if handover && user.TeamName != "" {
+			handovers, err := s.assignmentSvc.HandOverReviews(ctx, user, true)
+			if err != nil {
+				return err
+			}
+			move.Handovers = handovers
+		}
+		if _, err := s.userRepo.SetUserTeam(ctx, userID, teamName); err != nil {
+			return err
+		}
+		return s.moveRepo.RecordMove(ctx, move)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+	return move, nil
+}
+
+// GetMoves получает историю переходов пользователя
+func (s *TeamService) GetMoves(ctx context.Context, userID string) ([]domain.TeamMove, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return s.moveRepo.GetMovesByUser(ctx, userID)
+}
+
+// GetMemberships получает команды пользователя, основную первой
+func (s *TeamService) GetMemberships(ctx context.Context, userID string) ([]domain.TeamMembership, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return s.membershipRepo.GetMemberships(ctx, userID)
+}
+
+// AddMembership добавляет пользователя в команду как дополнительного участника:
+// он становится кандидатом в ревьюверы PR этой команды
+func (s *TeamService) AddMembership(ctx context.Context, userID, teamName string) ([]domain.TeamMembership, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if user.DepartedAt != nil {
+		return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed")
+	}
+	if user.TeamName == "" {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "user has no primary team; use /users/move")
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+	}
+	if err := s.authorize(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.membershipRepo.AddMembership(ctx, userID, teamName); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+	return s.membershipRepo.GetMemberships(ctx, userID)
+}
+
+// RemoveMembership исключает пользователя из дополнительной команды.
+// Основную команду так не покинуть — для этого есть перевод.
+func (s *TeamService) RemoveMembership(ctx context.Context, userID, teamName string) ([]domain.TeamMembership, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if user.TeamName == teamName {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "cannot leave primary team; use /users/move")
+	}
+	if err := s.authorize(ctx, teamName); err != nil {
+		return nil, err
+	}
+	if err := s.membershipRepo.RemoveMembership(ctx, userID, teamName); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user is not a member of team "+teamName)
+	}
+	return s.membershipRepo.GetMemberships(ctx, userID)
+}
+
+// SetPrimaryTeam делает одну из дополнительных команд пользователя основной;
+// прежняя основная остается дополнительной. Это выбор самого пользователя:
+// за другого его делает только администратор организации.
+func (s *TeamService) SetPrimaryTeam(ctx context.Context, userID, teamName string) ([]domain.TeamMembership, error) {
+	actor, ok := domain.ActorFrom(ctx)
+	if !ok {
+		return nil, domain.NewError(domain.ErrorCodeUnauthorized, "request actor is not authenticated")
+	}
+	if !actor.IsAdmin() && actor.UserID != userID {
+		return nil, domain.NewError(domain.ErrorCodeForbidden, "only the user or an organization admin may change the primary team")
+	}
+	memberships, err := s.GetMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	member := false
+	for _, m := range memberships {
+		if m.TeamName == teamName {
+			if m.IsPrimary {
+				return memberships, nil
+			}
+			member = true
+		}
+	}
+	if !member {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "user is not a member of team "+teamName+"; use /users/move")
+	}
+
+	if err := s.membershipRepo.SetPrimaryTeam(ctx, userID, teamName); err != nil {
+		return nil, err
+	}
+	return s.membershipRepo.GetMemberships(ctx, userID)
+}
+
+// SetRole задает роль участника команды; менять роли может тот же, кто меняет состав
+func (s *TeamService) SetRole(ctx context.Context, teamName, userID, role string) (*domain.Team, error) {
+	if err := domain.ValidateTeamRole(role); err != nil {
+		return nil, err
+	}
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := roles[userID]; !ok {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user is not a member of team "+teamName)
+	}
+	if err := s.authorize(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.membershipRepo.SetRole(ctx, userID, teamName, role); err != nil {
+		return nil, err
+	}
+	// Появившийся или снятый lead меняет кандидатов PR с требованием lead'а
+	s.notifier.Notify()
+	return s.GetTeam(ctx, teamName)
+}
+
+// authorize проверяет, что автор запроса может менять состав команды teamName:
+// это администратор организации или lead/maintainer команды. Без установленной
+// личности автора — UNAUTHORIZED. Команду без lead'ов и maintainer'ов меняет
+// только администратор, он же назначает ей первого lead'а.
+func (s *TeamService) authorize(ctx context.Context, teamName string) error {
+	actor, ok := domain.ActorFrom(ctx)
+	if !ok {
+		return domain.NewError(domain.ErrorCodeUnauthorized, "request actor is not authenticated")
+	}
+	if actor.IsAdmin() {
+		return nil
+	}
+	roles, err := s.membershipRepo.GetTeamRoles(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if domain.CanManageTeam(roles[actor.UserID]) {
+		return nil
+	}
+	return domain.NewError(domain.ErrorCodeForbidden,
+		"only leads and maintainers of team "+teamName+" or an organization admin may change its membership")
+}
+
+// authorizeAdmin пропускает только администратора организации
+func authorizeAdmin(ctx context.Context, action string) error {
+	actor, ok := domain.ActorFrom(ctx)
+	if !ok {
+		return domain.NewError(domain.ErrorCodeUnauthorized, "request actor is not authenticated")
+	}
+	if !actor.IsAdmin() {
+		return domain.NewError(domain.ErrorCodeForbidden, "only an organization admin may "+action)
+	}
+	return nil
+}
+
+// authorizeMoves проверяет право автора запроса забрать участников из их прежних команд
+func (s *TeamService) authorizeMoves(ctx context.Context, moves []domain.TeamMove) error {
+	for _, move := range moves {
+		if move.FromTeam == "" {
+			continue
+		}
+		if err := s.authorize(ctx, move.FromTeam); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRoles задает роли, указанные у участников; без роли участник остается member
+func (s *TeamService) applyRoles(ctx context.Context, teamName string, members []domain.User) error {
+	for _, member := range members {
+		if member.Role == "" {
+			continue
+		}
+		if err := s.membershipRepo.SetRole(ctx, member.UserID, teamName, member.Role); err != nil {
+			return fmt.Errorf("failed to set team role: %w", err)
+		}
+	}
+	return nil
+}
+
+// planMoves находит среди members пользователей, уже состоящих в другой команде
+// или оставшихся без команды, и готовит записи о переходе в teamName
+func (s *TeamService) planMoves(ctx context.Context, teamName string, members []domain.User, allowMove bool) ([]domain.TeamMove, error) {
+	ids := make([]string, len(members))
+	for i, member := range members {
+		ids[i] = member.UserID
+	}
+	existing, err := s.userRepo.GetAllUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []domain.TeamMove
+	for _, user := range existing {
+		if user.DepartedAt != nil {
+			return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed: "+user.UserID)
+		}
+		if user.TeamName == teamName {
+			continue
+		}
+		if user.TeamName != "" && !allowMove {
+			return nil, domain.NewError(domain.ErrorCodeUserInTeam,
+				fmt.Sprintf("user %s is in team %s; set allow_move, use /users/move or add a secondary team via /users/addTeam", user.UserID, user.TeamName))
+		}
+		moves = append(moves, domain.TeamMove{
+			UserID:    user.UserID,
+			FromTeam:  user.TeamName,
+			ToTeam:    teamName,
+			Handovers: []domain.ReviewHandover{},
+		})
+	}
+	return moves, nil
+}
+
+// recordMoves сохраняет переходы, совершенные через состав команды
+func (s *TeamService) recordMoves(ctx context.Context, moves []domain.TeamMove) error {
+	for i := range moves {
+		if err := s.moveRepo.RecordMove(ctx, &moves[i]); err != nil {
+			return fmt.Errorf("failed to record team move: %w", err)
+		}
+	}
+	return nil
+}
+
+// mergeMembers переносит в участников, уже заведенных в системе, текущие
+// значения полей, которые запрос не указал: повторное добавление в команду
+// не сбрасывает часовой пояс, часы работы, навыки, уровень и email
+func (s *TeamService) mergeMembers(ctx context.Context, members []domain.User) error {
+	ids := make([]string, len(members))
+	for i, member := range members {
+		ids[i] = member.UserID
+	}
+	existing, err := s.userRepo.GetAllUsersByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]domain.User, len(existing))
+	for _, user := range existing {
+		current[user.UserID] = user
+	}
+
+	for i := range members {
+		member := &members[i]
+		user, ok := current[member.UserID]
+		if !ok {
+			continue
+		}
+		if member.Timezone == "" {
+			member.Timezone = user.Timezone
+		}
+		if member.WorkStartHour == domain.UnsetWorkHour {
+			member.WorkStartHour = user.WorkStartHour
+		}
+		if member.WorkEndHour == domain.UnsetWorkHour {
+			member.WorkEndHour = user.WorkEndHour
+		}
+		if member.Skills == nil {
+			member.Skills = user.Skills
+		}
+		if member.Seniority == "" {
+			member.Seniority = user.Seniority
+		}
+		if member.Email == "" {
+			member.Email = user.Email
+		}
+	}
+	return nil
+}
+
+// normalizeMember заполняет значения по умолчанию и проверяет участника команды
+func normalizeMember(member *domain.User) error {
+	if member.Timezone == "" {
+		member.Timezone = domain.DefaultTimezone
+	}
+	if member.WorkStartHour == domain.UnsetWorkHour {
+		member.WorkStartHour = domain.DefaultWorkStartHour
+	}
+	if member.WorkEndHour == domain.UnsetWorkHour {
+		member.WorkEndHour = domain.DefaultWorkEndHour
+	}
+	member.Skills = domain.NormalizeTags(member.Skills)
+	if member.Seniority == "" {
+		member.Seniority = domain.SeniorityMiddle
+	}
+	if err := domain.ValidateSeniority(member.Seniority); err != nil {
+		return err
+	}
+	if member.Role != "" {
+		if err := domain.ValidateTeamRole(member.Role); err != nil {
+			return err
+		}
+	}
+	return domain.ValidateWorkingHours(member.Timezone, member.WorkStartHour, member.WorkEndHour)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubMembershipRepo возвращает заданные роли участников любой команды
+type stubMembershipRepo struct {
+	repo.MembershipRepository
+	roles map[string]string
+}
+
+func (r stubMembershipRepo) GetTeamRoles(context.Context, string) (map[string]string, error) {
+	return r.roles, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	led := map[string]string{"l1": domain.TeamRoleLead, "m1": domain.TeamRoleMember}
+	unmanaged := map[string]string{"m1": domain.TeamRoleMember}
+
+	tests := []struct {
+		name  string
+		roles map[string]string
+		actor *domain.Actor
+		code  domain.ErrorCode // пустой — изменение разрешено
+	}{
+		{name: "no actor", roles: unmanaged, actor: nil, code: domain.ErrorCodeUnauthorized},
+		{name: "admin", roles: led, actor: &domain.Actor{}},
+		{name: "lead", roles: led, actor: &domain.Actor{UserID: "l1"}},
+		{name: "member of led team", roles: led, actor: &domain.Actor{UserID: "m1"}, code: domain.ErrorCodeForbidden},
+		{name: "member of unmanaged team", roles: unmanaged, actor: &domain.Actor{UserID: "m1"}, code: domain.ErrorCodeForbidden},
+		{name: "outsider", roles: unmanaged, actor: &domain.Actor{UserID: "x1"}, code: domain.ErrorCodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTeamService(nil, nil, nil, nil, stubMembershipRepo{roles: tt.roles}, nil, nil, nil)
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = domain.WithActor(ctx, *tt.actor)
+			}
+
+			err := svc.authorize(ctx, "core")
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+			domErr, ok := err.(domain.DomainError)
+			require.True(t, ok, "expected domain error, got %v", err)
+			assert.Equal(t, tt.code, domErr.Code)
+		})
+	}
+}
@@ -0,0 +1,548 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
+	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
+	"gopkg.in/yaml.v3"
+)
+
+// TeamConfig — декларативное описание команд, например оргструктура из git.
+// Команды, которых нет в конфигурации, синхронизация не трогает.
+type TeamConfig struct {
+	Teams []TeamSpec `json:"teams"`
+}
+
+// TeamSpec — команда в конфигурации. Не указанные поля политики получают
+// значения по умолчанию, как при создании команды через API.
+type TeamSpec struct {
+	Name    string          `json:"name"`
+	Parent  string          `json:"parent"`
+	Policy  json.RawMessage `json:"policy"`
+	Members []MemberSpec    `json:"members"`
+
+	policy domain.TeamPolicy
+}
+
+// MemberSpec — участник команды в конфигурации. Пользователь может быть указан
+// в нескольких командах: атрибуты берутся из записи основной команды, в
+// остальных достаточно user_id и роли.
+type MemberSpec struct {
+	UserID        string   `json:"user_id"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	Active        *bool    `json:"active"` // по умолчанию true
+	Timezone      string   `json:"timezone"`
+	WorkStartHour *int     `json:"work_start_hour"`
+	WorkEndHour   *int     `json:"work_end_hour"`
+	Skills        []string `json:"skills"`
+	Seniority     string   `json:"seniority"`
+	Role          string   `json:"role"`    // роль в этой команде; по умолчанию member
+	Primary       bool     `json:"primary"` // основная команда; по умолчанию первая, где указан пользователь
+}
+
+// toUser конвертирует участника в domain model; не указанные поля — по умолчанию
+func (m MemberSpec) toUser() domain.User {
+	user := domain.User{
+		UserID:        m.UserID,
+		Username:      m.Username,
+		Email:         m.Email,
+		IsActive:      true,
+		Timezone:      m.Timezone,
+		WorkStartHour: domain.DefaultWorkStartHour,
+		WorkEndHour:   domain.DefaultWorkEndHour,
+		Skills:        m.Skills,
+		Seniority:     m.Seniority,
+	}
+	if m.Active != nil {
+		user.IsActive = *m.Active
+	}
+	if m.WorkStartHour != nil {
+		user.WorkStartHour = *m.WorkStartHour
+	}
+	if m.WorkEndHour != nil {
+		user.WorkEndHour = *m.WorkEndHour
+	}
+	return user
+}
+
+// ParseTeamConfig читает конфигурацию команд в YAML (JSON тоже подходит)
+func ParseTeamConfig(r io.Reader) (*TeamConfig, error) {
+	// YAML переводим в JSON, чтобы поля назывались так же, как в API
+	var raw interface{}
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, domain.NewError(domain.ErrorCodeInvalidInput, "team config is empty")
+		}
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "invalid team config: "+err.Error())
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "invalid team config: "+err.Error())
+	}
+
+	cfg := &TeamConfig{}
+	if err := decodeStrict(data, cfg); err != nil {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "invalid team config: "+err.Error())
+	}
+	for i := range cfg.Teams {
+		team := &cfg.Teams[i]
+		team.policy = domain.DefaultTeamPolicy()
+		if len(team.Policy) > 0 && string(team.Policy) != "null" {
+			if err := decodeStrict(team.Policy, &team.policy); err != nil {
+				return nil, domain.NewError(domain.ErrorCodeInvalidInput,
+					fmt.Sprintf("invalid policy of team %s: %v", team.Name, err))
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// decodeStrict разбирает JSON, отвергая неизвестные поля — опечатка в
+// конфигурации не должна молча превращаться в значение по умолчанию
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// TeamSyncService приводит команды в БД к декларативной конфигурации
+type TeamSyncService struct {
+	teamRepo       repo.TeamRepository
+	userRepo       repo.UserRepository
+	membershipRepo repo.MembershipRepository
+	moveRepo       repo.MoveRepository
+	notifier       StaffingNotifier
+}
+
+// NewTeamSyncService создает сервис синхронизации команд
+func NewTeamSyncService(
+	teamRepo repo.TeamRepository,
+	userRepo repo.UserRepository,
+	membershipRepo repo.MembershipRepository,
+	moveRepo repo.MoveRepository,
+	notifier StaffingNotifier,
+) *TeamSyncService {
+	return &TeamSyncService{
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		moveRepo:       moveRepo,
+		notifier:       notifier,
+	}
+}
+
+// syncStep — изменение плана и его применение
+type syncStep struct {
+	change domain.TeamSyncChange
+	apply  func(ctx context.Context) error
+}
+
+// desiredUser — пользователь, каким его описывает конфигурация
+type desiredUser struct {
+	user  domain.User // атрибуты; TeamName — основная команда
+	teams []string    // все команды пользователя в порядке конфигурации
+	roles map[string]string
+}
+
+// Plan сравнивает конфигурацию с БД и возвращает изменения, не применяя их
+func (s *TeamSyncService) Plan(ctx context.Context, cfg *TeamConfig) (*domain.TeamSyncPlan, error) {
+	steps, err := s.plan(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return syncPlan(steps, false), nil
+}
+
+// Apply применяет изменения плана по порядку. Изменения не атомарны: при ошибке
+// примененная часть остается, а повторный запуск доделывает остальное.
+// Ревью переведенных пользователей за ними остаются. Синхронизация меняет
+// любые команды и переводит пользователей между ними, поэтому применять ее
+// может только администратор организации.
+func (s *TeamSyncService) Apply(ctx context.Context, cfg *TeamConfig) (*domain.TeamSyncPlan, error) {
+	if err := authorizeAdmin(ctx, "apply a team sync"); err != nil {
+		return nil, err
+	}
+	steps, err := s.plan(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if err := step.apply(ctx); err != nil {
+			return nil, fmt.Errorf("%s %s %s: %w", step.change.Action, step.change.TeamName, step.change.UserID, err)
+		}
+	}
+	if len(steps) > 0 {
+		s.notifier.Notify()
+	}
+	return syncPlan(steps, true), nil
+}
+
+// plan проверяет конфигурацию и строит шаги в порядке применения: команды,
+// их политики и иерархия, затем пользователи, членства и роли
+func (s *TeamSyncService) plan(ctx context.Context, cfg *TeamConfig) ([]syncStep, error) {
+	users, order, err := s.validate(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var creates, policies, parents, members, removals, addSecondary, removeSecondary, roles []syncStep
+	managed := make(map[string]bool, len(cfg.Teams))
+	currentRoles := make(map[string]map[string]string, len(cfg.Teams))
+
+	for _, spec := range cfg.Teams {
+		managed[spec.Name] = true
+		policy := spec.policy
+
+		exists, err := s.teamRepo.TeamExists(ctx, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			creates = append(creates, syncStep{
+				change: domain.TeamSyncChange{Action: domain.SyncCreateTeam, TeamName: spec.Name},
+				apply: func(ctx context.Context) error {
+					return s.teamRepo.CreateTeam(ctx, &domain.Team{TeamName: spec.Name, Policy: policy})
+				},
+			})
+			if spec.Parent != "" {
+				parents = append(parents, s.setParentStep(spec.Name, "", spec.Parent))
+			}
+			continue
+		}
+
+		current, err := s.teamRepo.GetTeamByName(ctx, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if fields := changedFields(current.Policy, policy); len(fields) > 0 {
+			policies = append(policies, syncStep{
+				change: domain.TeamSyncChange{Action: domain.SyncUpdatePolicy, TeamName: spec.Name, Detail: strings.Join(fields, ", ")},
+				apply: func(ctx context.Context) error {
+					return s.teamRepo.UpdateTeamPolicy(ctx, spec.Name, &policy)
+				},
+			})
+		}
+		if current.ParentTeam != spec.Parent {
+			parents = append(parents, s.setParentStep(spec.Name, current.ParentTeam, spec.Parent))
+		}
+
+		if currentRoles[spec.Name], err = s.membershipRepo.GetTeamRoles(ctx, spec.Name); err != nil {
+			return nil, err
+		}
+
+		// Участники, которых нет в конфигурации, покидают команду
+		for _, member := range current.Members {
+			if _, ok := users[member.UserID]; ok {
+				continue
+			}
+			if member.TeamName == spec.Name {
+				removals = append(removals, syncStep{
+					change: domain.TeamSyncChange{Action: domain.SyncRemoveMember, TeamName: spec.Name, UserID: member.UserID},
+					apply: func(ctx context.Context) error {
+						return s.teamRepo.RemoveTeamMembers(ctx, spec.Name, []string{member.UserID})
+					},
+				})
+			} else {
+				removeSecondary = append(removeSecondary, s.removeSecondaryStep(member.UserID, spec.Name))
+			}
+		}
+	}
+
+	existing, err := s.userRepo.GetAllUsersByIDs(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	currentUsers := make(map[string]*domain.User, len(existing))
+	for i := range existing {
+		currentUsers[existing[i].UserID] = &existing[i]
+	}
+
+	for _, userID := range order {
+		desired := users[userID]
+		user := desired.user
+		primary := user.TeamName
+		current := currentUsers[userID]
+
+		// Членства до синхронизации: команда → основная ли
+		held := make(map[string]bool)
+		moved := true
+		switch {
+		case current == nil:
+			members = append(members, s.writeUserStep(domain.SyncAddMember, user, "", false, "new user"))
+		case current.DepartedAt != nil:
+			return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed: "+userID)
+		default:
+			memberships, err := s.membershipRepo.GetMemberships(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range memberships {
+				held[m.TeamName] = m.IsPrimary
+			}
+
+			switch {
+			case current.TeamName == "":
+				members = append(members, s.writeUserStep(domain.SyncAddMember, user, "", true, ""))
+			case current.TeamName != primary:
+				members = append(members, s.writeUserStep(domain.SyncMoveMember, user, current.TeamName, true,
+					current.TeamName+" → "+primary))
+			default:
+				moved = false
+				if fields := changedFields(syncedAttrsOf(*current), syncedAttrsOf(user)); len(fields) > 0 {
+					members = append(members, s.writeUserStep(domain.SyncUpdateMember, user, "", false, strings.Join(fields, ", ")))
+				}
+			}
+		}
+
+		for _, teamName := range desired.teams {
+			isPrimary, isMember := held[teamName]
+			// Прежнюю основную команду при переводе триггер удаляет — членство создается заново
+			fresh := !isMember || (isPrimary && moved)
+			if teamName != primary && fresh {
+				addSecondary = append(addSecondary, syncStep{
+					change: domain.TeamSyncChange{Action: domain.SyncAddSecondary, TeamName: teamName, UserID: userID},
+					apply: func(ctx context.Context) error {
+						return s.membershipRepo.AddMembership(ctx, userID, teamName)
+					},
+				})
+			}
+
+			currentRole := currentRoles[teamName][userID]
+			if fresh {
+				currentRole = domain.TeamRoleMember
+			}
+			if role := desired.roles[teamName]; role != currentRole {
+				roles = append(roles, syncStep{
+					change: domain.TeamSyncChange{Action: domain.SyncSetRole, TeamName: teamName, UserID: userID,
+						Detail: currentRole + " → " + role},
+					apply: func(ctx context.Context) error {
+						return s.membershipRepo.SetRole(ctx, userID, teamName, role)
+					},
+				})
+			}
+		}
+		heldTeams := make([]string, 0, len(held))
+		for teamName := range held {
+			heldTeams = append(heldTeams, teamName)
+		}
+		sort.Strings(heldTeams)
+		for _, teamName := range heldTeams {
+			if held[teamName] || teamName == primary || !managed[teamName] || desired.roles[teamName] != "" {
+				continue
+			}
+			removeSecondary = append(removeSecondary, s.removeSecondaryStep(userID, teamName))
+		}
+	}
+
+	var steps []syncStep
+	for _, group := range [][]syncStep{creates, policies, parents, members, removals, addSecondary, removeSecondary, roles} {
+		steps = append(steps, group...)
+	}
+	return steps, nil
+}
+
+// validate проверяет конфигурацию и собирает желаемое состояние пользователей;
+// order — ID пользователей в порядке первого упоминания
+func (s *TeamSyncService) validate(ctx context.Context, cfg *TeamConfig) (map[string]*desiredUser, []string, error) {
+	specs := make(map[string]*TeamSpec, len(cfg.Teams))
+	for i := range cfg.Teams {
+		spec := &cfg.Teams[i]
+		if spec.Name == "" {
+			return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "team name is required")
+		}
+		if specs[spec.Name] != nil {
+			return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "team is listed twice: "+spec.Name)
+		}
+		if err := spec.policy.Validate(); err != nil {
+			return nil, nil, err
+		}
+		specs[spec.Name] = spec
+	}
+
+	// Родитель — команда из конфигурации или уже существующая; циклы запрещены
+	for _, spec := range cfg.Teams {
+		seen := map[string]bool{spec.Name: true}
+		for parent := spec.Parent; parent != ""; {
+			if seen[parent] {
+				return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "team hierarchy has a cycle through "+spec.Name)
+			}
+			seen[parent] = true
+			if next, ok := specs[parent]; ok {
+				parent = next.Parent
+				continue
+			}
+			exists, err := s.teamRepo.TeamExists(ctx, parent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !exists {
+				return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput,
+					fmt.Sprintf("parent team of %s not found: %s", spec.Name, parent))
+			}
+			ancestors, err := s.teamRepo.GetTeamAncestors(ctx, parent)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, ancestor := range ancestors {
+				if seen[ancestor] {
+					return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "team hierarchy has a cycle through "+spec.Name)
+				}
+			}
+			break
+		}
+	}
+
+	users := make(map[string]*desiredUser)
+	var order []string
+	explicitPrimary := make(map[string]bool)
+	for _, spec := range cfg.Teams {
+		for _, member := range spec.Members {
+			if member.UserID == "" {
+				return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput, "user_id is required in team "+spec.Name)
+			}
+			role := member.Role
+			if role == "" {
+				role = domain.TeamRoleMember
+			}
+			if err := domain.ValidateTeamRole(role); err != nil {
+				return nil, nil, err
+			}
+
+			desired, ok := users[member.UserID]
+			if !ok {
+				desired = &desiredUser{roles: make(map[string]string)}
+				users[member.UserID] = desired
+				order = append(order, member.UserID)
+			}
+			if desired.roles[spec.Name] != "" {
+				return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput,
+					fmt.Sprintf("user %s is listed twice in team %s", member.UserID, spec.Name))
+			}
+			if member.Primary && explicitPrimary[member.UserID] {
+				return nil, nil, domain.NewError(domain.ErrorCodeInvalidInput,
+					"user has several primary teams: "+member.UserID)
+			}
+			desired.teams = append(desired.teams, spec.Name)
+			desired.roles[spec.Name] = role
+
+			if !ok || (member.Primary && !explicitPrimary[member.UserID]) {
+				user := member.toUser()
+				if err := normalizeMember(&user); err != nil {
+					return nil, nil, err
+				}
+				user.TeamName = spec.Name
+				desired.user = user
+			}
+			if member.Primary {
+				explicitPrimary[member.UserID] = true
+			}
+		}
+	}
+	return users, order, nil
+}
+
+// setParentStep меняет родительскую команду с from на to
+func (s *TeamSyncService) setParentStep(teamName, from, to string) syncStep {
+	return syncStep{
+		change: domain.TeamSyncChange{Action: domain.SyncSetParent, TeamName: teamName,
+			Detail: orNone(from) + " → " + orNone(to)},
+		apply: func(ctx context.Context) error {
+			return s.teamRepo.SetParentTeam(ctx, teamName, to)
+		},
+	}
+}
+
+// removeSecondaryStep исключает пользователя из дополнительной команды
+func (s *TeamSyncService) removeSecondaryStep(userID, teamName string) syncStep {
+	return syncStep{
+		change: domain.TeamSyncChange{Action: domain.SyncRemoveSecondary, TeamName: teamName, UserID: userID},
+		apply: func(ctx context.Context) error {
+			return s.membershipRepo.RemoveMembership(ctx, userID, teamName)
+		},
+	}
+}
+
+// writeUserStep сохраняет пользователя с основной командой user.TeamName;
+// при смене команды (recordMove) записывает переход из fromTeam
+func (s *TeamSyncService) writeUserStep(action string, user domain.User, fromTeam string, recordMove bool, detail string) syncStep {
+	return syncStep{
+		change: domain.TeamSyncChange{Action: action, TeamName: user.TeamName, UserID: user.UserID, Detail: detail},
+		apply: func(ctx context.Context) error {
+			if err := s.userRepo.CreateOrUpdateUser(ctx, &user); err != nil {
+				return err
+			}
+			if !recordMove {
+				return nil
+			}
+			return s.moveRepo.RecordMove(ctx, &domain.TeamMove{
+				UserID:    user.UserID,
+				FromTeam:  fromTeam,
+				ToTeam:    user.TeamName,
+				Handovers: []domain.ReviewHandover{},
+			})
+		},
+	}
+}
+
+// syncedAttrs — атрибуты пользователя, которыми управляет конфигурация
+type syncedAttrs struct {
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	IsActive      bool     `json:"is_active"`
+	Timezone      string   `json:"timezone"`
+	WorkStartHour int      `json:"work_start_hour"`
+	WorkEndHour   int      `json:"work_end_hour"`
+	Skills        []string `json:"skills"`
+	Seniority     string   `json:"seniority"`
+}
+
+func syncedAttrsOf(user domain.User) syncedAttrs {
+	return syncedAttrs{
+		Username:      user.Username,
+		Email:         user.Email,
+		IsActive:      user.IsActive,
+		Timezone:      user.Timezone,
+		WorkStartHour: user.WorkStartHour,
+		WorkEndHour:   user.WorkEndHour,
+		Skills:        domain.NormalizeTags(user.Skills),
+		Seniority:     user.Seniority,
+	}
+}
+
+// changedFields возвращает JSON-имена полей, которыми различаются структуры a и b одного типа
+func changedFields(a, b interface{}) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, strings.Split(va.Type().Field(i).Tag.Get("json"), ",")[0])
+		}
+	}
+	return fields
+}
+
+func orNone(teamName string) string {
+	if teamName == "" {
+		return "(none)"
+	}
+	return teamName
+}
+
+// syncPlan собирает изменения шагов в план
+func syncPlan(steps []syncStep, applied bool) *domain.TeamSyncPlan {
+	plan := &domain.TeamSyncPlan{Changes: make([]domain.TeamSyncChange, len(steps)), Applied: applied}
+	for i, step := range steps {
+		plan.Changes[i] = step.change
+	}
+	return plan
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/domain"
 	"github.com/Horronyt/PR-reviewers-assignment-service/internal/repo"
@@ -9,38 +10,282 @@ import (
 
 // UserService сервис для работы с пользователями
 type UserService struct {
-	userRepo  repo.UserRepository
-	statsRepo repo.StatsRepository
+	userRepo      repo.UserRepository
+	statsRepo     repo.StatsRepository
+	prRepo        repo.PRRepository
+	queueRepo     repo.StaffingQueueRepository
+	transactor    repo.Transactor
+	assignmentSvc *ReviewerAssignmentService
+	notifier      StaffingNotifier
 }
 
 // NewUserService создает новый сервис пользователей
-func NewUserService(userRepo repo.UserRepository, statsRepo repo.StatsRepository) *UserService {
+func NewUserService(
+	userRepo repo.UserRepository,
+	statsRepo repo.StatsRepository,
+	prRepo repo.PRRepository,
+	queueRepo repo.StaffingQueueRepository,
+	transactor repo.Transactor,
+	assignmentSvc *ReviewerAssignmentService,
+	notifier StaffingNotifier,
+) *UserService {
 	return &UserService{
-		userRepo:  userRepo,
-		statsRepo: statsRepo,
+		userRepo:      userRepo,
+		statsRepo:     statsRepo,
+		prRepo:        prRepo,
+		queueRepo:     queueRepo,
+		transactor:    transactor,
+		assignmentSvc: assignmentSvc,
+		notifier:      notifier,
 	}
 }
 
-// SetActive устанавливает флаг активности пользователя
-func (s *UserService) SetActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+// SetActive устанавливает флаг активности пользователя. При деактивации его
+// открытые ревью переназначаются на коллег; ревью, которым не нашлось замены,
+// остаются за ним и возвращаются с пустым ReplacedBy.
+func (s *UserService) SetActive(ctx context.Context, userID string, isActive bool) (*domain.User, []domain.ReviewHandover, error) {
+	current, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if isActive && current.DepartedAt != nil {
+		return nil, nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has departed")
+	}
+
 	user, err := s.userRepo.SetUserActive(ctx, userID, isActive)
+	if err != nil {
+		return nil, nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	handovers := []domain.ReviewHandover{}
+	if !isActive && current.IsActive {
+		if handovers, err = s.assignmentSvc.HandOverReviews(ctx, user, false); err != nil {
+			return nil, nil, err
+		}
+	}
+	if isActive {
+		s.notifier.Notify()
+	}
+	return user, handovers, nil
+}
+
+// SetWorkingHours задает часовой пояс и рабочее окно пользователя
+func (s *UserService) SetWorkingHours(ctx context.Context, userID, timezone string, startHour, endHour int) (*domain.User, error) {
+	if err := domain.ValidateWorkingHours(timezone, startHour, endHour); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.SetUserWorkingHours(ctx, userID, timezone, startHour, endHour)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return user, nil
+}
+
+// SetMaxOpenReviews задает персональный лимит открытых ревью (nil — значение команды)
+func (s *UserService) SetMaxOpenReviews(ctx context.Context, userID string, maxOpenReviews *int) (*domain.User, error) {
+	if maxOpenReviews != nil && *maxOpenReviews < 0 {
+		return nil, domain.NewError(domain.ErrorCodeInvalidInput, "max_open_reviews must not be negative")
+	}
+	user, err := s.userRepo.SetUserMaxOpenReviews(ctx, userID, maxOpenReviews)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	s.notifier.Notify()
+	return user, nil
+}
+
+// SetSkills заменяет навыки пользователя
+func (s *UserService) SetSkills(ctx context.Context, userID string, skills []string) (*domain.User, error) {
+	user, err := s.userRepo.SetUserSkills(ctx, userID, domain.NormalizeTags(skills))
 	if err != nil {
 		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
 	}
 	return user, nil
 }
 
+// SetSeniority задает уровень пользователя
+func (s *UserService) SetSeniority(ctx context.Context, userID, seniority string) (*domain.User, error) {
+	if err := domain.ValidateSeniority(seniority); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.SetUserSeniority(ctx, userID, seniority)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	return user, nil
+}
+
+// Offboard оформляет уход пользователя. Его открытые ревью переназначаются
+// на коллег; ревью без замены снимаются, а PR уходят в очередь добора.
+// Пользователь деактивируется и исключается из команды, но не удаляется:
+// PR, ревью и статистика сохраняются. При anonymize стираются имя, email
+// и внешние учетные записи.
+// Повторный вызов для ушедшего пользователя только анонимизирует его.
+// Уход оформляется одной транзакцией; пользователь деактивируется до передачи
+// ревью, чтобы не получить новых назначений.
+func (s *UserService) Offboard(ctx context.Context, userID string, anonymize bool) (*domain.Offboarding, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrorCodeNotFound, "user not found")
+	}
+	if user.DepartedAt != nil && !anonymize {
+		return nil, domain.NewError(domain.ErrorCodeUserDeparted, "user has already departed")
+	}
+
+	result := &domain.Offboarding{Handovers: []domain.ReviewHandover{}, Anonymized: anonymize}
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if user.DepartedAt == nil {
+			// Команду оставляем до передачи ревью: замена ищется среди ее участников
+			if _, err := s.userRepo.SetUserActive(ctx, userID, false); err != nil {
+				return err
+			}
+			handovers, err := s.releaseReviews(ctx, user)
+			if err != nil {
+				return err
+			}
+			result.Handovers = handovers
+			if user, err = s.userRepo.SetUserDeparted(ctx, userID, time.Now()); err != nil {
+				return err
+			}
+		}
+		if anonymize {
+			var err error
+			if user, err = s.userRepo.AnonymizeUser(ctx, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.User = user
+	s.notifier.Notify()
+	return result, nil
+}
+
+// releaseReviews передает открытые ревью уходящего пользователя коллегам
+// и снимает его с оставшихся открытых PR
+func (s *UserService) releaseReviews(ctx context.Context, user *domain.User) ([]domain.ReviewHandover, error) {
+	handovers, err := s.assignmentSvc.HandOverReviews(ctx, user, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Остались ревью без замены и «теневые» назначения
+	prs, err := s.prRepo.GetPRsByReviewer(ctx, user.UserID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range prs {
+		if summary.Status != domain.PRStatusOpen {
+			continue
+		}
+		pr, err := s.prRepo.GetPRByID(ctx, summary.Repository, summary.PullRequestID)
+		if err != nil {
+			return nil, err
+		}
+		released, err := s.assignmentSvc.ReleaseReviewer(ctx, pr, user.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !released {
+			continue
+		}
+		required, err := s.assignmentSvc.RequiredReviewers(ctx, pr)
+		if err != nil {
+			return nil, err
+		}
+		missing := required - len(pr.AssignedReviewers)
+		if missing <= 0 {
+			continue
+		}
+		if err := s.queueRepo.Enqueue(ctx, pr.Repository, pr.PullRequestID, missing, domain.ErrorCodeNoCandidate); err != nil {
+			return nil, err
+		}
+	}
+	return handovers, nil
+}
+
 // GetUser получает пользователя по ID
 func (s *UserService) GetUser(ctx context.Context, userID string) (*domain.User, error) {
 	return s.userRepo.GetUserByID(ctx, userID)
 }
 
-// GetReviewerStats получает статистику ревьюверов
-func (s *UserService) GetReviewerStats(ctx context.Context) ([]repo.ReviewerStats, error) {
-	return s.statsRepo.GetReviewerStats(ctx)
+// GetReviewerStats получает статистику ревьюверов; непустой repository
+// оставляет только назначения на PR этого репозитория
+func (s *UserService) GetReviewerStats(ctx context.Context, repository string) ([]repo.ReviewerStats, error) {
+	return s.statsRepo.GetReviewerStats(ctx, repository)
+}
+
+// TeamStatsRollup — статистика команды: собственная и вместе со всеми подкомандами
+type TeamStatsRollup struct {
+	Own   repo.TeamStats
+	Total repo.TeamStats
+}
+
+// GetTeamStats получает статистику команд, просуммированную вверх по иерархии.
+// Если teamName задан — только по этой команде и ее поддереву, если
+// repository — только по PR этого репозитория.
+func (s *UserService) GetTeamStats(ctx context.Context, teamName, repository string) ([]TeamStatsRollup, error) {
+	stats, err := s.statsRepo.GetTeamStats(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]repo.TeamStats, len(stats))
+	children := make(map[string][]string)
+	for _, st := range stats {
+		byName[st.TeamName] = st
+		if st.ParentTeam != "" {
+			children[st.ParentTeam] = append(children[st.ParentTeam], st.TeamName)
+		}
+	}
+	if teamName != "" {
+		if _, ok := byName[teamName]; !ok {
+			return nil, domain.NewError(domain.ErrorCodeNotFound, "team not found")
+		}
+	}
+
+	// subtree возвращает команду и всех ее потомков
+	var subtree func(name string, seen map[string]bool) []string
+	subtree = func(name string, seen map[string]bool) []string {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+		names := []string{name}
+		for _, child := range children[name] {
+			names = append(names, subtree(child, seen)...)
+		}
+		return names
+	}
+
+	var selected []string
+	if teamName != "" {
+		selected = subtree(teamName, make(map[string]bool))
+	} else {
+		for _, st := range stats {
+			selected = append(selected, st.TeamName)
+		}
+	}
+
+	result := make([]TeamStatsRollup, 0, len(selected))
+	for _, name := range selected {
+		own := byName[name]
+		total := repo.TeamStats{TeamName: own.TeamName, ParentTeam: own.ParentTeam}
+		for _, member := range subtree(name, make(map[string]bool)) {
+			st := byName[member]
+			total.Members += st.Members
+			total.AssignmentCount += st.AssignmentCount
+			total.AuthoredPRs += st.AuthoredPRs
+			total.OpenPRs += st.OpenPRs
+		}
+		result = append(result, TeamStatsRollup{Own: own, Total: total})
+	}
+	return result, nil
 }
 
-// GetPRStats получает статистику PR
-func (s *UserService) GetPRStats(ctx context.Context) (map[string]int, error) {
-	return s.statsRepo.GetPRStats(ctx)
+// GetPRStats получает статистику PR, при непустом repository — по одному репозиторию
+func (s *UserService) GetPRStats(ctx context.Context, repository string) (map[string]int, error) {
+	return s.statsRepo.GetPRStats(ctx, repository)
 }
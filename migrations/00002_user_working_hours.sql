@@ -0,0 +1,21 @@
+-- migrations/00002_user_working_hours.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Часовой пояс и рабочее окно пользователя (локальные часы, конец не включительно)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS timezone        VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN IF NOT EXISTS work_start_hour SMALLINT    NOT NULL DEFAULT 9  CHECK (work_start_hour BETWEEN 0 AND 23),
+    ADD COLUMN IF NOT EXISTS work_end_hour   SMALLINT    NOT NULL DEFAULT 18 CHECK (work_end_hour BETWEEN 0 AND 24);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS work_end_hour,
+    DROP COLUMN IF EXISTS work_start_hour,
+    DROP COLUMN IF EXISTS timezone;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- migrations/00003_review_capacity.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Лимит одновременно открытых ревью: персональный (NULL — брать из команды)
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER NULL CHECK (max_open_reviews >= 0);
+
+-- Значение по умолчанию для команды (NULL — без ограничения)
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS default_max_open_reviews INTEGER NULL CHECK (default_max_open_reviews >= 0);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE teams DROP COLUMN IF EXISTS default_max_open_reviews;
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- migrations/00004_staffing_queue.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Очередь PR, которым при создании не хватило ревьюверов
+CREATE TABLE IF NOT EXISTS pr_staffing_queue (
+    pull_request_id   VARCHAR(255) PRIMARY KEY REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    missing_reviewers INTEGER      NOT NULL CHECK (missing_reviewers > 0),
+    reason            VARCHAR(50)  NOT NULL,
+    attempts          INTEGER      NOT NULL DEFAULT 0,
+    enqueued_at       TIMESTAMP    NOT NULL DEFAULT NOW(),
+    last_attempt_at   TIMESTAMP    NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_staffing_queue_enqueued ON pr_staffing_queue(enqueued_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_staffing_queue_enqueued;
+DROP TABLE IF EXISTS pr_staffing_queue;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- migrations/00005_skills_and_labels.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Навыки пользователей и метки PR для подбора ревьюверов по совпадению
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS skills TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+
+-- Стратегия ранжирования кандидатов в команде
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS assignment_strategy VARCHAR(50) NOT NULL DEFAULT 'random';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE teams DROP COLUMN IF EXISTS assignment_strategy;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS labels;
+ALTER TABLE users DROP COLUMN IF EXISTS skills;
+
+-- +goose StatementEnd
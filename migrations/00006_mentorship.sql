@@ -0,0 +1,30 @@
+-- migrations/00006_mentorship.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Уровень пользователя для политики наставничества
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS seniority VARCHAR(20) NOT NULL DEFAULT 'middle'
+        CHECK (seniority IN ('intern', 'junior', 'middle', 'senior'));
+
+-- Политика команды: обязательный senior и «теневой» junior
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS require_senior BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS shadow_junior  BOOLEAN NOT NULL DEFAULT false;
+
+-- «Теневые» ревьюверы: назначены для обучения, апрув не требуется
+ALTER TABLE pr_reviewers
+    ADD COLUMN IF NOT EXISTS is_shadow BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS is_shadow;
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS shadow_junior,
+    DROP COLUMN IF EXISTS require_senior;
+ALTER TABLE users DROP COLUMN IF EXISTS seniority;
+
+-- +goose StatementEnd
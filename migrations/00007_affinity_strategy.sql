@@ -0,0 +1,23 @@
+-- migrations/00007_affinity_strategy.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Настройки стратегии affinity: окно истории и вес штрафа за повторные пары
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS affinity_window_days INTEGER          NOT NULL DEFAULT 30  CHECK (affinity_window_days > 0),
+    ADD COLUMN IF NOT EXISTS affinity_weight      DOUBLE PRECISION NOT NULL DEFAULT 1.0 CHECK (affinity_weight >= 0);
+
+-- Поиск истории пар автор–ревьювер
+CREATE INDEX IF NOT EXISTS idx_prs_author_created ON pull_requests(author_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_prs_author_created;
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS affinity_weight,
+    DROP COLUMN IF EXISTS affinity_window_days;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- migrations/00008_exclusion_rules.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Измененные файлы PR — для правил, завязанных на пути
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS paths TEXT[] NOT NULL DEFAULT '{}';
+
+-- Правила исключения ревьюверов
+CREATE TABLE IF NOT EXISTS assignment_rules (
+    rule_id    BIGSERIAL    PRIMARY KEY,
+    team_name  VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    kind       VARCHAR(50)  NOT NULL CHECK (kind IN ('reviewer_author', 'user_path', 'seniority_path')),
+    subject    VARCHAR(255) NOT NULL,
+    target     TEXT         NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    UNIQUE (team_name, kind, subject, target)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_assignment_rules_team ON assignment_rules(team_name);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_assignment_rules_team;
+DROP TABLE IF EXISTS assignment_rules;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS paths;
+
+-- +goose StatementEnd
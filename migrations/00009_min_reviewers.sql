@@ -0,0 +1,17 @@
+-- migrations/00009_min_reviewers.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Минимум ревьюверов, ниже которого нельзя снять ревьювера без замены
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS min_reviewers INTEGER NOT NULL DEFAULT 1 CHECK (min_reviewers >= 0);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS min_reviewers;
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- migrations/00010_assignment_events.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- История решений о назначении ревьюверов с объяснением выбора
+CREATE TABLE IF NOT EXISTS assignment_events (
+    event_id        BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    kind            VARCHAR(32)  NOT NULL CHECK (kind IN ('create', 'fill', 'reassign', 'add', 'remove')),
+    reviewers       TEXT[]       NOT NULL DEFAULT '{}',
+    explanation     JSONB,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(pull_request_id, event_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS assignment_events;
+
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- migrations/00011_team_lifecycle.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Переименование команды каскадно обновляет участников и правила,
+-- а удаление оставляет участников без команды вместо удаления их вместе с PR
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+ALTER TABLE assignment_rules DROP CONSTRAINT IF EXISTS assignment_rules_team_name_fkey;
+ALTER TABLE assignment_rules
+    ADD CONSTRAINT assignment_rules_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE assignment_rules DROP CONSTRAINT IF EXISTS assignment_rules_team_name_fkey;
+ALTER TABLE assignment_rules
+    ADD CONSTRAINT assignment_rules_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON DELETE CASCADE;
+
+-- Откат невозможен, пока есть пользователи без команды
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON DELETE CASCADE;
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- migrations/00012_team_moves.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- История переходов пользователей между командами. Имена команд хранятся
+-- как есть: запись переживает переименование и удаление команды.
+CREATE TABLE IF NOT EXISTS team_moves (
+    move_id    BIGSERIAL PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    from_team  VARCHAR(255) NULL,
+    to_team    VARCHAR(255) NOT NULL,
+    handovers  JSONB        NOT NULL DEFAULT '[]',
+    moved_at   TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_moves_user ON team_moves(user_id, move_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS team_moves;
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- migrations/00013_user_offboarding.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Ушедшие пользователи не удаляются, а помечаются departed_at: на них
+-- держатся PR, ревью и статистика
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email       VARCHAR(255) NULL,
+    ADD COLUMN IF NOT EXISTS departed_at TIMESTAMP    NULL;
+
+-- Удаление пользователя больше не уносит с собой историю PR и ревью
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_author_id_fkey FOREIGN KEY (author_id)
+        REFERENCES users(user_id) ON DELETE RESTRICT;
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_reviewer_id_fkey;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_reviewer_id_fkey FOREIGN KEY (reviewer_id)
+        REFERENCES users(user_id) ON DELETE RESTRICT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_reviewer_id_fkey;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_reviewer_id_fkey FOREIGN KEY (reviewer_id)
+        REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_author_id_fkey FOREIGN KEY (author_id)
+        REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS departed_at,
+    DROP COLUMN IF EXISTS email;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- migrations/00014_team_hierarchy.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Родительская команда: подкоманда эскалирует к ней подбор ревьюверов,
+-- статистика суммируется вверх по иерархии
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS parent_team VARCHAR(255) NULL
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams(parent_team);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_teams_parent;
+ALTER TABLE teams DROP COLUMN IF EXISTS parent_team;
+
+-- +goose StatementEnd
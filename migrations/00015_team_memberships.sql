@@ -0,0 +1,56 @@
+-- migrations/00015_team_memberships.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Членство пользователей в командах. Основная команда по-прежнему хранится
+-- в users.team_name (от нее зависят политика PR автора и статистика), а ее
+-- строка здесь поддерживается триггером; дополнительные команды задаются явно.
+CREATE TABLE IF NOT EXISTS team_memberships (
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    team_name  VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE,
+    is_primary BOOLEAN      NOT NULL DEFAULT false,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, team_name)
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_memberships_primary ON team_memberships(user_id) WHERE is_primary;
+CREATE INDEX IF NOT EXISTS idx_team_memberships_team ON team_memberships(team_name);
+
+INSERT INTO team_memberships (user_id, team_name, is_primary)
+SELECT user_id, team_name, true FROM users WHERE team_name IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Смена основной команды: прежняя основная строка удаляется, новая команда
+-- становится основной (дополнительное членство в ней повышается)
+CREATE OR REPLACE FUNCTION sync_primary_membership() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT DISTINCT FROM NEW.team_name THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT NULL THEN
+        DELETE FROM team_memberships
+        WHERE user_id = OLD.user_id AND team_name = OLD.team_name AND is_primary;
+    END IF;
+    IF NEW.team_name IS NOT NULL THEN
+        INSERT INTO team_memberships (user_id, team_name, is_primary)
+        VALUES (NEW.user_id, NEW.team_name, true)
+        ON CONFLICT (user_id, team_name) DO UPDATE SET is_primary = true;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_primary_membership
+    AFTER INSERT OR UPDATE OF team_name ON users
+    FOR EACH ROW EXECUTE FUNCTION sync_primary_membership();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS users_primary_membership ON users;
+DROP FUNCTION IF EXISTS sync_primary_membership();
+DROP TABLE IF EXISTS team_memberships;
+
+-- +goose StatementEnd
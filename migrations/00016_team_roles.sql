@@ -0,0 +1,22 @@
+-- migrations/00016_team_roles.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Роль участника в команде: lead и maintainer управляют составом команды,
+-- lead может быть обязательным ревьювером
+ALTER TABLE team_memberships
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'member'
+        CHECK (role IN ('lead', 'maintainer', 'member'));
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS require_lead BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE teams DROP COLUMN IF EXISTS require_lead;
+ALTER TABLE team_memberships DROP COLUMN IF EXISTS role;
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- migrations/00017_user_identities.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Учетные записи пользователей во внешних системах (логин GitHub, Slack ID и т.п.).
+-- Внешний ID принадлежит одному пользователю, у пользователя — одна запись на провайдера.
+-- external_id хранится нормализованным (см. domain.NormalizeIdentity).
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider    VARCHAR(20)  NOT NULL CHECK (provider IN ('github', 'gitlab', 'slack', 'email')),
+    external_id VARCHAR(255) NOT NULL,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, external_id),
+    UNIQUE (user_id, provider)
+    );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_identities;
+
+-- +goose StatementEnd
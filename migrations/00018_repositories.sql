@@ -0,0 +1,97 @@
+-- migrations/00018_repositories.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Репозитории с командой-владельцем и переопределениями политики назначения
+-- (NULL — берется значение команды автора PR)
+CREATE TABLE IF NOT EXISTS repositories (
+    repo_name      VARCHAR(255) PRIMARY KEY,
+    owner_team     VARCHAR(255) NULL REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL,
+    strategy       VARCHAR(20)  NULL,
+    require_senior BOOLEAN      NULL,
+    require_lead   BOOLEAN      NULL,
+    shadow_junior  BOOLEAN      NULL,
+    min_reviewers  INTEGER      NULL,
+    created_at     TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP    NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_repositories_owner ON repositories(owner_team);
+
+-- ID PR уникален в пределах репозитория. PR, созданные до появления
+-- репозиториев, остаются с пустым репозиторием.
+ALTER TABLE pull_requests     ADD COLUMN IF NOT EXISTS repository VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE pr_reviewers      ADD COLUMN IF NOT EXISTS repository VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE pr_staffing_queue ADD COLUMN IF NOT EXISTS repository VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE assignment_events ADD COLUMN IF NOT EXISTS repository VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_pull_request_id_fkey;
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pull_request_id_fkey;
+ALTER TABLE assignment_events DROP CONSTRAINT IF EXISTS assignment_events_pull_request_id_fkey;
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (repository, pull_request_id);
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_pkey;
+ALTER TABLE pr_reviewers ADD PRIMARY KEY (repository, pull_request_id, reviewer_id);
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pkey;
+ALTER TABLE pr_staffing_queue ADD PRIMARY KEY (repository, pull_request_id);
+ALTER TABLE pr_staffing_queue
+    ADD CONSTRAINT pr_staffing_queue_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE assignment_events
+    ADD CONSTRAINT assignment_events_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_pr_reviewers_pr;
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pr ON pr_reviewers(repository, pull_request_id);
+DROP INDEX IF EXISTS idx_assignment_events_pr;
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(repository, pull_request_id, event_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Откат возможен, только пока ID PR не повторяются в разных репозиториях
+ALTER TABLE assignment_events DROP CONSTRAINT IF EXISTS assignment_events_pull_request_fkey;
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pull_request_fkey;
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_pull_request_fkey;
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (pull_request_id);
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_pkey;
+ALTER TABLE pr_reviewers ADD PRIMARY KEY (pull_request_id, reviewer_id);
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_pull_request_id_fkey FOREIGN KEY (pull_request_id)
+        REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pkey;
+ALTER TABLE pr_staffing_queue ADD PRIMARY KEY (pull_request_id);
+ALTER TABLE pr_staffing_queue
+    ADD CONSTRAINT pr_staffing_queue_pull_request_id_fkey FOREIGN KEY (pull_request_id)
+        REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE assignment_events
+    ADD CONSTRAINT assignment_events_pull_request_id_fkey FOREIGN KEY (pull_request_id)
+        REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_assignment_events_pr;
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(pull_request_id, event_id);
+DROP INDEX IF EXISTS idx_pr_reviewers_pr;
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pr ON pr_reviewers(pull_request_id);
+
+ALTER TABLE assignment_events DROP COLUMN IF EXISTS repository;
+ALTER TABLE pr_staffing_queue DROP COLUMN IF EXISTS repository;
+ALTER TABLE pr_reviewers      DROP COLUMN IF EXISTS repository;
+ALTER TABLE pull_requests     DROP COLUMN IF EXISTS repository;
+
+DROP TABLE IF EXISTS repositories;
+
+-- +goose StatementEnd
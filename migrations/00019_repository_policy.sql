@@ -0,0 +1,18 @@
+-- migrations/00019_repository_policy.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Переопределения репозитория: число ревьюверов (NULL — по умолчанию),
+-- обязательные команды и пути, не влияющие на подбор
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS reviewers_count INTEGER NULL;
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS required_teams  TEXT[]  NOT NULL DEFAULT '{}';
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS excluded_paths  TEXT[]  NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE repositories DROP COLUMN IF EXISTS excluded_paths;
+ALTER TABLE repositories DROP COLUMN IF EXISTS required_teams;
+ALTER TABLE repositories DROP COLUMN IF EXISTS reviewers_count;
+-- +goose StatementEnd
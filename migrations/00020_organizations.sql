@@ -0,0 +1,286 @@
+-- migrations/00020_organizations.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Организации (бизнес-юниты) одного экземпляра сервиса. Команды, пользователи,
+-- PR и все производные данные принадлежат организации, ее ID входит в ключи,
+-- поэтому одинаковые имена команд и ID пользователей в разных организациях
+-- не пересекаются. Существующие данные переходят в организацию 'default'.
+CREATE TABLE IF NOT EXISTS organizations (
+    org_id     VARCHAR(255) PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW()
+    );
+
+INSERT INTO organizations (org_id, name) VALUES ('default', 'Default') ON CONFLICT DO NOTHING;
+
+-- API-ключи хранятся только в виде SHA-256
+CREATE TABLE IF NOT EXISTS organization_api_keys (
+    key_hash   CHAR(64)     PRIMARY KEY,
+    org_id     VARCHAR(255) NOT NULL REFERENCES organizations(org_id) ON DELETE CASCADE,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_organization_api_keys_org ON organization_api_keys(org_id);
+
+ALTER TABLE teams             ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE users             ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_requests     ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE pr_reviewers      ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE pr_staffing_queue ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE assignment_events ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE assignment_rules  ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE team_moves        ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE team_memberships  ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE user_identities   ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE repositories      ADD COLUMN IF NOT EXISTS org_id VARCHAR(255) NOT NULL DEFAULT 'default';
+
+-- Без значения по умолчанию запись без организации не пройдет незамеченной
+ALTER TABLE teams             ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE users             ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pull_requests     ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pr_reviewers      ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pr_staffing_queue ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE assignment_events ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE assignment_rules  ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE team_moves        ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE team_memberships  ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE user_identities   ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE repositories      ALTER COLUMN org_id DROP DEFAULT;
+
+ALTER TABLE teams             DROP CONSTRAINT IF EXISTS teams_parent_team_fkey;
+ALTER TABLE users             DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE pull_requests     DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_reviewer_id_fkey;
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_pull_request_fkey;
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pull_request_fkey;
+ALTER TABLE assignment_events DROP CONSTRAINT IF EXISTS assignment_events_pull_request_fkey;
+ALTER TABLE assignment_rules  DROP CONSTRAINT IF EXISTS assignment_rules_team_name_fkey;
+ALTER TABLE team_moves        DROP CONSTRAINT IF EXISTS team_moves_user_id_fkey;
+ALTER TABLE team_memberships  DROP CONSTRAINT IF EXISTS team_memberships_user_id_fkey;
+ALTER TABLE team_memberships  DROP CONSTRAINT IF EXISTS team_memberships_team_name_fkey;
+ALTER TABLE user_identities   DROP CONSTRAINT IF EXISTS user_identities_user_id_fkey;
+ALTER TABLE repositories      DROP CONSTRAINT IF EXISTS repositories_owner_team_fkey;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_pkey;
+ALTER TABLE teams ADD PRIMARY KEY (org_id, team_name);
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (org_id, user_id);
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (org_id, repository, pull_request_id);
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_pkey;
+ALTER TABLE pr_reviewers ADD PRIMARY KEY (org_id, repository, pull_request_id, reviewer_id);
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pkey;
+ALTER TABLE pr_staffing_queue ADD PRIMARY KEY (org_id, repository, pull_request_id);
+ALTER TABLE team_memberships DROP CONSTRAINT IF EXISTS team_memberships_pkey;
+ALTER TABLE team_memberships ADD PRIMARY KEY (org_id, user_id, team_name);
+ALTER TABLE user_identities DROP CONSTRAINT IF EXISTS user_identities_pkey;
+ALTER TABLE user_identities ADD PRIMARY KEY (org_id, provider, external_id);
+ALTER TABLE user_identities DROP CONSTRAINT IF EXISTS user_identities_user_id_provider_key;
+ALTER TABLE user_identities ADD CONSTRAINT user_identities_user_id_provider_key UNIQUE (org_id, user_id, provider);
+ALTER TABLE repositories DROP CONSTRAINT IF EXISTS repositories_pkey;
+ALTER TABLE repositories ADD PRIMARY KEY (org_id, repo_name);
+ALTER TABLE assignment_rules DROP CONSTRAINT IF EXISTS assignment_rules_team_name_kind_subject_target_key;
+ALTER TABLE assignment_rules
+    ADD CONSTRAINT assignment_rules_team_name_kind_subject_target_key UNIQUE (org_id, team_name, kind, subject, target);
+
+-- Удаление организации с данными запрещено
+ALTER TABLE teams         ADD CONSTRAINT teams_org_id_fkey         FOREIGN KEY (org_id) REFERENCES organizations(org_id);
+ALTER TABLE users         ADD CONSTRAINT users_org_id_fkey         FOREIGN KEY (org_id) REFERENCES organizations(org_id);
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_org_id_fkey FOREIGN KEY (org_id) REFERENCES organizations(org_id);
+ALTER TABLE repositories  ADD CONSTRAINT repositories_org_id_fkey  FOREIGN KEY (org_id) REFERENCES organizations(org_id);
+
+-- Прежние действия внешних ключей; SET NULL обнуляет только ссылку, не организацию
+ALTER TABLE teams
+    ADD CONSTRAINT teams_parent_team_fkey FOREIGN KEY (org_id, parent_team)
+        REFERENCES teams(org_id, team_name) ON UPDATE CASCADE ON DELETE SET NULL (parent_team);
+ALTER TABLE users
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (org_id, team_name)
+        REFERENCES teams(org_id, team_name) ON UPDATE CASCADE ON DELETE SET NULL (team_name);
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_author_id_fkey FOREIGN KEY (org_id, author_id)
+        REFERENCES users(org_id, user_id) ON DELETE RESTRICT;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_reviewer_id_fkey FOREIGN KEY (org_id, reviewer_id)
+        REFERENCES users(org_id, user_id) ON DELETE RESTRICT;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_pull_request_fkey FOREIGN KEY (org_id, repository, pull_request_id)
+        REFERENCES pull_requests(org_id, repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pr_staffing_queue
+    ADD CONSTRAINT pr_staffing_queue_pull_request_fkey FOREIGN KEY (org_id, repository, pull_request_id)
+        REFERENCES pull_requests(org_id, repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE assignment_events
+    ADD CONSTRAINT assignment_events_pull_request_fkey FOREIGN KEY (org_id, repository, pull_request_id)
+        REFERENCES pull_requests(org_id, repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE assignment_rules
+    ADD CONSTRAINT assignment_rules_team_name_fkey FOREIGN KEY (org_id, team_name)
+        REFERENCES teams(org_id, team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE team_moves
+    ADD CONSTRAINT team_moves_user_id_fkey FOREIGN KEY (org_id, user_id)
+        REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+ALTER TABLE team_memberships
+    ADD CONSTRAINT team_memberships_user_id_fkey FOREIGN KEY (org_id, user_id)
+        REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+ALTER TABLE team_memberships
+    ADD CONSTRAINT team_memberships_team_name_fkey FOREIGN KEY (org_id, team_name)
+        REFERENCES teams(org_id, team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE user_identities
+    ADD CONSTRAINT user_identities_user_id_fkey FOREIGN KEY (org_id, user_id)
+        REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+ALTER TABLE repositories
+    ADD CONSTRAINT repositories_owner_team_fkey FOREIGN KEY (org_id, owner_team)
+        REFERENCES teams(org_id, team_name) ON UPDATE CASCADE ON DELETE SET NULL (owner_team);
+
+DROP INDEX IF EXISTS idx_team_memberships_primary;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_memberships_primary ON team_memberships(org_id, user_id) WHERE is_primary;
+DROP INDEX IF EXISTS idx_pr_reviewers_reviewer;
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer ON pr_reviewers(org_id, reviewer_id);
+DROP INDEX IF EXISTS idx_assignment_events_pr;
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(org_id, repository, pull_request_id, event_id);
+
+-- Основное членство заводится в организации пользователя
+CREATE OR REPLACE FUNCTION sync_primary_membership() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT DISTINCT FROM NEW.team_name THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT NULL THEN
+        DELETE FROM team_memberships
+        WHERE org_id = OLD.org_id AND user_id = OLD.user_id AND team_name = OLD.team_name AND is_primary;
+    END IF;
+    IF NEW.team_name IS NOT NULL THEN
+        INSERT INTO team_memberships (org_id, user_id, team_name, is_primary)
+        VALUES (NEW.org_id, NEW.user_id, NEW.team_name, true)
+        ON CONFLICT (org_id, user_id, team_name) DO UPDATE SET is_primary = true;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Откат возможен, только пока имена команд и ID не повторяются в разных организациях
+CREATE OR REPLACE FUNCTION sync_primary_membership() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT DISTINCT FROM NEW.team_name THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.team_name IS NOT NULL THEN
+        DELETE FROM team_memberships
+        WHERE user_id = OLD.user_id AND team_name = OLD.team_name AND is_primary;
+    END IF;
+    IF NEW.team_name IS NOT NULL THEN
+        INSERT INTO team_memberships (user_id, team_name, is_primary)
+        VALUES (NEW.user_id, NEW.team_name, true)
+        ON CONFLICT (user_id, team_name) DO UPDATE SET is_primary = true;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_assignment_events_pr;
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(repository, pull_request_id, event_id);
+DROP INDEX IF EXISTS idx_pr_reviewers_reviewer;
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer ON pr_reviewers(reviewer_id);
+DROP INDEX IF EXISTS idx_team_memberships_primary;
+
+ALTER TABLE repositories      DROP CONSTRAINT IF EXISTS repositories_owner_team_fkey;
+ALTER TABLE user_identities   DROP CONSTRAINT IF EXISTS user_identities_user_id_fkey;
+ALTER TABLE team_memberships  DROP CONSTRAINT IF EXISTS team_memberships_team_name_fkey;
+ALTER TABLE team_memberships  DROP CONSTRAINT IF EXISTS team_memberships_user_id_fkey;
+ALTER TABLE team_moves        DROP CONSTRAINT IF EXISTS team_moves_user_id_fkey;
+ALTER TABLE assignment_rules  DROP CONSTRAINT IF EXISTS assignment_rules_team_name_fkey;
+ALTER TABLE assignment_events DROP CONSTRAINT IF EXISTS assignment_events_pull_request_fkey;
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pull_request_fkey;
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_pull_request_fkey;
+ALTER TABLE pr_reviewers      DROP CONSTRAINT IF EXISTS pr_reviewers_reviewer_id_fkey;
+ALTER TABLE pull_requests     DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE users             DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE teams             DROP CONSTRAINT IF EXISTS teams_parent_team_fkey;
+ALTER TABLE repositories      DROP CONSTRAINT IF EXISTS repositories_org_id_fkey;
+ALTER TABLE pull_requests     DROP CONSTRAINT IF EXISTS pull_requests_org_id_fkey;
+ALTER TABLE users             DROP CONSTRAINT IF EXISTS users_org_id_fkey;
+ALTER TABLE teams             DROP CONSTRAINT IF EXISTS teams_org_id_fkey;
+
+ALTER TABLE assignment_rules DROP CONSTRAINT IF EXISTS assignment_rules_team_name_kind_subject_target_key;
+ALTER TABLE assignment_rules ADD CONSTRAINT assignment_rules_team_name_kind_subject_target_key UNIQUE (team_name, kind, subject, target);
+ALTER TABLE repositories DROP CONSTRAINT IF EXISTS repositories_pkey;
+ALTER TABLE repositories ADD PRIMARY KEY (repo_name);
+ALTER TABLE user_identities DROP CONSTRAINT IF EXISTS user_identities_user_id_provider_key;
+ALTER TABLE user_identities ADD CONSTRAINT user_identities_user_id_provider_key UNIQUE (user_id, provider);
+ALTER TABLE user_identities DROP CONSTRAINT IF EXISTS user_identities_pkey;
+ALTER TABLE user_identities ADD PRIMARY KEY (provider, external_id);
+ALTER TABLE team_memberships DROP CONSTRAINT IF EXISTS team_memberships_pkey;
+ALTER TABLE team_memberships ADD PRIMARY KEY (user_id, team_name);
+ALTER TABLE pr_staffing_queue DROP CONSTRAINT IF EXISTS pr_staffing_queue_pkey;
+ALTER TABLE pr_staffing_queue ADD PRIMARY KEY (repository, pull_request_id);
+ALTER TABLE pr_reviewers DROP CONSTRAINT IF EXISTS pr_reviewers_pkey;
+ALTER TABLE pr_reviewers ADD PRIMARY KEY (repository, pull_request_id, reviewer_id);
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (repository, pull_request_id);
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (user_id);
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_pkey;
+ALTER TABLE teams ADD PRIMARY KEY (team_name);
+
+ALTER TABLE teams
+    ADD CONSTRAINT teams_parent_team_fkey FOREIGN KEY (parent_team)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE users
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_author_id_fkey FOREIGN KEY (author_id)
+        REFERENCES users(user_id) ON DELETE RESTRICT;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_reviewer_id_fkey FOREIGN KEY (reviewer_id)
+        REFERENCES users(user_id) ON DELETE RESTRICT;
+ALTER TABLE pr_reviewers
+    ADD CONSTRAINT pr_reviewers_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pr_staffing_queue
+    ADD CONSTRAINT pr_staffing_queue_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE assignment_events
+    ADD CONSTRAINT assignment_events_pull_request_fkey FOREIGN KEY (repository, pull_request_id)
+        REFERENCES pull_requests(repository, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE assignment_rules
+    ADD CONSTRAINT assignment_rules_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE team_moves
+    ADD CONSTRAINT team_moves_user_id_fkey FOREIGN KEY (user_id)
+        REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE team_memberships
+    ADD CONSTRAINT team_memberships_user_id_fkey FOREIGN KEY (user_id)
+        REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE team_memberships
+    ADD CONSTRAINT team_memberships_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE user_identities
+    ADD CONSTRAINT user_identities_user_id_fkey FOREIGN KEY (user_id)
+        REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE repositories
+    ADD CONSTRAINT repositories_owner_team_fkey FOREIGN KEY (owner_team)
+        REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_memberships_primary ON team_memberships(user_id) WHERE is_primary;
+
+ALTER TABLE repositories      DROP COLUMN IF EXISTS org_id;
+ALTER TABLE user_identities   DROP COLUMN IF EXISTS org_id;
+ALTER TABLE team_memberships  DROP COLUMN IF EXISTS org_id;
+ALTER TABLE team_moves        DROP COLUMN IF EXISTS org_id;
+ALTER TABLE assignment_rules  DROP COLUMN IF EXISTS org_id;
+ALTER TABLE assignment_events DROP COLUMN IF EXISTS org_id;
+ALTER TABLE pr_staffing_queue DROP COLUMN IF EXISTS org_id;
+ALTER TABLE pr_reviewers      DROP COLUMN IF EXISTS org_id;
+ALTER TABLE pull_requests     DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users             DROP COLUMN IF EXISTS org_id;
+ALTER TABLE teams             DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS organization_api_keys;
+DROP TABLE IF EXISTS organizations;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- migrations/00021_api_key_users.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Ключ, выпущенный пользователю, действует от его имени: права на изменение
+-- команд определяются его ролями. Ключ без пользователя — ключ администратора
+-- организации. Удаление пользователя отзывает его ключи.
+ALTER TABLE organization_api_keys ADD COLUMN IF NOT EXISTS user_id VARCHAR(255);
+ALTER TABLE organization_api_keys
+    ADD CONSTRAINT organization_api_keys_user_id_fkey FOREIGN KEY (org_id, user_id)
+        REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Ключи пользователей без привязки стали бы ключами администратора
+DELETE FROM organization_api_keys WHERE user_id IS NOT NULL;
+ALTER TABLE organization_api_keys DROP CONSTRAINT IF EXISTS organization_api_keys_user_id_fkey;
+ALTER TABLE organization_api_keys DROP COLUMN IF EXISTS user_id;
+
+-- +goose StatementEnd
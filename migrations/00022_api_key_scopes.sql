@@ -0,0 +1,24 @@
+-- migrations/00022_api_key_scopes.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Область действия ключа: api — обычный API, scim — только /scim/v2 для
+-- провижининга из identity provider'а. SCIM-токен не привязан к пользователю.
+ALTER TABLE organization_api_keys ADD COLUMN IF NOT EXISTS scope VARCHAR(16) NOT NULL DEFAULT 'api';
+ALTER TABLE organization_api_keys
+    ADD CONSTRAINT organization_api_keys_scope_check CHECK (scope IN ('api', 'scim'));
+ALTER TABLE organization_api_keys
+    ADD CONSTRAINT organization_api_keys_scim_user_check CHECK (scope = 'api' OR user_id IS NULL);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- SCIM-токены без области действия стали бы ключами администратора
+DELETE FROM organization_api_keys WHERE scope <> 'api';
+ALTER TABLE organization_api_keys DROP CONSTRAINT IF EXISTS organization_api_keys_scim_user_check;
+ALTER TABLE organization_api_keys DROP CONSTRAINT IF EXISTS organization_api_keys_scope_check;
+ALTER TABLE organization_api_keys DROP COLUMN IF EXISTS scope;
+
+-- +goose StatementEnd
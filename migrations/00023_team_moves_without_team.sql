@@ -0,0 +1,18 @@
+-- migrations/00023_team_moves_without_team.sql
+-- +goose Up
+-- +goose StatementBegin
+
+-- Переход может оставить пользователя без команды (пустой team_name в
+-- реестре): такой переход хранится с to_team = NULL, как from_team для
+-- пользователя, у которого команды не было.
+ALTER TABLE team_moves ALTER COLUMN to_team DROP NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM team_moves WHERE to_team IS NULL;
+ALTER TABLE team_moves ALTER COLUMN to_team SET NOT NULL;
+
+-- +goose StatementEnd
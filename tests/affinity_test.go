@@ -0,0 +1,121 @@
+// tests/affinity_test.go
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffinityStrategy(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "data",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "R1", "is_active": true},
+			{"user_id": "r2", "username": "R2", "is_active": true},
+			{"user_id": "r3", "username": "R3", "is_active": true},
+		},
+		"policy": map[string]any{"strategy": "affinity", "affinity_window_days": 7, "affinity_weight": 2},
+	})
+
+	createPR := func(t *testing.T, id string) []string {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   id,
+			"pull_request_name": id,
+			"author_id":         "author",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Len(t, result.PR.AssignedReviewers, 2)
+		return result.PR.AssignedReviewers
+	}
+
+	t.Run("Reviewer without recent pairs is preferred", func(t *testing.T) {
+		first := createPR(t, "pr-aff-1")
+
+		fresh := map[string]bool{"r1": true, "r2": true, "r3": true}
+		for _, r := range first {
+			delete(fresh, r)
+		}
+		require.Len(t, fresh, 1)
+
+		second := createPR(t, "pr-aff-2")
+		for id := range fresh {
+			assert.Contains(t, second, id)
+		}
+	})
+
+	t.Run("Shadow reviews do not count as pairs", func(t *testing.T) {
+		// Отдельный автор: у r1 и r2 только «теневые» ревью его PR, у r3 — одно обычное
+		resp := it.Post(t, "/team/add", map[string]any{
+			"team_name": "shadowed",
+			"members": []map[string]any{
+				{"user_id": "s-author", "username": "Author", "is_active": true},
+				{"user_id": "s1", "username": "S1", "is_active": true},
+				{"user_id": "s2", "username": "S2", "is_active": true},
+				{"user_id": "s3", "username": "S3", "is_active": true},
+			},
+			"policy": map[string]any{"strategy": "affinity", "affinity_window_days": 7, "affinity_weight": 2},
+		})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		_, err := it.db.Exec(context.Background(), `
+            INSERT INTO pull_requests (org_id, repository, pull_request_id, pull_request_name, author_id, status, created_at)
+            VALUES ('default', '', 'pr-sh-1', 'Old 1', 's-author', 'MERGED', NOW()),
+                   ('default', '', 'pr-sh-2', 'Old 2', 's-author', 'MERGED', NOW());
+            INSERT INTO pr_reviewers (org_id, repository, pull_request_id, reviewer_id, is_shadow)
+            VALUES ('default', '', 'pr-sh-1', 's1', true), ('default', '', 'pr-sh-1', 's2', true),
+                   ('default', '', 'pr-sh-2', 's1', true), ('default', '', 'pr-sh-2', 's2', true),
+                   ('default', '', 'pr-sh-1', 's3', false);
+        `)
+		require.NoError(t, err)
+
+		resp = it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-sh-3",
+			"pull_request_name": "New",
+			"author_id":         "s-author",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.ElementsMatch(t, []string{"s1", "s2"}, result.PR.AssignedReviewers)
+	})
+
+	t.Run("Policy exposes affinity settings", func(t *testing.T) {
+		resp := it.Get(t, "/team/get?team_name=data")
+		defer resp.Body.Close()
+
+		var result struct {
+			Policy struct {
+				Strategy           string  `json:"strategy"`
+				AffinityWindowDays int     `json:"affinity_window_days"`
+				AffinityWeight     float64 `json:"affinity_weight"`
+			} `json:"policy"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, "affinity", result.Policy.Strategy)
+		assert.Equal(t, 7, result.Policy.AffinityWindowDays)
+		assert.Equal(t, 2.0, result.Policy.AffinityWeight)
+	})
+}
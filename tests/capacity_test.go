@@ -0,0 +1,89 @@
+// tests/capacity_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewerCapacity(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "billing",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "R1", "is_active": true},
+			{"user_id": "r2", "username": "R2", "is_active": true},
+		},
+		"policy": map[string]any{"default_max_open_reviews": 1},
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-cap-1",
+		"pull_request_name": "First",
+		"author_id":         "author",
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Everyone at capacity → PR queued with NO_CAPACITY", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-cap-2",
+			"pull_request_name": "Second",
+			"author_id":         "author",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+				Understaffed      bool     `json:"understaffed"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Empty(t, result.PR.AssignedReviewers)
+		assert.True(t, result.PR.Understaffed)
+
+		queued := it.understaffed(t)
+		require.Contains(t, queued, "pr-cap-2")
+		assert.Equal(t, "NO_CAPACITY", queued["pr-cap-2"].Reason)
+		assert.Equal(t, 2, queued["pr-cap-2"].MissingReviewers)
+	})
+
+	t.Run("Personal limit overrides team default", func(t *testing.T) {
+		resp := it.Post(t, "/users/setCapacity", map[string]any{"user_id": "r1", "max_open_reviews": 2})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Воркер добирает r1 на PR из очереди, r2 по-прежнему на пределе
+		require.Eventually(t, func() bool {
+			return it.understaffed(t)["pr-cap-2"].MissingReviewers == 1
+		}, 5*time.Second, 100*time.Millisecond)
+
+		resp = it.Get(t, "/users/getReview?user_id=r1")
+		defer resp.Body.Close()
+		var reviews struct {
+			PullRequests []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+		assert.Len(t, reviews.PullRequests, 2)
+	})
+
+	t.Run("Negative limit → 400", func(t *testing.T) {
+		resp := it.Post(t, "/team/setPolicy", map[string]any{
+			"team_name": "billing",
+			"policy":    map[string]any{"default_max_open_reviews": -1},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,108 @@
+// tests/explain_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type explanation struct {
+	Reviewers []string `json:"reviewers"`
+	Strategy  string   `json:"strategy"`
+	Pool      []string `json:"candidate_pool"`
+	Excluded  []struct {
+		UserID string `json:"user_id"`
+		Reason string `json:"reason"`
+	} `json:"excluded"`
+	Scores []struct {
+		UserID string `json:"user_id"`
+	} `json:"scores"`
+}
+
+// excludedReasons возвращает причину исключения по каждому пользователю
+func (e explanation) excludedReasons() map[string]string {
+	reasons := make(map[string]string, len(e.Excluded))
+	for _, ex := range e.Excluded {
+		reasons[ex.UserID] = ex.Reason
+	}
+	return reasons
+}
+
+func TestExplainAssignment(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "explained",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "Reviewer 1", "is_active": true},
+			{"user_id": "busy", "username": "Busy", "is_active": true},
+			{"user_id": "away", "username": "Away", "is_active": false},
+		},
+	})
+	resp := it.Post(t, "/users/setCapacity", map[string]any{"user_id": "busy", "max_open_reviews": 0})
+	resp.Body.Close()
+
+	resp = it.Post(t, "/pullRequest/create?explain=true", map[string]any{
+		"pull_request_id":   "pr-explain-1",
+		"pull_request_name": "Feature",
+		"author_id":         "author",
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		Explanation explanation `json:"explanation"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	t.Run("Explanation lists pool, exclusions and scores", func(t *testing.T) {
+		e := created.Explanation
+		assert.Equal(t, "random", e.Strategy)
+		assert.ElementsMatch(t, []string{"author", "r1", "busy", "away"}, e.Pool)
+		assert.Equal(t, map[string]string{
+			"author": "author",
+			"busy":   "at_capacity",
+			"away":   "inactive",
+		}, e.excludedReasons())
+		require.Len(t, e.Scores, 1)
+		assert.Equal(t, "r1", e.Scores[0].UserID)
+		assert.Equal(t, []string{"r1"}, e.Reviewers)
+	})
+
+	t.Run("Explanation is omitted unless requested", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-explain-2",
+			"pull_request_name": "Feature",
+			"author_id":         "author",
+		})
+		defer resp.Body.Close()
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.NotContains(t, body, "explanation")
+	})
+
+	t.Run("Decision is persisted in assignment history", func(t *testing.T) {
+		resp := it.Get(t, "/pullRequest/assignmentHistory?pull_request_id=pr-explain-1")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var history struct {
+			Events []struct {
+				Kind        string      `json:"kind"`
+				Reviewers   []string    `json:"reviewers"`
+				Explanation explanation `json:"explanation"`
+			} `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+		require.NotEmpty(t, history.Events)
+		assert.Equal(t, "create", history.Events[0].Kind)
+		assert.Equal(t, []string{"r1"}, history.Events[0].Reviewers)
+		assert.Equal(t, "at_capacity", history.Events[0].Explanation.excludedReasons()["busy"])
+	})
+}
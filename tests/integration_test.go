@@ -4,7 +4,9 @@ package tests
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,6 +26,21 @@ import (
 
 const baseURL = "http://127.0.0.1:8080"
 
+// testAPIKey — ключ администратора организации по умолчанию, который клиент тестов подставляет
+// в каждый запрос без своего ключа
+const testAPIKey = "prk_default-test-key"
+
+// apiKeyTransport добавляет testAPIKey в запросы без X-API-Key и Authorization
+type apiKeyTransport struct{}
+
+func (apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("X-API-Key") == "" && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-API-Key", testAPIKey)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
 type IntegrationTest struct {
 	db     *pgxpool.Pool
 	client *http.Client
@@ -151,7 +168,7 @@ func New(t *testing.T) *IntegrationTest {
 
 	tester := &IntegrationTest{
 		db:     pool,
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: &http.Client{Timeout: 10 * time.Second, Transport: apiKeyTransport{}},
 	}
 
 	t.Cleanup(func() {
@@ -169,11 +186,20 @@ func (it *IntegrationTest) truncateAllTables(t *testing.T) {
 	defer cancel()
 
 	_, err := it.db.Exec(ctx, `
-        TRUNCATE TABLE pr_reviewers, pull_requests, teams, users RESTART IDENTITY CASCADE
+        TRUNCATE TABLE assignment_events, assignment_rules, team_memberships, user_identities, team_moves, pr_staffing_queue, pr_reviewers, pull_requests, repositories, teams, users, organization_api_keys RESTART IDENTITY CASCADE
     `)
 	if err != nil {
 		t.Logf("TRUNCATE warning: %v", err)
 	}
+	// Организация по умолчанию и ее ключ нужны всем тестам без заголовков
+	if _, err := it.db.Exec(ctx, `DELETE FROM organizations WHERE org_id <> 'default'`); err != nil {
+		t.Logf("DELETE organizations warning: %v", err)
+	}
+	hash := sha256.Sum256([]byte(testAPIKey))
+	if _, err := it.db.Exec(ctx, `INSERT INTO organization_api_keys (key_hash, org_id) VALUES ($1, 'default')`,
+		hex.EncodeToString(hash[:])); err != nil {
+		t.Logf("INSERT api key warning: %v", err)
+	}
 }
 
 // Оставляем Close только для пула — но он теперь в t.Cleanup
@@ -191,7 +217,32 @@ func (it *IntegrationTest) Post(t *testing.T, endpoint string, body any) *http.R
 
 func (it *IntegrationTest) Get(t *testing.T, endpoint string) *http.Response {
 	t.Helper()
-	resp, err := http.Get(baseURL + endpoint)
+	resp, err := it.client.Get(baseURL + endpoint)
 	require.NoError(t, err, "GET %s failed", endpoint)
 	return resp
 }
+
+// UserKey заводит в организации по умолчанию API-ключ, действующий от имени userID
+func (it *IntegrationTest) UserKey(t *testing.T, userID string) string {
+	t.Helper()
+	key := "prk_user-" + userID
+	hash := sha256.Sum256([]byte(key))
+	_, err := it.db.Exec(context.Background(),
+		`INSERT INTO organization_api_keys (key_hash, org_id, user_id) VALUES ($1, 'default', $2)`,
+		hex.EncodeToString(hash[:]), userID)
+	require.NoError(t, err)
+	return key
+}
+
+// PostAs — Post с ключом key вместо ключа администратора
+func (it *IntegrationTest) PostAs(t *testing.T, key, endpoint string, body any) *http.Response {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, baseURL+endpoint, bytes.NewBuffer(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	resp, err := it.client.Do(req)
+	require.NoError(t, err, "POST %s failed", endpoint)
+	return resp
+}
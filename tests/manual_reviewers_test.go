@@ -0,0 +1,114 @@
+// tests/manual_reviewers_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualReviewers(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "manual",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "Reviewer 1", "is_active": true},
+			{"user_id": "r2", "username": "Reviewer 2", "is_active": true},
+			{"user_id": "r3", "username": "Reviewer 3", "is_active": true},
+			{"user_id": "gone", "username": "Inactive", "is_active": false},
+		},
+	})
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "other",
+		"members":   []map[string]any{{"user_id": "stranger", "username": "Stranger", "is_active": true}},
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-manual-1",
+		"pull_request_name": "Feature",
+		"author_id":         "author",
+		"reviewers":         []string{"r1", "r2"},
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	type prResponse struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+		ReplacedBy string `json:"replaced_by"`
+	}
+	type errorResponse struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+
+	t.Run("Reassign to a chosen user", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/reassign", map[string]any{
+			"pull_request_id": "pr-manual-1",
+			"old_user_id":     "r2",
+			"new_user_id":     "r3",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body prResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "r3", body.ReplacedBy)
+		assert.ElementsMatch(t, []string{"r1", "r3"}, body.PR.AssignedReviewers)
+	})
+
+	t.Run("Add a named reviewer", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/addReviewer", map[string]any{"pull_request_id": "pr-manual-1", "user_id": "r2"})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body prResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.ElementsMatch(t, []string{"r1", "r2", "r3"}, body.PR.AssignedReviewers)
+	})
+
+	t.Run("Add validates the candidate", func(t *testing.T) {
+		cases := []struct {
+			userID string
+			status int
+			code   string
+		}{
+			{"r1", http.StatusConflict, "ALREADY_ASSIGNED"},
+			{"author", http.StatusBadRequest, "INVALID_INPUT"},
+			{"gone", http.StatusBadRequest, "INVALID_INPUT"},
+			{"stranger", http.StatusBadRequest, "INVALID_INPUT"},
+			{"ghost", http.StatusNotFound, "NOT_FOUND"},
+		}
+		for _, tc := range cases {
+			resp := it.Post(t, "/pullRequest/addReviewer", map[string]any{"pull_request_id": "pr-manual-1", "user_id": tc.userID})
+			var errResp errorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			resp.Body.Close()
+			assert.Equal(t, tc.status, resp.StatusCode, tc.userID)
+			assert.Equal(t, tc.code, errResp.Error.Code, tc.userID)
+		}
+	})
+
+	t.Run("Remove down to the team minimum", func(t *testing.T) {
+		for _, userID := range []string{"r2", "r3"} {
+			resp := it.Post(t, "/pullRequest/removeReviewer", map[string]any{"pull_request_id": "pr-manual-1", "user_id": userID})
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		resp := it.Post(t, "/pullRequest/removeReviewer", map[string]any{"pull_request_id": "pr-manual-1", "user_id": "r1"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp errorResponse
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.Equal(t, "MIN_REVIEWERS", errResp.Error.Code)
+	})
+}
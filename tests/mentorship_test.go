@@ -0,0 +1,75 @@
+// tests/mentorship_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentorshipPolicy(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "onboarding",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "j1", "username": "Junior 1", "is_active": true, "seniority": "junior"},
+			{"user_id": "j2", "username": "Junior 2", "is_active": true, "seniority": "intern"},
+			{"user_id": "s1", "username": "Senior", "is_active": true, "seniority": "senior"},
+		},
+		"policy": map[string]any{"require_senior": true, "shadow_junior": true},
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-mentor-1",
+		"pull_request_name": "Risky change",
+		"author_id":         "author",
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+			ShadowReviewers   []string `json:"shadow_reviewers"`
+		} `json:"pr"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	t.Run("Senior is always assigned", func(t *testing.T) {
+		assert.Len(t, created.PR.AssignedReviewers, 2)
+		assert.Contains(t, created.PR.AssignedReviewers, "s1")
+	})
+
+	t.Run("Remaining junior shadows the review", func(t *testing.T) {
+		require.Len(t, created.PR.ShadowReviewers, 1)
+		assert.NotContains(t, created.PR.AssignedReviewers, created.PR.ShadowReviewers[0])
+	})
+
+	t.Run("Only senior cannot be replaced by a junior → 409 NO_CANDIDATE", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/reassign", map[string]any{
+			"pull_request_id": "pr-mentor-1",
+			"old_user_id":     "s1",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.Equal(t, "NO_CANDIDATE", errResp.Error.Code)
+	})
+
+	t.Run("Unknown seniority → 400", func(t *testing.T) {
+		resp := it.Post(t, "/users/setSeniority", map[string]any{"user_id": "j1", "seniority": "wizard"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
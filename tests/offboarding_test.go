@@ -0,0 +1,99 @@
+// tests/offboarding_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOffboarding(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "core",
+		"members": []map[string]any{
+			{"user_id": "c1", "username": "C1", "is_active": true},
+			{"user_id": "c2", "username": "C2", "is_active": true, "email": "c2@example.com"},
+			{"user_id": "c3", "username": "C3", "is_active": true},
+			{"user_id": "c4", "username": "C4", "is_active": true},
+		},
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-off-1",
+		"pull_request_name": "Feature",
+		"author_id":         "c1",
+		"reviewers":         []string{"c2", "c3"},
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Offboarding hands over open reviews and keeps history", func(t *testing.T) {
+		resp := it.Post(t, "/users/offboard", map[string]any{"user_id": "c2", "anonymize": true})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			User struct {
+				Username   string  `json:"username"`
+				IsActive   bool    `json:"is_active"`
+				DepartedAt *string `json:"departed_at"`
+			} `json:"user"`
+			Handovers []struct {
+				PullRequestID string `json:"pull_request_id"`
+				ReplacedBy    string `json:"replaced_by"`
+			} `json:"handovers"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.False(t, body.User.IsActive)
+		assert.NotNil(t, body.User.DepartedAt)
+		assert.Equal(t, "anonymized user", body.User.Username)
+		require.Len(t, body.Handovers, 1)
+		assert.Equal(t, "c4", body.Handovers[0].ReplacedBy)
+
+		resp = it.Get(t, "/team/get?team_name=core")
+		defer resp.Body.Close()
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		for _, m := range team.Members {
+			assert.NotEqual(t, "c2", m.UserID)
+		}
+
+		resp = it.Get(t, "/stats/reviewers")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Departed user cannot be reactivated or re-added", func(t *testing.T) {
+		resp := it.Post(t, "/users/setIsActive", map[string]any{"user_id": "c2", "is_active": true})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = it.Post(t, "/team/update", map[string]any{
+			"team_name":   "core",
+			"add_members": []map[string]any{{"user_id": "c2", "username": "C2", "is_active": true}},
+		})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Offboarding twice without anonymize → 409", func(t *testing.T) {
+		resp := it.Post(t, "/users/offboard", map[string]any{"user_id": "c2"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Unknown user → 404", func(t *testing.T) {
+		resp := it.Post(t, "/users/offboard", map[string]any{"user_id": "nobody"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,171 @@
+// tests/organizations_test.go
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizations(t *testing.T) {
+	it := New(t)
+
+	// Организации и ключи заводятся напрямую в БД, как это делает cmd/orgs
+	keys := map[string]string{"acme": "prk_acme-test-key", "globex": "prk_globex-test-key"}
+	acmeKey := keys["acme"]
+	_, err := it.db.Exec(context.Background(), `
+        INSERT INTO organizations (org_id, name) VALUES ('acme', 'Acme'), ('globex', 'Globex')
+    `)
+	require.NoError(t, err)
+	for orgID, key := range keys {
+		hash := sha256.Sum256([]byte(key))
+		_, err = it.db.Exec(context.Background(),
+			`INSERT INTO organization_api_keys (key_hash, org_id) VALUES ($1, $2)`, hex.EncodeToString(hash[:]), orgID)
+		require.NoError(t, err)
+	}
+
+	// send без X-API-Key и Authorization в headers идет с ключом организации по умолчанию
+	send := func(t *testing.T, method, endpoint string, body any, headers map[string]string) *http.Response {
+		t.Helper()
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req, err := http.NewRequest(method, baseURL+endpoint, bytes.NewBuffer(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := it.client.Do(req)
+		require.NoError(t, err, "%s %s failed", method, endpoint)
+		return resp
+	}
+	inOrg := func(orgID string) map[string]string {
+		return map[string]string{"X-API-Key": keys[orgID]}
+	}
+	type teamResponse struct {
+		TeamName string `json:"team_name"`
+		Members  []struct {
+			UserID   string `json:"user_id"`
+			Username string `json:"username"`
+		} `json:"members"`
+	}
+	getTeam := func(t *testing.T, headers map[string]string) (int, teamResponse) {
+		resp := send(t, http.MethodGet, "/team/get?team_name=core", nil, headers)
+		defer resp.Body.Close()
+		var team teamResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		}
+		return resp.StatusCode, team
+	}
+
+	// Одинаковые команда и ID пользователей в двух организациях
+	for _, orgID := range []string{"acme", "globex"} {
+		resp := send(t, http.MethodPost, "/team/add", map[string]any{
+			"team_name": "core",
+			"members": []map[string]any{
+				{"user_id": "u1", "username": orgID + "-author", "is_active": true},
+				{"user_id": "u2", "username": orgID + "-reviewer", "is_active": true},
+				{"user_id": "u3", "username": orgID + "-reviewer", "is_active": true},
+			},
+		}, inOrg(orgID))
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	t.Run("Same ids are isolated between organizations", func(t *testing.T) {
+		status, team := getTeam(t, inOrg("globex"))
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, team.Members, 3)
+		for _, m := range team.Members {
+			assert.Contains(t, m.Username, "globex-")
+		}
+
+		// В организации по умолчанию такой команды нет
+		status, _ = getTeam(t, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Pull requests do not leak between organizations", func(t *testing.T) {
+		for _, orgID := range []string{"acme", "globex"} {
+			resp := send(t, http.MethodPost, "/pullRequest/create", map[string]any{
+				"pull_request_id":   "pr-1",
+				"pull_request_name": "Feature",
+				"author_id":         "u1",
+			}, inOrg(orgID))
+			resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+
+		resp := send(t, http.MethodPost, "/pullRequest/merge", map[string]any{"pull_request_id": "pr-1"}, inOrg("acme"))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var stats struct {
+			OpenPRs   int `json:"open_prs"`
+			MergedPRs int `json:"merged_prs"`
+		}
+		resp = send(t, http.MethodGet, "/stats/prs", nil, inOrg("globex"))
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+		assert.Equal(t, 1, stats.OpenPRs)
+		assert.Equal(t, 0, stats.MergedPRs)
+	})
+
+	t.Run("API key resolves its organization", func(t *testing.T) {
+		status, team := getTeam(t, map[string]string{"X-API-Key": acmeKey})
+		require.Equal(t, http.StatusOK, status)
+		require.NotEmpty(t, team.Members)
+		assert.Contains(t, team.Members[0].Username, "acme-")
+
+		status, team = getTeam(t, map[string]string{"Authorization": "Bearer " + acmeKey})
+		require.Equal(t, http.StatusOK, status)
+		assert.Contains(t, team.Members[0].Username, "acme-")
+
+		// Ключ acme не открывает данные globex
+		status, _ = getTeam(t, map[string]string{"X-API-Key": acmeKey, "X-Organization": "globex"})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Unknown key is rejected", func(t *testing.T) {
+		status, _ := getTeam(t, map[string]string{"X-API-Key": "prk_unknown"})
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		// Ключ default не открывает другую организацию, даже несуществующую
+		status, _ = getTeam(t, map[string]string{"X-Organization": "initech"})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Requests without a key are rejected by default", func(t *testing.T) {
+		// Клиент без ключа: заголовок X-Organization сам по себе ничего не открывает
+		keyless := func(t *testing.T, headers map[string]string) int {
+			req, err := http.NewRequest(http.MethodGet, baseURL+"/team/get?team_name=core", nil)
+			require.NoError(t, err)
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		assert.Equal(t, http.StatusUnauthorized, keyless(t, map[string]string{"X-Organization": "other"}))
+		assert.Equal(t, http.StatusUnauthorized, keyless(t, map[string]string{"X-Organization": "globex"}))
+		assert.Equal(t, http.StatusUnauthorized, keyless(t, nil))
+
+		resp, err := http.Get(baseURL + "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,110 @@
+// tests/pr_concurrency_test.go
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentReviewerChanges(t *testing.T) {
+	it := New(t)
+
+	members := []map[string]any{{"user_id": "author", "username": "Author", "is_active": true}}
+	reviewers := []string{"r1", "r2", "r3", "r4", "r5", "r6", "r7", "r8"}
+	for _, id := range reviewers {
+		members = append(members, map[string]any{"user_id": id, "username": "Reviewer " + id, "is_active": true})
+	}
+	resp := it.Post(t, "/team/add", map[string]any{"team_name": "busy", "members": members})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-race",
+		"pull_request_name": "Race",
+		"author_id":         "author",
+		"reviewers":         []string{"r1", "r2"},
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Parallel additions are not lost", func(t *testing.T) {
+		added := reviewers[2:]
+		statuses := make([]int, len(added))
+		var wg sync.WaitGroup
+		for i, userID := range added {
+			wg.Add(1)
+			go func(i int, userID string) {
+				defer wg.Done()
+				data, _ := json.Marshal(map[string]any{"pull_request_id": "pr-race", "user_id": userID})
+				resp, err := it.client.Post(baseURL+"/pullRequest/addReviewer", "application/json", bytes.NewBuffer(data))
+				if err != nil {
+					return
+				}
+				resp.Body.Close()
+				statuses[i] = resp.StatusCode
+			}(i, userID)
+		}
+		wg.Wait()
+		for i, status := range statuses {
+			assert.Equal(t, http.StatusOK, status, "addReviewer %s", added[i])
+		}
+
+		var count int
+		require.NoError(t, it.db.QueryRow(context.Background(), `
+            SELECT COUNT(*) FROM pr_reviewers WHERE pull_request_id = 'pr-race' AND NOT is_shadow
+        `).Scan(&count))
+		assert.Equal(t, len(reviewers), count)
+	})
+
+	t.Run("Parallel creates of one PR create it once", func(t *testing.T) {
+		const attempts = 8
+		statuses := make([]int, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				data, _ := json.Marshal(map[string]any{
+					"pull_request_id":   "pr-dup",
+					"pull_request_name": "Duplicate",
+					"author_id":         "author",
+				})
+				resp, err := it.client.Post(baseURL+"/pullRequest/create", "application/json", bytes.NewBuffer(data))
+				if err != nil {
+					return
+				}
+				resp.Body.Close()
+				statuses[i] = resp.StatusCode
+			}(i)
+		}
+		wg.Wait()
+
+		created := 0
+		for _, status := range statuses {
+			if status == http.StatusCreated {
+				created++
+			} else {
+				assert.Equal(t, http.StatusConflict, status)
+			}
+		}
+		assert.Equal(t, 1, created)
+
+		// Проигравшие не перезаписали ревьюверов и не записали вторую историю
+		var reviewerCount, events int
+		require.NoError(t, it.db.QueryRow(context.Background(), `
+            SELECT COUNT(*) FROM pr_reviewers WHERE pull_request_id = 'pr-dup' AND NOT is_shadow
+        `).Scan(&reviewerCount))
+		assert.Equal(t, 2, reviewerCount)
+		require.NoError(t, it.db.QueryRow(context.Background(), `
+            SELECT COUNT(*) FROM assignment_events WHERE pull_request_id = 'pr-dup'
+        `).Scan(&events))
+		assert.Equal(t, 1, events)
+	})
+}
@@ -0,0 +1,71 @@
+// tests/preview_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewAssignment(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "preview",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "db", "username": "DB expert", "is_active": true, "skills": []string{"database"}},
+			{"user_id": "r1", "username": "Reviewer 1", "is_active": true},
+			{"user_id": "r2", "username": "Reviewer 2", "is_active": true},
+		},
+		"policy": map[string]any{"strategy": "skills"},
+	})
+
+	resp := it.Post(t, "/pullRequest/previewAssignment", map[string]any{
+		"author_id": "author",
+		"labels":    []string{"database"},
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var preview struct {
+		AssignedReviewers []string `json:"assigned_reviewers"`
+		Understaffed      bool     `json:"understaffed"`
+		Strategy          string   `json:"strategy"`
+		Alternates        []struct {
+			UserID string `json:"user_id"`
+		} `json:"alternates"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+
+	t.Run("Preview uses the team strategy and lists alternates", func(t *testing.T) {
+		assert.Equal(t, "skills", preview.Strategy)
+		require.Len(t, preview.AssignedReviewers, 2)
+		assert.Contains(t, preview.AssignedReviewers, "db")
+		assert.False(t, preview.Understaffed)
+		require.Len(t, preview.Alternates, 1)
+		assert.NotContains(t, preview.AssignedReviewers, preview.Alternates[0].UserID)
+	})
+
+	t.Run("Nothing is persisted", func(t *testing.T) {
+		for _, userID := range []string{"db", "r1", "r2"} {
+			resp := it.Get(t, "/users/getReview?user_id="+userID)
+			var reviews struct {
+				PullRequests []any `json:"pull_requests"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+			resp.Body.Close()
+			assert.Empty(t, reviews.PullRequests, userID)
+		}
+		assert.Empty(t, it.understaffed(t))
+	})
+
+	t.Run("Unknown author → 404", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/previewAssignment", map[string]any{"author_id": "ghost"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
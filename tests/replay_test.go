@@ -0,0 +1,93 @@
+// tests/replay_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayAssignment(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "replay",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "Reviewer 1", "is_active": true},
+			{"user_id": "r2", "username": "Reviewer 2", "is_active": true},
+			{"user_id": "r3", "username": "Reviewer 3", "is_active": true},
+			{"user_id": "r4", "username": "Reviewer 4", "is_active": true},
+		},
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-replay-1",
+		"pull_request_name": "Feature",
+		"author_id":         "author",
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = it.Post(t, "/pullRequest/addReviewer", map[string]any{"pull_request_id": "pr-replay-1", "user_id": "r4"})
+	resp.Body.Close()
+
+	resp = it.Get(t, "/pullRequest/assignmentHistory?pull_request_id=pr-replay-1")
+	var history struct {
+		Events []struct {
+			EventID     int64    `json:"event_id"`
+			Kind        string   `json:"kind"`
+			Reviewers   []string `json:"reviewers"`
+			Explanation struct {
+				Snapshot *struct {
+					Seed int64 `json:"seed"`
+				} `json:"snapshot"`
+			} `json:"explanation"`
+		} `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Len(t, history.Events, 2)
+	created, added := history.Events[0], history.Events[1]
+
+	t.Run("Seed is recorded with the assignment", func(t *testing.T) {
+		require.NotNil(t, created.Explanation.Snapshot)
+		assert.Nil(t, added.Explanation.Snapshot, "manual assignment has no snapshot")
+	})
+
+	t.Run("Replay reproduces the recorded choice", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp := it.Post(t, "/pullRequest/replayAssignment", map[string]any{"event_id": created.EventID})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var replay struct {
+				Seed     int64 `json:"seed"`
+				Matches  bool  `json:"matches"`
+				Replayed struct {
+					Reviewers []string `json:"reviewers"`
+				} `json:"replayed"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&replay))
+			resp.Body.Close()
+
+			assert.True(t, replay.Matches)
+			assert.Equal(t, created.Explanation.Snapshot.Seed, replay.Seed)
+			assert.Equal(t, created.Reviewers, replay.Replayed.Reviewers)
+		}
+	})
+
+	t.Run("Manual event cannot be replayed → 400", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/replayAssignment", map[string]any{"event_id": added.EventID})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Unknown event → 404", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/replayAssignment", map[string]any{"event_id": 999999})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,144 @@
+// tests/repositories_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositories(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "repo-team",
+		"members": []map[string]any{
+			{"user_id": "ra", "username": "Author", "is_active": true},
+			{"user_id": "rb", "username": "B", "is_active": true},
+			{"user_id": "rc", "username": "C", "is_active": true},
+		},
+	})
+
+	type createResponse struct {
+		PR struct {
+			Repository        string   `json:"repository"`
+			PullRequestID     string   `json:"pull_request_id"`
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+		Strategy string `json:"strategy"`
+	}
+	createPR := func(t *testing.T, repository, prID string) *http.Response {
+		return it.Post(t, "/pullRequest/create", map[string]any{
+			"repository":        repository,
+			"pull_request_id":   prID,
+			"pull_request_name": "Change " + prID,
+			"author_id":         "ra",
+		})
+	}
+
+	t.Run("Repository is registered once", func(t *testing.T) {
+		resp := it.Post(t, "/repository/add", map[string]any{
+			"repository": "backend",
+			"owner_team": "repo-team",
+			"policy":     map[string]any{"strategy": "affinity"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Post(t, "/repository/add", map[string]any{"repository": "frontend", "owner_team": "repo-team"})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Post(t, "/repository/add", map[string]any{"repository": "backend"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = it.Post(t, "/repository/add", map[string]any{"repository": "orphan", "owner_team": "ghost"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Repositories are listed by owner team", func(t *testing.T) {
+		resp := it.Get(t, "/repository/list?team_name=repo-team")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			Repositories []struct {
+				Repository string `json:"repository"`
+			} `json:"repositories"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Repositories, 2)
+		assert.Equal(t, "backend", body.Repositories[0].Repository)
+	})
+
+	t.Run("Same PR id is allowed in different repositories", func(t *testing.T) {
+		for _, repository := range []string{"backend", "frontend"} {
+			resp := createPR(t, repository, "42")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var body createResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, repository, body.PR.Repository)
+		}
+
+		resp := createPR(t, "backend", "42")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = createPR(t, "unknown", "42")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Repository policy overrides the team strategy", func(t *testing.T) {
+		resp := createPR(t, "backend", "43")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body createResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "affinity", body.Strategy)
+
+		resp = createPR(t, "frontend", "43")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "random", body.Strategy)
+	})
+
+	t.Run("Merge touches only the PR of its repository", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/merge", map[string]any{"repository": "backend", "pull_request_id": "42"})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/stats/prs?repository=frontend")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var stats struct {
+			OpenPRs   int `json:"open_prs"`
+			MergedPRs int `json:"merged_prs"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+		assert.Equal(t, 2, stats.OpenPRs)
+		assert.Equal(t, 0, stats.MergedPRs)
+	})
+
+	t.Run("Reviews are filtered by repository", func(t *testing.T) {
+		resp := it.Get(t, "/users/getReview?user_id=rb&repository=frontend")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			PullRequests []struct {
+				Repository string `json:"repository"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.NotEmpty(t, body.PullRequests)
+		for _, pr := range body.PullRequests {
+			assert.Equal(t, "frontend", pr.Repository)
+		}
+	})
+}
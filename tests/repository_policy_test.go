@@ -0,0 +1,127 @@
+// tests/repository_policy_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryPolicy(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "mono",
+		"members": []map[string]any{
+			{"user_id": "ma", "username": "Author", "is_active": true},
+			{"user_id": "m1", "username": "M1", "is_active": true},
+			{"user_id": "m2", "username": "M2", "is_active": true},
+			{"user_id": "m3", "username": "M3", "is_active": true},
+			{"user_id": "mi", "username": "Intern", "is_active": true, "seniority": "intern"},
+		},
+	})
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "security",
+		"members": []map[string]any{
+			{"user_id": "s1", "username": "Sec", "is_active": true},
+		},
+	})
+	resp := it.Post(t, "/team/rules/add", map[string]any{
+		"team_name": "mono", "kind": "seniority_path", "subject": "intern", "target": "vendor/",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	addRepository := func(t *testing.T, name string, policy map[string]any) *http.Response {
+		return it.Post(t, "/repository/add", map[string]any{"repository": name, "owner_team": "mono", "policy": policy})
+	}
+	type createResponse struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	createPR := func(t *testing.T, repository, prID string) createResponse {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"repository":        repository,
+			"pull_request_id":   prID,
+			"pull_request_name": "Change " + prID,
+			"author_id":         "ma",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body createResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("Invalid overrides are rejected", func(t *testing.T) {
+		resp := addRepository(t, "bad", map[string]any{"reviewers_count": 0})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = addRepository(t, "bad", map[string]any{"required_teams": []string{"ghost"}})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Reviewers count overrides the default", func(t *testing.T) {
+		resp := addRepository(t, "monorepo", map[string]any{"reviewers_count": 3})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp = addRepository(t, "tiny-lib", map[string]any{"reviewers_count": 1})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		assert.Len(t, createPR(t, "monorepo", "1").PR.AssignedReviewers, 3)
+		assert.Len(t, createPR(t, "tiny-lib", "1").PR.AssignedReviewers, 1)
+		assert.Len(t, createPR(t, "", "repo-policy-1").PR.AssignedReviewers, 2)
+	})
+
+	t.Run("Required team supplies a reviewer", func(t *testing.T) {
+		resp := addRepository(t, "auth-service", map[string]any{"required_teams": []string{"security"}})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		created := createPR(t, "auth-service", "1")
+		assert.Len(t, created.PR.AssignedReviewers, 2)
+		assert.Contains(t, created.PR.AssignedReviewers, "s1")
+
+		resp = it.Post(t, "/pullRequest/removeReviewer", map[string]any{
+			"repository": "auth-service", "pull_request_id": "1", "user_id": "s1",
+		})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Excluded paths do not trigger rules", func(t *testing.T) {
+		// Правило запрещает intern'у vendor/, пока путь не исключен репозиторием
+		resp := addRepository(t, "vendored", map[string]any{"excluded_paths": []string{"/vendor"}})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Post(t, "/pullRequest/create", map[string]any{
+			"repository":        "monorepo",
+			"pull_request_id":   "2",
+			"pull_request_name": "Bump deps",
+			"author_id":         "ma",
+			"paths":             []string{"vendor/lib/a.go"},
+			"reviewers":         []string{"mi"},
+		})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = it.Post(t, "/pullRequest/create", map[string]any{
+			"repository":        "vendored",
+			"pull_request_id":   "2",
+			"pull_request_name": "Bump deps",
+			"author_id":         "ma",
+			"paths":             []string{"vendor/lib/a.go"},
+			"reviewers":         []string{"mi"},
+		})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
@@ -0,0 +1,190 @@
+// tests/roster_test.go
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRosterImportExport(t *testing.T) {
+	it := New(t)
+
+	type importReport struct {
+		Rows []struct {
+			Row    int    `json:"row"`
+			UserID string `json:"user_id"`
+			Action string `json:"action"`
+			Error  string `json:"error"`
+		} `json:"rows"`
+		CreatedTeams []string `json:"created_teams"`
+		Errors       int      `json:"errors"`
+		Applied      bool     `json:"applied"`
+	}
+	upload := func(t *testing.T, endpoint, body string) (int, importReport) {
+		t.Helper()
+		resp, err := it.client.Post(baseURL+endpoint, "text/csv", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var report importReport
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		return resp.StatusCode, report
+	}
+
+	roster := "user_id,username,team_name,is_active\n" +
+		"csv-1,Alice,csv-team,true\n" +
+		"csv-2,Bob,csv-team,true\n" +
+		"csv-3,Carol,,false\n"
+
+	t.Run("Dry run reports actions without applying", func(t *testing.T) {
+		status, report := upload(t, "/users/import?dry_run=true", roster)
+		require.Equal(t, http.StatusOK, status)
+		assert.False(t, report.Applied)
+		assert.Equal(t, []string{"csv-team"}, report.CreatedTeams)
+		require.Len(t, report.Rows, 3)
+		for _, row := range report.Rows {
+			assert.Equal(t, "create", row.Action)
+		}
+
+		resp := it.Get(t, "/team/get?team_name=csv-team")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Row errors reject the whole file", func(t *testing.T) {
+		status, report := upload(t, "/users/import", "user_id,username,team_name,is_active\n"+
+			"csv-1,Alice,csv-team,true\n"+
+			"csv-2,Bob,csv-team,maybe\n"+
+			"csv-1,Alice,csv-team,true\n"+
+			"csv-4,Dan\n")
+		require.Equal(t, http.StatusBadRequest, status)
+		assert.False(t, report.Applied)
+		assert.Equal(t, 3, report.Errors)
+		require.Len(t, report.Rows, 4)
+		assert.Empty(t, report.Rows[0].Error)
+		assert.Equal(t, 3, report.Rows[1].Row)
+		assert.NotEmpty(t, report.Rows[1].Error)
+		assert.Contains(t, report.Rows[2].Error, "row 2")
+		assert.NotEmpty(t, report.Rows[3].Error)
+
+		resp := it.Get(t, "/team/get?team_name=csv-team")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Missing column is rejected", func(t *testing.T) {
+		resp, err := it.client.Post(baseURL+"/users/import", "text/csv", strings.NewReader("user_id,username\ncsv-1,Alice\n"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Import applies the roster", func(t *testing.T) {
+		status, report := upload(t, "/users/import", roster)
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, report.Applied)
+
+		resp := it.Get(t, "/team/get?team_name=csv-team")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		assert.Len(t, team.Members, 2)
+	})
+
+	t.Run("Reimport detects moves and updates", func(t *testing.T) {
+		reimport := "user_id,username,team_name,is_active\n" +
+			"csv-1,Alice,csv-team,true\n" +
+			"csv-2,Bob,csv-other,true\n" +
+			"csv-3,Caroline,,false\n"
+
+		// Без allow_move смена команды — ошибка строки
+		status, report := upload(t, "/users/import", reimport)
+		require.Equal(t, http.StatusBadRequest, status)
+		assert.False(t, report.Applied)
+		assert.Equal(t, 1, report.Errors)
+		require.Len(t, report.Rows, 3)
+		assert.Equal(t, "csv-2", report.Rows[1].UserID)
+		assert.Contains(t, report.Rows[1].Error, "allow_move")
+
+		status, report = upload(t, "/users/import?allow_move=true", reimport)
+		require.Equal(t, http.StatusOK, status)
+		actions := make(map[string]string)
+		for _, row := range report.Rows {
+			actions[row.UserID] = row.Action
+		}
+		assert.Equal(t, map[string]string{"csv-1": "unchanged", "csv-2": "move", "csv-3": "update"}, actions)
+		assert.Equal(t, []string{"csv-other"}, report.CreatedTeams)
+	})
+
+	t.Run("Export returns the roster as CSV", func(t *testing.T) {
+		resp := it.Get(t, "/users/export")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/csv")
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.NotEmpty(t, records)
+		assert.Equal(t, []string{"user_id", "username", "team_name", "is_active"}, records[0])
+		rows := make(map[string][]string)
+		for _, record := range records[1:] {
+			rows[record[0]] = record
+		}
+		assert.Equal(t, []string{"csv-2", "Bob", "csv-other", "true"}, rows["csv-2"])
+		assert.Equal(t, []string{"csv-3", "Caroline", "", "false"}, rows["csv-3"])
+	})
+
+	t.Run("Moves need the right to change both teams", func(t *testing.T) {
+		// Участник без роли не переводит коллегу из своей команды
+		req, err := http.NewRequest(http.MethodPost, baseURL+"/users/import?allow_move=true", strings.NewReader(
+			"user_id,username,team_name,is_active\ncsv-1,Alice,csv-other,true\n"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("X-API-Key", it.UserKey(t, "csv-1"))
+		resp, err := it.client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.Get(t, "/users/moves?user_id=csv-1")
+		defer resp.Body.Close()
+		var history struct {
+			Moves []struct{} `json:"moves"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+		assert.Empty(t, history.Moves)
+	})
+
+	t.Run("Move to no team is recorded", func(t *testing.T) {
+		status, report := upload(t, "/users/import?allow_move=true", "user_id,username,team_name,is_active\n"+
+			"csv-2,Bob,,true\n")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, report.Rows, 1)
+		assert.Equal(t, "move", report.Rows[0].Action)
+
+		resp := it.Get(t, "/users/moves?user_id=csv-2")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var history struct {
+			Moves []struct {
+				FromTeam string `json:"from_team"`
+				ToTeam   string `json:"to_team"`
+			} `json:"moves"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+		require.NotEmpty(t, history.Moves)
+		last := history.Moves[len(history.Moves)-1]
+		assert.Equal(t, "csv-other", last.FromTeam)
+		assert.Empty(t, last.ToTeam)
+	})
+}
@@ -0,0 +1,113 @@
+// tests/rules_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusionRules(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "platform",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "spouse", "username": "Spouse", "is_active": true},
+			{"user_id": "intern", "username": "Intern", "is_active": true, "seniority": "intern"},
+			{"user_id": "r1", "username": "Reviewer 1", "is_active": true},
+		},
+	})
+
+	for _, rule := range []map[string]any{
+		{"team_name": "platform", "kind": "reviewer_author", "subject": "spouse", "target": "author"},
+		{"team_name": "platform", "kind": "seniority_path", "subject": "intern", "target": "migrations/"},
+	} {
+		resp := it.Post(t, "/team/rules/add", rule)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	type createdPR struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+
+	t.Run("Rules filter automatic assignment", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-rules-1",
+			"pull_request_name": "Schema change",
+			"author_id":         "author",
+			"paths":             []string{"migrations/00042_add_column.sql"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created createdPR
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Equal(t, []string{"r1"}, created.PR.AssignedReviewers)
+	})
+
+	t.Run("Requested reviewer violating a rule → 409 RULE_VIOLATION", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-rules-2",
+			"pull_request_name": "Feature",
+			"author_id":         "author",
+			"reviewers":         []string{"spouse"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.Equal(t, "RULE_VIOLATION", errResp.Error.Code)
+	})
+
+	t.Run("Reassign never picks an excluded reviewer", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/reassign", map[string]any{
+			"pull_request_id": "pr-rules-1",
+			"old_user_id":     "r1",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Deleted rule no longer applies", func(t *testing.T) {
+		resp := it.Get(t, "/team/rules?team_name=platform")
+		var listed struct {
+			Rules []struct {
+				RuleID int64  `json:"rule_id"`
+				Kind   string `json:"kind"`
+			} `json:"rules"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+		resp.Body.Close()
+		require.Len(t, listed.Rules, 2)
+
+		resp = it.Post(t, "/team/rules/delete", map[string]any{"rule_id": listed.Rules[0].RuleID})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-rules-3",
+			"pull_request_name": "Feature",
+			"author_id":         "author",
+			"reviewers":         []string{"spouse"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created createdPR
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Contains(t, created.PR.AssignedReviewers, "spouse")
+	})
+}
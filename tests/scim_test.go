@@ -0,0 +1,239 @@
+// tests/scim_test.go
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScimProvisioning(t *testing.T) {
+	it := New(t)
+
+	// SCIM-токен заводится напрямую в БД, как это делает cmd/orgs -scim
+	const scimToken = "prk_default-scim-token"
+	hash := sha256.Sum256([]byte(scimToken))
+	_, err := it.db.Exec(context.Background(),
+		`INSERT INTO organization_api_keys (key_hash, org_id, scope) VALUES ($1, 'default', 'scim')`,
+		hex.EncodeToString(hash[:]))
+	require.NoError(t, err)
+
+	sendWith := func(t *testing.T, token, method, endpoint string, body any) *http.Response {
+		t.Helper()
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req, err := http.NewRequest(method, baseURL+endpoint, bytes.NewBuffer(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/scim+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "%s %s failed", method, endpoint)
+		return resp
+	}
+	send := func(t *testing.T, method, endpoint string, body any) *http.Response {
+		t.Helper()
+		return sendWith(t, scimToken, method, endpoint, body)
+	}
+	createUser := func(t *testing.T, userName string) {
+		resp := send(t, http.MethodPost, "/scim/v2/Users", map[string]any{
+			"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+			"userName": userName,
+			"name":     map[string]any{"givenName": "User", "familyName": userName},
+			"emails":   []map[string]any{{"value": userName + "@example.com", "primary": true}},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	for _, userName := range []string{"author", "r1", "r2", "r3"} {
+		createUser(t, userName)
+	}
+
+	t.Run("Duplicate user is a uniqueness conflict", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/scim/v2/Users", map[string]any{"userName": "r1"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var body struct {
+			ScimType string `json:"scimType"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "uniqueness", body.ScimType)
+	})
+
+	t.Run("Group becomes a team with members", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/scim/v2/Groups", map[string]any{
+			"displayName": "scim-team",
+			"members":     []map[string]any{{"value": "author"}, {"value": "r1"}, {"value": "r2"}, {"value": "r3"}},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Get(t, "/team/get?team_name=scim-team")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		assert.Len(t, team.Members, 4)
+	})
+
+	t.Run("List filters by userName", func(t *testing.T) {
+		resp := send(t, http.MethodGet, `/scim/v2/Users?filter=userName%20eq%20%22r2%22`, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var list struct {
+			TotalResults int `json:"totalResults"`
+			Resources    []struct {
+				ID     string `json:"id"`
+				Emails []struct {
+					Value string `json:"value"`
+				} `json:"emails"`
+			} `json:"Resources"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		require.Equal(t, 1, list.TotalResults)
+		assert.Equal(t, "r2", list.Resources[0].ID)
+		assert.Equal(t, "r2@example.com", list.Resources[0].Emails[0].Value)
+	})
+
+	var reviewers []string
+	t.Run("Deactivation hands over reviews", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-scim-1",
+			"pull_request_name": "Provisioned",
+			"author_id":         "author",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		require.Len(t, created.PR.AssignedReviewers, 2)
+		reviewers = created.PR.AssignedReviewers
+
+		resp = send(t, http.MethodPatch, "/scim/v2/Users/"+reviewers[0], map[string]any{
+			"schemas":    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+			"Operations": []map[string]any{{"op": "Replace", "path": "active", "value": "False"}},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var user struct {
+			Active bool `json:"active"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+		assert.False(t, user.Active)
+
+		resp = it.Get(t, "/users/getReview?user_id="+reviewers[0])
+		defer resp.Body.Close()
+		var reviews struct {
+			PullRequests []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+		assert.Empty(t, reviews.PullRequests)
+	})
+
+	t.Run("Deprovisioning offboards the user", func(t *testing.T) {
+		resp := send(t, http.MethodDelete, "/scim/v2/Users/"+reviewers[1], nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/scim/v2/Users/"+reviewers[1], nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = it.Get(t, "/users/getReview?user_id="+reviewers[1])
+		defer resp.Body.Close()
+		var reviews struct {
+			PullRequests []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+		assert.Empty(t, reviews.PullRequests)
+	})
+
+	t.Run("Group with open PRs is not deleted", func(t *testing.T) {
+		// У автора открыт pr-scim-1: удаление отклоняется, как /team/delete с политикой reject
+		resp := send(t, http.MethodDelete, "/scim/v2/Groups/scim-team", nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/scim/v2/Groups/scim-team", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Group patch removes a member", func(t *testing.T) {
+		resp := send(t, http.MethodPatch, "/scim/v2/Groups/scim-team", map[string]any{
+			"Operations": []map[string]any{{"op": "remove", "path": `members[value eq "author"]`}},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var group struct {
+			Members []struct {
+				Value string `json:"value"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&group))
+		for _, member := range group.Members {
+			assert.NotEqual(t, "author", member.Value)
+		}
+	})
+
+	t.Run("Group delete removes the team", func(t *testing.T) {
+		resp := send(t, http.MethodDelete, "/scim/v2/Groups/scim-team", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/scim/v2/Groups/scim-team", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("SCIM requires a SCIM token", func(t *testing.T) {
+		// Без токена, с ключом обычного API и с неизвестным токеном SCIM закрыт
+		resp := sendWith(t, "", http.MethodGet, "/scim/v2/Users", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = sendWith(t, testAPIKey, http.MethodGet, "/scim/v2/Users", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = sendWith(t, "prk_unknown", http.MethodGet, "/scim/v2/Users", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/scim/v2/Users", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// SCIM-токен не открывает обычный API
+		resp = sendWith(t, scimToken, http.MethodGet, "/team/get?team_name=scim-team", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
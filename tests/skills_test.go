@@ -0,0 +1,80 @@
+// tests/skills_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkillMatchedAssignment(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "platform",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "gopher", "username": "Gopher", "is_active": true, "skills": []string{"go"}},
+			{"user_id": "dba", "username": "DBA", "is_active": true, "skills": []string{"Postgres", "go"}},
+			{"user_id": "fe", "username": "Frontend", "is_active": true, "skills": []string{"frontend"}},
+		},
+		"policy": map[string]any{"strategy": "skills"},
+	})
+
+	t.Run("Reviewers with matching skills are preferred", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-skills-1",
+			"pull_request_name": "Migrate storage",
+			"author_id":         "author",
+			"labels":            []string{"go", "postgres"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+			Strategy       string `json:"strategy"`
+			ReviewerScores []struct {
+				UserID    string             `json:"user_id"`
+				Breakdown map[string]float64 `json:"breakdown"`
+			} `json:"reviewer_scores"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.ElementsMatch(t, []string{"gopher", "dba"}, result.PR.AssignedReviewers)
+		assert.Equal(t, "skills", result.Strategy)
+		require.Len(t, result.ReviewerScores, 2)
+		for _, score := range result.ReviewerScores {
+			if score.UserID == "dba" {
+				assert.Equal(t, 2.0, score.Breakdown["skill_match"])
+			}
+		}
+	})
+
+	t.Run("Set skills", func(t *testing.T) {
+		resp := it.Post(t, "/users/setSkills", map[string]any{"user_id": "fe", "skills": []string{"React", " frontend ", "react"}})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			User struct {
+				Skills []string `json:"skills"`
+			} `json:"user"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, []string{"frontend", "react"}, result.User.Skills)
+	})
+
+	t.Run("Unknown strategy → 400", func(t *testing.T) {
+		resp := it.Post(t, "/team/setPolicy", map[string]any{
+			"team_name": "platform",
+			"policy":    map[string]any{"strategy": "coin-flip"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,114 @@
+// tests/staffing_queue_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type queuedPR struct {
+	PullRequestID    string `json:"pull_request_id"`
+	MissingReviewers int    `json:"missing_reviewers"`
+	Reason           string `json:"reason"`
+}
+
+// understaffed возвращает очередь добора ревьюверов по ID PR
+func (it *IntegrationTest) understaffed(t *testing.T) map[string]queuedPR {
+	t.Helper()
+	resp := it.Get(t, "/pullRequest/understaffed")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		PullRequests []queuedPR `json:"pull_requests"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+
+	queue := make(map[string]queuedPR, len(result.PullRequests))
+	for _, q := range result.PullRequests {
+		queue[q.PullRequestID] = q
+	}
+	return queue
+}
+
+func TestStaffingQueue(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "search",
+		"members": []map[string]any{
+			{"user_id": "author", "username": "Author", "is_active": true},
+			{"user_id": "r1", "username": "R1", "is_active": false},
+			{"user_id": "r2", "username": "R2", "is_active": false},
+		},
+	})
+
+	t.Run("PR without candidates is queued", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-queue-1",
+			"pull_request_name": "Lonely",
+			"author_id":         "author",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			PR struct {
+				Understaffed bool `json:"understaffed"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.PR.Understaffed)
+
+		queued := it.understaffed(t)
+		require.Contains(t, queued, "pr-queue-1")
+		assert.Equal(t, 2, queued["pr-queue-1"].MissingReviewers)
+		assert.Equal(t, "NO_CANDIDATE", queued["pr-queue-1"].Reason)
+	})
+
+	t.Run("Reactivated users are assigned by the worker", func(t *testing.T) {
+		for _, id := range []string{"r1", "r2"} {
+			resp := it.Post(t, "/users/setIsActive", map[string]any{"user_id": id, "is_active": true})
+			resp.Body.Close()
+		}
+
+		require.Eventually(t, func() bool {
+			_, queued := it.understaffed(t)["pr-queue-1"]
+			return !queued
+		}, 5*time.Second, 100*time.Millisecond)
+
+		resp := it.Get(t, "/users/getReview?user_id=r2")
+		defer resp.Body.Close()
+		var reviews struct {
+			PullRequests []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+		require.Len(t, reviews.PullRequests, 1)
+		assert.Equal(t, "pr-queue-1", reviews.PullRequests[0].PullRequestID)
+	})
+
+	t.Run("Merged PR leaves the queue", func(t *testing.T) {
+		it.Post(t, "/team/add", map[string]any{
+			"team_name": "solo",
+			"members":   []map[string]any{{"user_id": "solo", "username": "Solo", "is_active": true}},
+		})
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-queue-2",
+			"pull_request_name": "Solo work",
+			"author_id":         "solo",
+		})
+		resp.Body.Close()
+		require.Contains(t, it.understaffed(t), "pr-queue-2")
+
+		resp = it.Post(t, "/pullRequest/merge", map[string]any{"pull_request_id": "pr-queue-2"})
+		resp.Body.Close()
+		assert.NotContains(t, it.understaffed(t), "pr-queue-2")
+	})
+}
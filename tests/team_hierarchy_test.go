@@ -0,0 +1,163 @@
+// tests/team_hierarchy_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamHierarchy(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "platform",
+		"members": []map[string]any{
+			{"user_id": "p1", "username": "P1", "is_active": true},
+			{"user_id": "p2", "username": "P2", "is_active": true},
+		},
+	})
+	resp := it.Post(t, "/team/add", map[string]any{
+		"team_name":   "platform-db",
+		"parent_team": "platform",
+		"members": []map[string]any{
+			{"user_id": "d1", "username": "D1", "is_active": true},
+			{"user_id": "d2", "username": "D2", "is_active": true},
+		},
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Unknown parent → 404", func(t *testing.T) {
+		resp := it.Post(t, "/team/add", map[string]any{
+			"team_name":   "orphan",
+			"parent_team": "missing",
+			"members":     []map[string]any{},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Cycles are rejected", func(t *testing.T) {
+		resp := it.Post(t, "/team/setParent", map[string]any{"team_name": "platform", "parent_team": "platform-db"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Assignment escalates to the parent team", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create?explain=true", map[string]any{
+			"pull_request_id":   "pr-db-1",
+			"pull_request_name": "Index",
+			"author_id":         "d1",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var body struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+			Explanation struct {
+				EscalatedTo []string `json:"escalated_to"`
+			} `json:"explanation"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.PR.AssignedReviewers, 2)
+		assert.Contains(t, body.PR.AssignedReviewers, "d2")
+		assert.Equal(t, []string{"platform"}, body.Explanation.EscalatedTo)
+	})
+
+	t.Run("Author team rules apply to escalated candidates", func(t *testing.T) {
+		// Правило команды автора запрещает p1 из родительской команды ревьюить d2
+		resp := it.Post(t, "/team/rules/add", map[string]any{
+			"team_name": "platform-db", "kind": "reviewer_author", "subject": "p1", "target": "d2",
+		})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Post(t, "/pullRequest/create?explain=true", map[string]any{
+			"pull_request_id":   "pr-db-2",
+			"pull_request_name": "Vacuum",
+			"author_id":         "d2",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var body struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+			Explanation struct {
+				EscalatedTo []string `json:"escalated_to"`
+				Excluded    []struct {
+					UserID string `json:"user_id"`
+					Reason string `json:"reason"`
+				} `json:"excluded"`
+			} `json:"explanation"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.ElementsMatch(t, []string{"d1", "p2"}, body.PR.AssignedReviewers)
+		assert.Equal(t, []string{"platform"}, body.Explanation.EscalatedTo)
+		reasons := map[string]string{}
+		for _, ex := range body.Explanation.Excluded {
+			reasons[ex.UserID] = ex.Reason
+		}
+		assert.Equal(t, "rule", reasons["p1"])
+
+		// Ручное назначение проверяет те же правила
+		resp = it.Post(t, "/pullRequest/addReviewer", map[string]any{"pull_request_id": "pr-db-2", "user_id": "p1"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "RULE_VIOLATION", errResp.Error.Code)
+	})
+
+	t.Run("Subtree is returned on request", func(t *testing.T) {
+		resp := it.Get(t, "/team/get?team_name=platform&subtree=true")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Subteams []struct {
+				TeamName   string `json:"team_name"`
+				ParentTeam string `json:"parent_team"`
+			} `json:"subteams"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Subteams, 1)
+		assert.Equal(t, "platform-db", body.Subteams[0].TeamName)
+		assert.Equal(t, "platform", body.Subteams[0].ParentTeam)
+	})
+
+	t.Run("Stats roll up through the hierarchy", func(t *testing.T) {
+		resp := it.Get(t, "/stats/teams?team_name=platform")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		type counters struct {
+			Members     int `json:"members"`
+			AuthoredPRs int `json:"authored_prs"`
+		}
+		var body struct {
+			TeamStats []struct {
+				TeamName string   `json:"team_name"`
+				Own      counters `json:"own"`
+				Total    counters `json:"total"`
+			} `json:"team_stats"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.TeamStats, 2)
+		assert.Equal(t, "platform", body.TeamStats[0].TeamName)
+		assert.Equal(t, 0, body.TeamStats[0].Own.AuthoredPRs)
+		assert.Equal(t, 1, body.TeamStats[0].Total.AuthoredPRs)
+		assert.Equal(t, 4, body.TeamStats[0].Total.Members)
+	})
+}
@@ -0,0 +1,121 @@
+// tests/team_lifecycle_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamUpdateAndDelete(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "alpha",
+		"members": []map[string]any{
+			{"user_id": "a1", "username": "A1", "is_active": true},
+			{"user_id": "a2", "username": "A2", "is_active": true},
+			{"user_id": "a3", "username": "A3", "is_active": true},
+		},
+	})
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "taken",
+		"members":   []map[string]any{{"user_id": "t1", "username": "T1", "is_active": true}},
+	})
+	it.Post(t, "/team/rules/add", map[string]any{"team_name": "alpha", "kind": "reviewer_author", "subject": "a2", "target": "a1"})
+
+	type teamResponse struct {
+		Team struct {
+			TeamName string `json:"team_name"`
+			Members  []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		} `json:"team"`
+	}
+	memberIDs := func(resp teamResponse) []string {
+		var ids []string
+		for _, m := range resp.Team.Members {
+			ids = append(ids, m.UserID)
+		}
+		return ids
+	}
+
+	t.Run("Rename to an existing team → 409", func(t *testing.T) {
+		resp := it.Post(t, "/team/update", map[string]any{"team_name": "alpha", "new_team_name": "taken"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Rename cascades to members and rules", func(t *testing.T) {
+		resp := it.Post(t, "/team/update", map[string]any{
+			"team_name":      "alpha",
+			"new_team_name":  "beta",
+			"add_members":    []map[string]any{{"user_id": "b4", "username": "B4", "is_active": true}},
+			"remove_members": []string{"a3"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body teamResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "beta", body.Team.TeamName)
+		assert.ElementsMatch(t, []string{"a1", "a2", "b4"}, memberIDs(body))
+
+		resp = it.Get(t, "/team/get?team_name=alpha")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = it.Get(t, "/team/rules?team_name=beta")
+		var rules struct {
+			Rules []any `json:"rules"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rules))
+		resp.Body.Close()
+		assert.Len(t, rules.Rules, 1)
+	})
+
+	t.Run("Removing a non-member → 404", func(t *testing.T) {
+		resp := it.Post(t, "/team/update", map[string]any{"team_name": "beta", "remove_members": []string{"t1"}})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-beta-1",
+		"pull_request_name": "Feature",
+		"author_id":         "a1",
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Delete is rejected while members have open PRs", func(t *testing.T) {
+		resp := it.Post(t, "/team/delete", map[string]any{"team_name": "beta"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.Equal(t, "TEAM_HAS_OPEN_PRS", errResp.Error.Code)
+	})
+
+	t.Run("Detach deletes the team but keeps users and PRs", func(t *testing.T) {
+		resp := it.Post(t, "/team/delete", map[string]any{"team_name": "beta", "open_pr_policy": "detach"})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/team/get?team_name=beta")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = it.Post(t, "/pullRequest/merge", map[string]any{"pull_request_id": "pr-beta-1"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
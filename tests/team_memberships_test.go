@@ -0,0 +1,100 @@
+// tests/team_memberships_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamMemberships(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "web",
+		"members": []map[string]any{
+			{"user_id": "w1", "username": "W1", "is_active": true},
+			{"user_id": "w2", "username": "W2", "is_active": true},
+		},
+	})
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "staff",
+		"members":   []map[string]any{{"user_id": "s1", "username": "S1", "is_active": true}},
+	})
+
+	type membershipsResponse struct {
+		Teams []struct {
+			TeamName  string `json:"team_name"`
+			IsPrimary bool   `json:"is_primary"`
+		} `json:"teams"`
+	}
+	decodeTeams := func(t *testing.T, resp *http.Response) membershipsResponse {
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body membershipsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("Secondary membership makes the user a candidate", func(t *testing.T) {
+		body := decodeTeams(t, it.Post(t, "/users/addTeam", map[string]any{"user_id": "s1", "team_name": "web"}))
+		require.Len(t, body.Teams, 2)
+		assert.Equal(t, "staff", body.Teams[0].TeamName)
+		assert.True(t, body.Teams[0].IsPrimary)
+		assert.Equal(t, "web", body.Teams[1].TeamName)
+		assert.False(t, body.Teams[1].IsPrimary)
+
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-web-1",
+			"pull_request_name": "Page",
+			"author_id":         "w1",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.ElementsMatch(t, []string{"w2", "s1"}, created.PR.AssignedReviewers)
+	})
+
+	t.Run("Team listing marks secondary members", func(t *testing.T) {
+		resp := it.Get(t, "/team/get?team_name=web")
+		defer resp.Body.Close()
+		var team struct {
+			Members []struct {
+				UserID    string `json:"user_id"`
+				IsPrimary bool   `json:"is_primary"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		primary := make(map[string]bool)
+		for _, m := range team.Members {
+			primary[m.UserID] = m.IsPrimary
+		}
+		assert.Equal(t, map[string]bool{"w1": true, "w2": true, "s1": false}, primary)
+	})
+
+	t.Run("Primary team cannot be left directly", func(t *testing.T) {
+		resp := it.Post(t, "/users/removeTeam", map[string]any{"user_id": "s1", "team_name": "staff"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Switching the primary team keeps the old one as secondary", func(t *testing.T) {
+		body := decodeTeams(t, it.Post(t, "/users/setPrimaryTeam", map[string]any{"user_id": "s1", "team_name": "web"}))
+		require.Len(t, body.Teams, 2)
+		assert.Equal(t, "web", body.Teams[0].TeamName)
+		assert.True(t, body.Teams[0].IsPrimary)
+		assert.Equal(t, "staff", body.Teams[1].TeamName)
+
+		body = decodeTeams(t, it.Post(t, "/users/removeTeam", map[string]any{"user_id": "s1", "team_name": "staff"}))
+		require.Len(t, body.Teams, 1)
+		assert.Equal(t, "web", body.Teams[0].TeamName)
+	})
+}
@@ -0,0 +1,203 @@
+// tests/team_moves_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamMoves(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "old",
+		"members": []map[string]any{
+			{"user_id": "o1", "username": "O1", "is_active": true},
+			{"user_id": "o2", "username": "O2", "is_active": true},
+			{"user_id": "o3", "username": "O3", "is_active": true},
+			{"user_id": "o4", "username": "O4", "is_active": true},
+		},
+	})
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "new",
+		"members":   []map[string]any{{"user_id": "n1", "username": "N1", "is_active": true}},
+	})
+
+	t.Run("Team add rejects members of another team", func(t *testing.T) {
+		resp := it.Post(t, "/team/add", map[string]any{
+			"team_name": "other",
+			"members":   []map[string]any{{"user_id": "o4", "username": "O4", "is_active": true}},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.Equal(t, "USER_IN_OTHER_TEAM", errResp.Error.Code)
+	})
+
+	t.Run("allow_move moves the member and records it", func(t *testing.T) {
+		resp := it.Post(t, "/team/update", map[string]any{
+			"team_name":   "new",
+			"add_members": []map[string]any{{"user_id": "o4", "username": "O4", "is_active": true}},
+			"allow_move":  true,
+		})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/moves?user_id=o4")
+		defer resp.Body.Close()
+		var body struct {
+			Moves []struct {
+				FromTeam string `json:"from_team"`
+				ToTeam   string `json:"to_team"`
+			} `json:"moves"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Moves, 1)
+		assert.Equal(t, "old", body.Moves[0].FromTeam)
+		assert.Equal(t, "new", body.Moves[0].ToTeam)
+	})
+
+	resp := it.Post(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr-move-1",
+		"pull_request_name": "Feature",
+		"author_id":         "o1",
+	})
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Len(t, created.PR.AssignedReviewers, 2)
+	mover := created.PR.AssignedReviewers[0]
+
+	t.Run("Move with handover reassigns open reviews in the old team", func(t *testing.T) {
+		resp := it.Post(t, "/users/move", map[string]any{
+			"user_id":          mover,
+			"team_name":        "new",
+			"handover_reviews": true,
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Move struct {
+				FromTeam  string `json:"from_team"`
+				Handovers []struct {
+					PullRequestID string `json:"pull_request_id"`
+					ReplacedBy    string `json:"replaced_by"`
+				} `json:"handovers"`
+			} `json:"move"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "old", body.Move.FromTeam)
+		require.Len(t, body.Move.Handovers, 1)
+		assert.Equal(t, "pr-move-1", body.Move.Handovers[0].PullRequestID)
+		assert.NotEmpty(t, body.Move.Handovers[0].ReplacedBy)
+		assert.NotEqual(t, mover, body.Move.Handovers[0].ReplacedBy)
+
+		resp = it.Get(t, "/team/get?team_name=new")
+		defer resp.Body.Close()
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		var ids []string
+		for _, m := range team.Members {
+			ids = append(ids, m.UserID)
+		}
+		assert.Contains(t, ids, mover)
+	})
+
+	t.Run("Moving into the current team → 400", func(t *testing.T) {
+		resp := it.Post(t, "/users/move", map[string]any{"user_id": "n1", "team_name": "new"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Moving into a missing team → 404", func(t *testing.T) {
+		resp := it.Post(t, "/users/move", map[string]any{"user_id": "n1", "team_name": "missing"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestTeamRemoveMembers(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "crew",
+		"members": []map[string]any{
+			{"user_id": "c1", "username": "C1", "is_active": true},
+			{"user_id": "c2", "username": "C2", "is_active": true},
+			{"user_id": "c3", "username": "C3", "is_active": true},
+			{"user_id": "c4", "username": "C4", "is_active": true},
+		},
+	})
+
+	t.Run("Removing a member hands over reviews and records a move to no team", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/create", map[string]any{
+			"pull_request_id":   "pr-remove-1",
+			"pull_request_name": "Fix",
+			"author_id":         "c1",
+		})
+		var created struct {
+			PR struct {
+				AssignedReviewers []string `json:"assigned_reviewers"`
+			} `json:"pr"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		resp.Body.Close()
+		require.NotEmpty(t, created.PR.AssignedReviewers)
+		removed := created.PR.AssignedReviewers[0]
+
+		resp = it.Post(t, "/team/update", map[string]any{"team_name": "crew", "remove_members": []string{removed}})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/getReview?user_id="+removed)
+		var reviews struct {
+			PullRequests []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"pull_requests"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviews))
+		resp.Body.Close()
+		for _, pr := range reviews.PullRequests {
+			assert.NotEqual(t, "pr-remove-1", pr.PullRequestID)
+		}
+
+		resp = it.Get(t, "/users/moves?user_id="+removed)
+		defer resp.Body.Close()
+		var body struct {
+			Moves []struct {
+				FromTeam  string `json:"from_team"`
+				ToTeam    string `json:"to_team"`
+				Handovers []struct {
+					PullRequestID string `json:"pull_request_id"`
+					ReplacedBy    string `json:"replaced_by"`
+				} `json:"handovers"`
+			} `json:"moves"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.NotEmpty(t, body.Moves)
+		last := body.Moves[len(body.Moves)-1]
+		assert.Equal(t, "crew", last.FromTeam)
+		assert.Empty(t, last.ToTeam)
+		require.NotEmpty(t, last.Handovers)
+		assert.NotEmpty(t, last.Handovers[0].ReplacedBy)
+	})
+}
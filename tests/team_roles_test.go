@@ -0,0 +1,234 @@
+// tests/team_roles_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamRoles(t *testing.T) {
+	it := New(t)
+
+	resp := it.Post(t, "/team/add", map[string]any{
+		"team_name": "core",
+		"members": []map[string]any{
+			{"user_id": "a1", "username": "Author", "is_active": true},
+			{"user_id": "l1", "username": "Lead", "is_active": true, "role": "lead"},
+			{"user_id": "m1", "username": "M1", "is_active": true},
+			{"user_id": "m2", "username": "M2", "is_active": true},
+			{"user_id": "m3", "username": "M3", "is_active": true},
+		},
+		"policy": map[string]any{"require_lead": true},
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("Team listing shows roles", func(t *testing.T) {
+		resp := it.Get(t, "/team/get?team_name=core")
+		defer resp.Body.Close()
+		var team struct {
+			Members []struct {
+				UserID string `json:"user_id"`
+				Role   string `json:"role"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		roles := make(map[string]string)
+		for _, m := range team.Members {
+			roles[m.UserID] = m.Role
+		}
+		assert.Equal(t, "lead", roles["l1"])
+		assert.Equal(t, "member", roles["m1"])
+	})
+
+	t.Run("Lead is assigned to every PR", func(t *testing.T) {
+		for _, prID := range []string{"pr-lead-1", "pr-lead-2", "pr-lead-3"} {
+			resp := it.Post(t, "/pullRequest/create", map[string]any{
+				"pull_request_id":   prID,
+				"pull_request_name": "Change",
+				"author_id":         "a1",
+			})
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var created struct {
+				PR struct {
+					AssignedReviewers []string `json:"assigned_reviewers"`
+				} `json:"pr"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+			resp.Body.Close()
+			assert.Contains(t, created.PR.AssignedReviewers, "l1")
+		}
+	})
+
+	t.Run("Only lead cannot be reassigned to a member", func(t *testing.T) {
+		resp := it.Post(t, "/pullRequest/reassign", map[string]any{
+			"pull_request_id": "pr-lead-1",
+			"old_user_id":     "l1",
+		})
+		defer resp.Body.Close()
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	})
+
+	// Ключи, действующие от имени участников; роль определяется по ключу, а не по телу запроса
+	leadKey, memberKey := it.UserKey(t, "l1"), it.UserKey(t, "m1")
+
+	t.Run("Members cannot change membership of a led team", func(t *testing.T) {
+		resp := it.PostAs(t, memberKey, "/team/update", map[string]any{
+			"team_name":      "core",
+			"remove_members": []string{"m3"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.PostAs(t, memberKey, "/team/setRole", map[string]any{
+			"team_name": "core",
+			"user_id":   "m1",
+			"role":      "maintainer",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Lead grants maintainer who may change membership", func(t *testing.T) {
+		resp := it.PostAs(t, leadKey, "/team/setRole", map[string]any{
+			"team_name": "core",
+			"user_id":   "m1",
+			"role":      "maintainer",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.PostAs(t, memberKey, "/team/update", map[string]any{
+			"team_name":      "core",
+			"remove_members": []string{"m3"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Unknown role is rejected", func(t *testing.T) {
+		resp := it.PostAs(t, leadKey, "/team/setRole", map[string]any{
+			"team_name": "core",
+			"user_id":   "m2",
+			"role":      "owner",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Only an admin appoints the first lead", func(t *testing.T) {
+		resp := it.Post(t, "/team/add", map[string]any{
+			"team_name": "open",
+			"members": []map[string]any{
+				{"user_id": "o1", "username": "O1", "is_active": true},
+				{"user_id": "o2", "username": "O2", "is_active": true},
+			},
+		})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// Участник команды без lead'ов не назначит lead'ом себя и не поменяет состав
+		ownKey := it.UserKey(t, "o1")
+		resp = it.PostAs(t, ownKey, "/team/setRole", map[string]any{
+			"team_name": "open",
+			"user_id":   "o1",
+			"role":      "lead",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.PostAs(t, ownKey, "/team/update", map[string]any{
+			"team_name":      "open",
+			"remove_members": []string{"o2"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		// actor_id в теле запроса ничего не дает
+		resp = it.PostAs(t, ownKey, "/team/setRole", map[string]any{
+			"team_name": "open",
+			"user_id":   "o1",
+			"role":      "lead",
+			"actor_id":  "l1",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.Post(t, "/team/setRole", map[string]any{
+			"team_name": "open",
+			"user_id":   "o1",
+			"role":      "lead",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Policy, hierarchy and rules need team rights", func(t *testing.T) {
+		plainKey := it.UserKey(t, "m2")
+
+		resp := it.PostAs(t, plainKey, "/team/setPolicy", map[string]any{
+			"team_name": "core",
+			"policy":    map[string]any{"require_lead": false},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.PostAs(t, plainKey, "/team/setParent", map[string]any{"team_name": "core", "parent_team": ""})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		rule := map[string]any{"team_name": "core", "kind": "reviewer_author", "subject": "m2", "target": "a1"}
+		resp = it.PostAs(t, plainKey, "/team/rules/add", rule)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.PostAs(t, leadKey, "/team/rules/add", rule)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created struct {
+			Rule struct {
+				RuleID int64 `json:"rule_id"`
+			} `json:"rule"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+		resp = it.PostAs(t, plainKey, "/team/rules/delete", map[string]any{"rule_id": created.Rule.RuleID})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = it.PostAs(t, leadKey, "/team/rules/delete", map[string]any{"rule_id": created.Rule.RuleID})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Only an admin applies a team sync", func(t *testing.T) {
+		config := map[string]any{"teams": []map[string]any{{
+			"name":    "synced",
+			"members": []map[string]any{{"user_id": "s1", "username": "S1"}},
+		}}}
+
+		// План ничего не меняет и доступен всем
+		resp := it.PostAs(t, leadKey, "/team/sync", config)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.PostAs(t, leadKey, "/team/sync?apply=true", config)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Key cannot act on behalf of another user", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/team/get?team_name=core", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", memberKey)
+		req.Header.Set("X-Actor-ID", "l1")
+		resp, err := it.client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -0,0 +1,115 @@
+// tests/team_sync_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamSync(t *testing.T) {
+	it := New(t)
+
+	type syncResponse struct {
+		Changes []struct {
+			Action   string `json:"action"`
+			TeamName string `json:"team_name"`
+			UserID   string `json:"user_id"`
+		} `json:"changes"`
+		Applied bool `json:"applied"`
+	}
+	sync := func(t *testing.T, endpoint string, config map[string]any) syncResponse {
+		resp := it.Post(t, endpoint, config)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body syncResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	// Конфигурация в JSON — тоже корректный YAML
+	config := map[string]any{
+		"teams": []map[string]any{
+			{"name": "eng"},
+			{
+				"name":   "platform",
+				"parent": "eng",
+				"policy": map[string]any{"require_lead": true},
+				"members": []map[string]any{
+					{"user_id": "p1", "username": "P1", "role": "lead", "seniority": "senior"},
+					{"user_id": "p2", "username": "P2"},
+				},
+			},
+		},
+	}
+
+	t.Run("Plan does not change the database", func(t *testing.T) {
+		plan := sync(t, "/team/sync", config)
+		assert.False(t, plan.Applied)
+		actions := make(map[string]int)
+		for _, change := range plan.Changes {
+			actions[change.Action]++
+		}
+		assert.Equal(t, 2, actions["create_team"])
+		assert.Equal(t, 1, actions["set_parent"])
+		assert.Equal(t, 2, actions["add_member"])
+		assert.Equal(t, 1, actions["set_role"])
+
+		resp := it.Get(t, "/team/get?team_name=platform")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Apply creates teams, members and roles", func(t *testing.T) {
+		plan := sync(t, "/team/sync?apply=true", config)
+		assert.True(t, plan.Applied)
+
+		resp := it.Get(t, "/team/get?team_name=platform")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var team struct {
+			ParentTeam string `json:"parent_team"`
+			Members    []struct {
+				UserID string `json:"user_id"`
+				Role   string `json:"role"`
+			} `json:"members"`
+			Policy struct {
+				RequireLead bool `json:"require_lead"`
+			} `json:"policy"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+		assert.Equal(t, "eng", team.ParentTeam)
+		assert.True(t, team.Policy.RequireLead)
+		roles := make(map[string]string)
+		for _, m := range team.Members {
+			roles[m.UserID] = m.Role
+		}
+		assert.Equal(t, map[string]string{"p1": "lead", "p2": "member"}, roles)
+	})
+
+	t.Run("Applied config has no changes", func(t *testing.T) {
+		plan := sync(t, "/team/sync", config)
+		assert.Empty(t, plan.Changes)
+	})
+
+	t.Run("Members missing from the config are removed", func(t *testing.T) {
+		config["teams"].([]map[string]any)[1]["members"] = []map[string]any{
+			{"user_id": "p1", "username": "P1", "role": "lead", "seniority": "senior"},
+		}
+		plan := sync(t, "/team/sync?apply=true", config)
+		require.Len(t, plan.Changes, 1)
+		assert.Equal(t, "remove_member", plan.Changes[0].Action)
+		assert.Equal(t, "p2", plan.Changes[0].UserID)
+	})
+
+	t.Run("Invalid config is rejected", func(t *testing.T) {
+		resp := it.Post(t, "/team/sync", map[string]any{
+			"teams": []map[string]any{{"name": "x", "polcy": map[string]any{}}},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
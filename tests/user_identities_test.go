@@ -0,0 +1,127 @@
+// tests/user_identities_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserIdentities(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "ident",
+		"members": []map[string]any{
+			{"user_id": "i1", "username": "I1", "is_active": true},
+			{"user_id": "i2", "username": "I2", "is_active": true},
+		},
+	})
+
+	link := func(t *testing.T, userID, provider, externalID string) *http.Response {
+		return it.Post(t, "/users/identities/link", map[string]any{
+			"user_id": userID, "provider": provider, "external_id": externalID,
+		})
+	}
+	type resolveResponse struct {
+		Identities []struct {
+			UserID     string `json:"user_id"`
+			ExternalID string `json:"external_id"`
+		} `json:"identities"`
+		Unresolved []string `json:"unresolved"`
+	}
+
+	t.Run("Linked identities resolve case-insensitively", func(t *testing.T) {
+		resp := link(t, "i1", "github", "Octo-Cat")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = link(t, "i1", "slack", "U012ABC")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/resolve?provider=github&external_id=octo-cat&external_id=ghost")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body resolveResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Identities, 1)
+		assert.Equal(t, "i1", body.Identities[0].UserID)
+		assert.Equal(t, []string{"ghost"}, body.Unresolved)
+	})
+
+	t.Run("Identity of another user is not taken over", func(t *testing.T) {
+		resp := link(t, "i2", "github", "OCTO-CAT")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var body struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "IDENTITY_TAKEN", body.Error.Code)
+	})
+
+	t.Run("Relinking replaces the identity at the provider", func(t *testing.T) {
+		resp := link(t, "i1", "github", "octocat-new")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/identities?user_id=i1")
+		defer resp.Body.Close()
+		var body struct {
+			Identities []struct {
+				Provider   string `json:"provider"`
+				ExternalID string `json:"external_id"`
+			} `json:"identities"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		ids := make(map[string]string)
+		for _, identity := range body.Identities {
+			ids[identity.Provider] = identity.ExternalID
+		}
+		assert.Equal(t, map[string]string{"github": "octocat-new", "slack": "U012ABC"}, ids)
+
+		// Освободившийся логин можно привязать другому
+		resp = link(t, "i2", "github", "octo-cat")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Unknown provider is rejected", func(t *testing.T) {
+		resp := link(t, "i1", "bitbucket", "octo")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Unlink removes the identity", func(t *testing.T) {
+		resp := it.Post(t, "/users/identities/unlink", map[string]any{"user_id": "i1", "provider": "slack"})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/resolve?provider=slack&external_id=U012ABC")
+		defer resp.Body.Close()
+		var body resolveResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Empty(t, body.Identities)
+
+		resp = it.Post(t, "/users/identities/unlink", map[string]any{"user_id": "i1", "provider": "slack"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Anonymization removes identities", func(t *testing.T) {
+		resp := it.Post(t, "/users/offboard", map[string]any{"user_id": "i1", "anonymize": true})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = it.Get(t, "/users/resolve?provider=github&external_id=octocat-new")
+		defer resp.Body.Close()
+		var body resolveResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Empty(t, body.Identities)
+	})
+}
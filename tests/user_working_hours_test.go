@@ -0,0 +1,119 @@
+// tests/user_working_hours_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserWorkingHours(t *testing.T) {
+	it := New(t)
+
+	it.Post(t, "/team/add", map[string]any{
+		"team_name": "global",
+		"members": []map[string]any{
+			{"user_id": "msk", "username": "Ivan", "is_active": true, "timezone": "Europe/Moscow"},
+			{"user_id": "sf", "username": "John", "is_active": true},
+		},
+	})
+
+	t.Run("Set working hours", func(t *testing.T) {
+		resp := it.Post(t, "/users/setWorkingHours", map[string]any{
+			"user_id":         "sf",
+			"timezone":        "America/Los_Angeles",
+			"work_start_hour": 10,
+			"work_end_hour":   19,
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			User struct {
+				Timezone      string `json:"timezone"`
+				WorkStartHour int    `json:"work_start_hour"`
+				WorkEndHour   int    `json:"work_end_hour"`
+			} `json:"user"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, "America/Los_Angeles", result.User.Timezone)
+		assert.Equal(t, 10, result.User.WorkStartHour)
+		assert.Equal(t, 19, result.User.WorkEndHour)
+	})
+
+	t.Run("Team members keep their timezone", func(t *testing.T) {
+		resp := it.Get(t, "/team/get?team_name=global")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Members []struct {
+				UserID   string `json:"user_id"`
+				Timezone string `json:"timezone"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		timezones := map[string]string{}
+		for _, m := range result.Members {
+			timezones[m.UserID] = m.Timezone
+		}
+		assert.Equal(t, "Europe/Moscow", timezones["msk"])
+		assert.Equal(t, "America/Los_Angeles", timezones["sf"])
+	})
+
+	t.Run("Unknown timezone → 400", func(t *testing.T) {
+		resp := it.Post(t, "/users/setWorkingHours", map[string]any{
+			"user_id":         "msk",
+			"timezone":        "Mars/Olympus",
+			"work_start_hour": 9,
+			"work_end_hour":   18,
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Non-existing user → 404", func(t *testing.T) {
+		resp := it.Post(t, "/users/setWorkingHours", map[string]any{
+			"user_id":         "ghost",
+			"timezone":        "UTC",
+			"work_start_hour": 9,
+			"work_end_hour":   18,
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Moving to another team keeps unspecified fields", func(t *testing.T) {
+		resp := it.Post(t, "/team/add", map[string]any{
+			"team_name":  "night",
+			"allow_move": true,
+			"members": []map[string]any{
+				{"user_id": "sf", "username": "John", "is_active": true, "skills": []string{"go"}},
+			},
+		})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = it.Get(t, "/team/get?team_name=night")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Members []struct {
+				Timezone      string   `json:"timezone"`
+				WorkStartHour int      `json:"work_start_hour"`
+				WorkEndHour   int      `json:"work_end_hour"`
+				Skills        []string `json:"skills"`
+			} `json:"members"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Len(t, result.Members, 1)
+		assert.Equal(t, "America/Los_Angeles", result.Members[0].Timezone)
+		assert.Equal(t, 10, result.Members[0].WorkStartHour)
+		assert.Equal(t, 19, result.Members[0].WorkEndHour)
+		assert.Equal(t, []string{"go"}, result.Members[0].Skills)
+	})
+}